@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Autoescalado de pools: una tarea registrada con WithAutoscale ajusta sus
+// workers entre Min y Max. Cada Interval se evalúa:
+//
+//   - alta: todos los workers ocupados y la cola larga (QueuePerWorker jobs
+//     por worker) o con un job esperando más de MaxWait, siempre que la CPU
+//     del host no supere MaxCPU;
+//   - baja: cola vacía y workers ociosos desde hace IdleTimeout.
+//
+// Los cooldowns evitan oscilar: tras un alta no hay otra hasta UpCooldown
+// ni bajas hasta DownCooldown.
+
+// ScalePolicy configura el autoescalado. Los campos en cero toman el valor
+// por defecto indicado.
+type ScalePolicy struct {
+	Min, Max       int
+	Interval       time.Duration // evaluación (1s)
+	QueuePerWorker int           // jobs en cola por worker que disparan un alta (2)
+	MaxWait        time.Duration // espera del job más antiguo que dispara un alta (500ms)
+	MaxCPU         float64       // 0..1: uso de CPU del host sobre el que no se agregan workers (0.9)
+	UpCooldown     time.Duration // entre altas (2s)
+	DownCooldown   time.Duration // tras un alta o una baja, antes de la próxima baja (30s)
+	IdleTimeout    time.Duration // ociosidad que retira a un worker (30s)
+}
+
+// withDefaults completa los campos en cero y acota Min/Max.
+func (p ScalePolicy) withDefaults() ScalePolicy {
+	if p.Min < 1 {
+		p.Min = 1
+	}
+	if p.Max < p.Min {
+		p.Max = p.Min
+	}
+	if p.Interval <= 0 {
+		p.Interval = time.Second
+	}
+	if p.QueuePerWorker <= 0 {
+		p.QueuePerWorker = 2
+	}
+	if p.MaxWait <= 0 {
+		p.MaxWait = 500 * time.Millisecond
+	}
+	if p.MaxCPU <= 0 {
+		p.MaxCPU = 0.9
+	}
+	if p.UpCooldown <= 0 {
+		p.UpCooldown = 2 * time.Second
+	}
+	if p.DownCooldown <= 0 {
+		p.DownCooldown = 30 * time.Second
+	}
+	if p.IdleTimeout <= 0 {
+		p.IdleTimeout = 30 * time.Second
+	}
+	return p
+}
+
+// clamp lleva n al rango [Min, Max].
+func (p ScalePolicy) clamp(n int) int {
+	if n < p.Min {
+		return p.Min
+	}
+	if n > p.Max {
+		return p.Max
+	}
+	return n
+}
+
+// ScaleEvent registra un cambio de tamaño de un pool.
+type ScaleEvent struct {
+	Time   time.Time `json:"time"`
+	From   int       `json:"from"`
+	To     int       `json:"to"`
+	Reason string    `json:"reason"`
+}
+
+// maxScaleEvents es cuántos eventos recientes se conservan por pool.
+const maxScaleEvents = 20
+
+// autoscaler evalúa un pool periódicamente y guarda sus eventos.
+type autoscaler struct {
+	policy ScalePolicy
+
+	mu               sync.Mutex
+	lastUp, lastDown time.Time
+	ups, downs       int64
+	events           []ScaleEvent // los más recientes, del más viejo al más nuevo
+}
+
+func newAutoscaler(p ScalePolicy) *autoscaler {
+	return &autoscaler{policy: p.withDefaults()}
+}
+
+func (a *autoscaler) run(pool *WorkerPool) {
+	t := time.NewTicker(a.policy.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-pool.StopChan:
+			return
+		case <-t.C:
+			a.evaluate(pool, time.Now())
+		}
+	}
+}
+
+// evaluate decide y aplica un alta o una baja.
+func (a *autoscaler) evaluate(pool *WorkerPool, now time.Time) {
+	p := a.policy
+	size := pool.Size()
+	queued := pool.Queue.Len()
+	wait := pool.Queue.OldestWait()
+	busy := int(atomic.LoadInt64(&pool.Active)) >= size
+	cpu, cpuOK := hostCPU.utilization() // muestrea en cada evaluación
+
+	a.mu.Lock()
+	upReady := now.Sub(a.lastUp) >= p.UpCooldown
+	downReady := now.Sub(a.lastUp) >= p.DownCooldown && now.Sub(a.lastDown) >= p.DownCooldown
+	a.mu.Unlock()
+
+	switch {
+	case size < p.Min:
+		pool.grow(p.Min - size)
+		a.record(pool.Name, now, size, p.Min, "por debajo del mínimo", true)
+
+	case size < p.Max && upReady && busy && queued > 0 &&
+		(queued >= p.QueuePerWorker*size || wait >= p.MaxWait):
+		if cpuOK && cpu > p.MaxCPU {
+			return
+		}
+		step := queued / p.QueuePerWorker
+		if step < 1 {
+			step = 1
+		}
+		to := p.clamp(size + step)
+		pool.grow(to - size)
+		a.record(pool.Name, now, size, to, fmt.Sprintf("cola %d, espera %v", queued, wait.Round(time.Millisecond)), true)
+
+	case size > p.Min && downReady && queued == 0:
+		if n := pool.retireIdle(size-p.Min, p.IdleTimeout); n > 0 {
+			a.record(pool.Name, now, size, size-n, fmt.Sprintf("%d workers ociosos", n), false)
+		}
+	}
+}
+
+func (a *autoscaler) record(pool string, now time.Time, from, to int, reason string, up bool) {
+	fmt.Printf("[Autoscale:%s] %d -> %d workers (%s)\n", pool, from, to, reason)
+	a.mu.Lock()
+	if up {
+		a.lastUp = now
+		a.ups++
+	} else {
+		a.lastDown = now
+		a.downs++
+	}
+	a.events = append(a.events, ScaleEvent{Time: now, From: from, To: to, Reason: reason})
+	if len(a.events) > maxScaleEvents {
+		a.events = a.events[len(a.events)-maxScaleEvents:]
+	}
+	a.mu.Unlock()
+}
+
+// stats son las métricas del autoescalado que se incluyen en Stats del pool.
+func (a *autoscaler) stats() map[string]any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return map[string]any{
+		"min":         a.policy.Min,
+		"max":         a.policy.Max,
+		"scale_ups":   a.ups,
+		"scale_downs": a.downs,
+		"events":      append([]ScaleEvent(nil), a.events...),
+	}
+}
+
+// grow agrega n workers.
+func (p *WorkerPool) grow(n int) {
+	if n <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := 0; i < n; i++ {
+		p.addWorkerLocked()
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Uso de CPU del host
+// -----------------------------------------------------------------------------
+
+// cpuSampler calcula el uso de CPU entre muestras de cpuTimes. Los pools
+// comparten el sampler: una muestra reciente se reutiliza.
+type cpuSampler struct {
+	mu          sync.Mutex
+	at          time.Time
+	idle, total uint64
+	last        float64
+	ok          bool
+}
+
+// cpuMinSample es el intervalo mínimo entre muestras.
+const cpuMinSample = 500 * time.Millisecond
+
+var hostCPU = &cpuSampler{}
+
+// utilization devuelve el uso (0..1) desde la muestra anterior. ok es
+// false si la plataforma no lo informa o aún no hay dos muestras.
+func (s *cpuSampler) utilization() (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.at) < cpuMinSample {
+		return s.last, s.ok
+	}
+	idle, total, err := cpuTimes()
+	if err != nil {
+		return 0, false
+	}
+	if !s.at.IsZero() && total > s.total {
+		s.last = 1 - float64(idle-s.idle)/float64(total-s.total)
+		s.ok = true
+	}
+	s.at, s.idle, s.total = time.Now(), idle, total
+	return s.last, s.ok
+}
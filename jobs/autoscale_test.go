@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitUntil espera hasta 2s a que cond se cumpla
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("la condición no se cumplió a tiempo")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestAutoscale_UpAndDown prueba el alta con la cola llena y la baja de los workers ociosos
+func TestAutoscale_UpAndDown(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	m := NewManager("", time.Minute, time.Minute)
+	defer m.Close()
+	m.Register("burst", gatedTask(&runs, release), 1, 10, 5*time.Second, WithAutoscale(ScalePolicy{
+		Min: 1, Max: 3,
+		Interval:       time.Hour, // se evalúa a mano
+		QueuePerWorker: 1,
+		MaxCPU:         1,
+		UpCooldown:     time.Second,
+		DownCooldown:   time.Second,
+		IdleTimeout:    time.Millisecond,
+	}))
+	pool := m.pools["burst"]
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		id, _, err := m.Submit("burst", url.Values{"n": {strconv.Itoa(i)}}, PrioNormal)
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	waitUntil(t, func() bool { return runs.Load() == 1 && pool.Queue.Len() == 3 })
+
+	now := time.Now()
+	pool.scaler.evaluate(pool, now)
+	if size := pool.Size(); size != 3 {
+		t.Fatalf("tamaño tras el alta = %d; se esperaba 3 (el máximo)", size)
+	}
+	waitUntil(t, func() bool { return runs.Load() == 3 })
+
+	// en cooldown: ni altas ni bajas
+	pool.scaler.evaluate(pool, now.Add(100*time.Millisecond))
+	if size := pool.Size(); size != 3 {
+		t.Errorf("tamaño en cooldown = %d; se esperaba 3", size)
+	}
+
+	close(release)
+	for _, id := range ids {
+		waitStatus(t, m, id, StatusDone)
+	}
+	waitUntil(t, func() bool { return atomic.LoadInt64(&pool.Active) == 0 })
+	time.Sleep(5 * time.Millisecond) // supera IdleTimeout
+
+	pool.scaler.evaluate(pool, now.Add(2*time.Second))
+	if size := pool.Size(); size != 1 {
+		t.Errorf("tamaño tras la baja = %d; se esperaba 1 (el mínimo)", size)
+	}
+
+	st := pool.Stats()["autoscale"].(map[string]any)
+	events := st["events"].([]ScaleEvent)
+	if st["scale_ups"] != int64(1) || st["scale_downs"] != int64(1) || len(events) != 2 ||
+		events[0].From != 1 || events[0].To != 3 || events[1].To != 1 {
+		t.Errorf("métricas = %+v", st)
+	}
+}
+
+// TestAutoscale_InitialSize prueba que el tamaño inicial respete Min y Max
+func TestAutoscale_InitialSize(t *testing.T) {
+	m := NewManager("", time.Minute, time.Minute)
+	defer m.Close()
+	m.Register("small", quickTask, 1, 4, time.Second, WithAutoscale(ScalePolicy{Min: 2, Max: 4, Interval: time.Hour}))
+	m.Register("big", quickTask, 8, 4, time.Second, WithAutoscale(ScalePolicy{Min: 1, Max: 4, Interval: time.Hour}))
+	if size := m.pools["small"].Size(); size != 2 {
+		t.Errorf("small: %d workers; se esperaban 2", size)
+	}
+	if size := m.pools["big"].Size(); size != 4 {
+		t.Errorf("big: %d workers; se esperaban 4", size)
+	}
+}
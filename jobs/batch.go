@@ -0,0 +1,286 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrBatchNotFound    = errors.New("lote no encontrado")
+	ErrBatchEmpty       = errors.New("el lote no tiene jobs")
+	ErrBatchTooLarge    = errors.New("el lote supera el máximo de jobs")
+	ErrBatchRejected    = errors.New("lote atómico rechazado")
+	ErrInvalidBatchMode = errors.New("modo de lote inválido (use atomic o partial)")
+)
+
+// Máximo de jobs por lote.
+const maxBatchItems = 1000
+
+// Modos de envío de un lote.
+const (
+	BatchPartial = "partial" // se encolan los jobs válidos; el resto informa su error
+	BatchAtomic  = "atomic"  // se encolan todos o ninguno
+)
+
+// BatchItem es un job dentro de un lote.
+type BatchItem struct {
+	Task   string            `json:"task"`
+	Params map[string]string `json:"params,omitempty"`
+	Prio   string            `json:"prio,omitempty"` // high | normal | low (vacío = normal)
+}
+
+// BatchSpec es el cuerpo de /jobs/batch.
+type BatchSpec struct {
+	Mode string      `json:"mode,omitempty"` // vacío = partial
+	Jobs []BatchItem `json:"jobs"`
+}
+
+// BatchItemResult informa, en el orden del envío, el job creado o el error.
+type BatchItemResult struct {
+	Index  int       `json:"index"`
+	JobID  string    `json:"job_id,omitempty"`
+	Status JobStatus `json:"status,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// BatchResult es la respuesta a un envío por lote.
+type BatchResult struct {
+	ID       string            `json:"batch_id"`
+	Mode     string            `json:"mode"`
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Items    []BatchItemResult `json:"items"`
+}
+
+// BatchStatus agrega el estado de los jobs de un lote. Los jobs eliminados
+// por TTL dejan de contarse.
+type BatchStatus struct {
+	ID       string            `json:"batch_id"`
+	Total    int               `json:"total"`
+	Counts   map[JobStatus]int `json:"counts"`
+	Finished bool              `json:"finished"` // todos en estado final
+	JobIDs   []string          `json:"job_ids"`
+}
+
+// SubmitBatch crea todos los jobs del lote con una sola escritura del
+// archivo de persistencia. En modo atómico, un job inválido o una cola
+// sin lugar rechazan el lote completo.
+func (m *Manager) SubmitBatch(spec BatchSpec) (*BatchResult, error) {
+	mode := spec.Mode
+	if mode == "" {
+		mode = BatchPartial
+	}
+	if mode != BatchPartial && mode != BatchAtomic {
+		return nil, ErrInvalidBatchMode
+	}
+	if len(spec.Jobs) == 0 {
+		return nil, ErrBatchEmpty
+	}
+	if len(spec.Jobs) > maxBatchItems {
+		return nil, fmt.Errorf("%w (%d)", ErrBatchTooLarge, maxBatchItems)
+	}
+
+	res := &BatchResult{ID: "batch-" + genID(), Mode: mode, Items: make([]BatchItemResult, len(spec.Jobs))}
+	jobs := make([]*Job, len(spec.Jobs))
+	pools := make([]*WorkerPool, len(spec.Jobs))
+
+	// Cada ítem se valida como un envío de /jobs/submit, antes del lock:
+	// estimar puede leer archivos (ver limits.go)
+	checks := make([]error, len(spec.Jobs))
+	for i, it := range spec.Jobs {
+		jobs[i], checks[i] = m.newSubmitJob(it.Task, it.Params, ParsePriority(it.Prio), nil)
+	}
+
+	// Validar tareas y registrar los jobs válidos en el mapa con un solo lock
+	invalid := false
+	m.mu.Lock()
+	for i, it := range spec.Jobs {
+		res.Items[i].Index = i
+		pool, ok := m.pools[it.Task]
+		switch {
+		case !ok:
+			res.Items[i].Error = fmt.Sprintf("%v: %s", ErrTaskNotFound, it.Task)
+		case checks[i] != nil:
+			res.Items[i].Error = checks[i].Error()
+		default:
+			jobs[i].Batch = res.ID
+			pools[i] = pool
+			continue
+		}
+		jobs[i] = nil
+		invalid = true
+	}
+	if mode == BatchAtomic && invalid {
+		m.mu.Unlock()
+		return m.rejectBatch(res, ErrBatchRejected), nil
+	}
+	// Como en /jobs/submit, un job de una tarea con coalescing se une a un
+	// líder en curso (también a uno anterior del mismo lote) y no se encola
+	leaders := make([]bool, len(spec.Jobs))
+	for i, j := range jobs {
+		if j != nil && !m.attachOrLeadLocked(j) {
+			leaders[i] = true
+			m.jobs[j.ID] = j
+			m.idx.add(j)
+		}
+	}
+	m.mu.Unlock()
+
+	if mode == BatchAtomic {
+		var lj []*Job
+		var lp []*WorkerPool
+		for i, j := range jobs {
+			if leaders[i] {
+				lj, lp = append(lj, j), append(lp, pools[i])
+			}
+		}
+		if err := pushAll(batchQueues(lj, lp)); err != nil {
+			m.unregisterBatch(jobs, leaders)
+			return m.rejectBatch(res, fmt.Errorf("%w: %v", ErrBatchRejected, err)), nil
+		}
+	} else {
+		for i, j := range jobs {
+			if !leaders[i] {
+				continue
+			}
+			if err := pools[i].Queue.Push(j); err != nil {
+				m.mu.Lock()
+				delete(m.jobs, j.ID)
+				m.idx.remove(j)
+				m.mu.Unlock()
+				// un seguidor que se unió entretanto toma su lugar
+				m.promoteFollower(*j)
+				res.Items[i].Error = err.Error()
+				jobs[i] = nil
+			}
+		}
+	}
+
+	var ids []string
+	m.mu.RLock()
+	for i, j := range jobs {
+		if j == nil {
+			res.Rejected++
+			continue
+		}
+		ids = append(ids, j.ID)
+		res.Accepted++
+		res.Items[i].JobID = j.ID
+		res.Items[i].Status = j.Status
+	}
+	m.mu.RUnlock()
+	m.persist(ids...)
+	fmt.Printf("[Manager] lote %s (%s): %d aceptados, %d rechazados\n", res.ID, mode, res.Accepted, res.Rejected)
+	return res, nil
+}
+
+// unregisterBatch retira del mapa los jobs de un lote atómico rechazado. Un
+// envío de afuera que se unió entretanto a uno de sus líderes toma su lugar.
+func (m *Manager) unregisterBatch(jobs []*Job, leaders []bool) {
+	m.mu.Lock()
+	for _, j := range jobs {
+		delete(m.jobs, j.ID)
+		m.idx.remove(j)
+	}
+	m.mu.Unlock()
+	for i, j := range jobs {
+		if leaders[i] {
+			m.promoteFollower(*j)
+		}
+	}
+}
+
+// batchQueues agrupa los jobs por cola, con las colas ordenadas por nombre
+// de pool para que dos lotes concurrentes las bloqueen en el mismo orden.
+func batchQueues(jobs []*Job, pools []*WorkerPool) ([]*PriorityQueue, map[*PriorityQueue][]*Job) {
+	byQueue := map[*PriorityQueue][]*Job{}
+	names := map[*PriorityQueue]string{}
+	for i, j := range jobs {
+		q := pools[i].Queue
+		byQueue[q] = append(byQueue[q], j)
+		names[q] = pools[i].Name
+	}
+	order := make([]*PriorityQueue, 0, len(byQueue))
+	for q := range byQueue {
+		order = append(order, q)
+	}
+	sort.Slice(order, func(a, b int) bool { return names[order[a]] < names[order[b]] })
+	return order, byQueue
+}
+
+// rejectBatch marca todos los ítems como rechazados; conserva el error
+// propio de cada ítem inválido.
+func (m *Manager) rejectBatch(res *BatchResult, err error) *BatchResult {
+	for i := range res.Items {
+		if res.Items[i].Error == "" {
+			res.Items[i].Error = err.Error()
+		}
+		res.Items[i].JobID, res.Items[i].Status = "", ""
+	}
+	res.Accepted, res.Rejected = 0, len(res.Items)
+	fmt.Printf("[Manager] lote %s rechazado: %v\n", res.ID, err)
+	return res
+}
+
+// batchJobsLocked devuelve los jobs del lote ordenados por creación. Requiere m.mu tomado.
+func (m *Manager) batchJobsLocked(id string) []*Job {
+	var out []*Job
+	for _, j := range m.jobs {
+		if j.Batch == id {
+			out = append(out, j)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].ID < out[b].ID })
+	return out
+}
+
+// GetBatch agrega el estado de los jobs del lote.
+func (m *Manager) GetBatch(id string) (*BatchStatus, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	jobs := m.batchJobsLocked(id)
+	if len(jobs) == 0 {
+		return nil, ErrBatchNotFound
+	}
+	st := &BatchStatus{ID: id, Total: len(jobs), Counts: map[JobStatus]int{}, Finished: true}
+	for _, j := range jobs {
+		st.Counts[j.Status]++
+		st.JobIDs = append(st.JobIDs, j.ID)
+		if !j.Status.Terminal() {
+			st.Finished = false
+		}
+	}
+	return st, nil
+}
+
+// CancelBatch cancela los jobs del lote que todavía no terminaron, con una
+// sola escritura del archivo de persistencia. Devuelve cuántos canceló.
+func (m *Manager) CancelBatch(id string) (int, error) {
+	m.mu.RLock()
+	var ids []string
+	jobs := m.batchJobsLocked(id)
+	for _, j := range jobs {
+		if !j.Status.Terminal() {
+			ids = append(ids, j.ID)
+		}
+	}
+	m.mu.RUnlock()
+	if len(jobs) == 0 {
+		return 0, ErrBatchNotFound
+	}
+
+	var canceled []Job
+	var canceledIDs []string
+	for _, jobID := range ids {
+		if final, err := m.cancel(jobID); err == nil {
+			canceled = append(canceled, final)
+			canceledIDs = append(canceledIDs, jobID)
+		}
+	}
+	m.persist(canceledIDs...)
+	for _, j := range canceled {
+		m.jobFinished(j)
+	}
+	return len(canceled), nil
+}
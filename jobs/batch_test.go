@@ -0,0 +1,126 @@
+package jobs
+
+import (
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestManager_BatchPartial prueba que los jobs válidos se encolen y los inválidos informen su error
+func TestManager_BatchPartial(t *testing.T) {
+	m := NewManager("", 1*time.Minute, 1*time.Minute)
+	m.Register("block", blockingTask, 1, 1, 10*time.Second)
+	defer m.Close()
+
+	// El único worker queda ocupado y la clase normal tiene un solo lugar
+	res, err := m.SubmitBatch(BatchSpec{Jobs: []BatchItem{{Task: "block"}}})
+	if err != nil || res.Accepted != 1 {
+		t.Fatalf("SubmitBatch = %+v, %v; se esperaba 1 aceptado", res, err)
+	}
+	first := res.Items[0].JobID
+	waitStatus(t, m, first, StatusRunning)
+
+	res, err = m.SubmitBatch(BatchSpec{Jobs: []BatchItem{
+		{Task: "block"}, {Task: "nope"}, {Task: "block"}, {Task: "block", Prio: "high"},
+	}})
+	if err != nil {
+		t.Fatalf("SubmitBatch: %v", err)
+	}
+	if res.Accepted != 2 || res.Rejected != 2 {
+		t.Errorf("aceptados/rechazados = %d/%d; se esperaba 2/2 (%+v)", res.Accepted, res.Rejected, res.Items)
+	}
+	if res.Items[1].Error == "" || res.Items[2].Error != ErrBackpressure.Error() {
+		t.Errorf("errores por ítem = %+v; se esperaba task no registrada y backpressure", res.Items)
+	}
+
+	st, err := m.GetBatch(res.ID)
+	if err != nil || st.Total != 2 || st.Finished || st.Counts[StatusQueued] != 2 {
+		t.Fatalf("GetBatch = %+v, %v; se esperaban 2 jobs en cola", st, err)
+	}
+
+	if n, err := m.CancelBatch(res.ID); err != nil || n != 2 {
+		t.Errorf("CancelBatch = %d, %v; se esperaban 2 cancelados", n, err)
+	}
+	if st, _ := m.GetBatch(res.ID); st.Counts[StatusCanceled] != 2 || !st.Finished {
+		t.Errorf("GetBatch tras cancelar = %+v; se esperaban 2 cancelados", st)
+	}
+	m.Cancel(first)
+	if _, err := m.GetBatch("batch-x"); err != ErrBatchNotFound {
+		t.Errorf("GetBatch(inexistente) = %v; se esperaba ErrBatchNotFound", err)
+	}
+}
+
+// TestManager_BatchAtomic prueba que un lote atómico entre completo o no entre
+func TestManager_BatchAtomic(t *testing.T) {
+	m := NewManager("", 1*time.Minute, 1*time.Minute)
+	m.Register("block", blockingTask, 1, 2, 10*time.Second)
+	m.Register("quick", quickTask, 1, 4, 1*time.Second)
+	defer m.Close()
+
+	for name, items := range map[string][]BatchItem{
+		"tarea inválida": {{Task: "quick"}, {Task: "nope"}},
+		"sin lugar":      {{Task: "quick"}, {Task: "block"}, {Task: "block"}, {Task: "block"}, {Task: "block"}},
+	} {
+		res, err := m.SubmitBatch(BatchSpec{Mode: BatchAtomic, Jobs: items})
+		if err != nil {
+			t.Fatalf("%s: SubmitBatch: %v", name, err)
+		}
+		if res.Accepted != 0 || res.Rejected != len(items) {
+			t.Errorf("%s: aceptados/rechazados = %d/%d; se esperaba 0/%d", name, res.Accepted, res.Rejected, len(items))
+		}
+		if n := len(m.JobsSnapshot()); n != 0 {
+			t.Errorf("%s: quedaron %d jobs; un lote rechazado no debe crear ninguno", name, n)
+		}
+	}
+
+	res, _ := m.SubmitBatch(BatchSpec{Mode: BatchAtomic, Jobs: []BatchItem{{Task: "quick"}, {Task: "quick", Prio: "high"}}})
+	if res.Accepted != 2 {
+		t.Fatalf("lote válido: %+v; se esperaban 2 aceptados", res)
+	}
+	waitStatus(t, m, res.Items[1].JobID, StatusDone)
+
+	if _, err := m.SubmitBatch(BatchSpec{Mode: "todo"}); err != ErrInvalidBatchMode {
+		t.Errorf("SubmitBatch(modo inválido) = %v; se esperaba ErrInvalidBatchMode", err)
+	}
+}
+
+// TestManager_BatchCoalesce prueba que los jobs de un lote se unan a un
+// líder en curso como los de /jobs/submit y lo sean para envíos posteriores
+func TestManager_BatchCoalesce(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	m := NewManager("", 1*time.Minute, 1*time.Minute)
+	m.Register("gated", gatedTask(&runs, release), 2, 10, 5*time.Second, WithCoalescing(true))
+	defer m.Close()
+
+	// prio en los parámetros enruta el envío: no distingue a los ítems
+	res, err := m.SubmitBatch(BatchSpec{Jobs: []BatchItem{
+		{Task: "gated", Params: map[string]string{"n": "1"}},
+		{Task: "gated", Params: map[string]string{"n": "1", "prio": "high"}},
+		{Task: "gated", Params: map[string]string{"n": "2"}},
+	}})
+	if err != nil || res.Accepted != 3 {
+		t.Fatalf("SubmitBatch = %+v, %v; se esperaban 3 aceptados", res, err)
+	}
+	leader := res.Items[0].JobID
+	if j, _ := m.GetStatus(res.Items[1].JobID); j.CoalescedWith != leader || j.Params["prio"] != "" {
+		t.Errorf("ítem repetido = coalesced_with %q, params %v; se esperaba unido a %s sin prio", j.CoalescedWith, j.Params, leader)
+	}
+	if j, _ := m.GetStatus(res.Items[2].JobID); j.CoalescedWith != "" {
+		t.Errorf("ítem distinto unido a %s; se esperaba un líder propio", j.CoalescedWith)
+	}
+
+	later, _, _ := m.Submit("gated", url.Values{"n": {"1"}}, PrioNormal)
+	if j, _ := m.GetStatus(later); j.CoalescedWith != leader {
+		t.Errorf("envío posterior unido a %q; se esperaba el líder del lote %s", j.CoalescedWith, leader)
+	}
+
+	close(release)
+	for _, id := range []string{res.Items[1].JobID, res.Items[2].JobID, later} {
+		waitStatus(t, m, id, StatusDone)
+	}
+	if n := runs.Load(); n != 2 {
+		t.Errorf("ejecuciones = %d; se esperaban 2", n)
+	}
+}
@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Presupuesto global de CPU. Cada tarea tiene su propio pool, así que la
+// suma de workers puede superar a los núcleos. Los jobs de las tareas de
+// clase cpu comparten un conjunto de tokens (GOMAXPROCS por defecto): un
+// worker toma uno después de sacar el job de la cola y lo devuelve al
+// terminar. Las tareas de clase io no pasan por el presupuesto.
+//
+// Si hay varias tareas esperando, el token libre va a la de menor paso
+// (stride scheduling): cada concesión le suma 1/peso, así que una tarea de
+// peso 2 recibe el doble de turnos que una de peso 1 y ninguna se queda
+// sin turno. Una tarea que vuelve de estar ociosa arranca desde el paso
+// global y no acumula crédito.
+
+// TaskClass indica qué recurso limita a una tarea.
+type TaskClass string
+
+const (
+	IOBound  TaskClass = "io"  // sin límite global (por defecto)
+	CPUBound TaskClass = "cpu" // consume tokens del presupuesto de CPU
+)
+
+// WithCPUBudget fija la cantidad de jobs de clase cpu que pueden ejecutarse
+// a la vez entre todos los pools (por defecto GOMAXPROCS).
+func WithCPUBudget(n int) ManagerOption {
+	return func(m *Manager) {
+		if n > 0 {
+			m.cpu = newCPUBudget(n)
+		}
+	}
+}
+
+type cpuBudget struct {
+	mu       sync.Mutex
+	capacity int
+	inUse    int
+	pass     float64 // paso de la última concesión
+	tasks    map[string]*budgetTask
+}
+
+type budgetTask struct {
+	weight  int
+	pass    float64
+	running int
+	granted int64
+	waiters []chan struct{} // en orden de llegada; se cierran al conceder
+}
+
+func newCPUBudget(capacity int) *cpuBudget {
+	if capacity <= 0 {
+		capacity = runtime.GOMAXPROCS(0)
+	}
+	return &cpuBudget{capacity: capacity, tasks: make(map[string]*budgetTask)}
+}
+
+// register incorpora una tarea con su peso (mínimo 1).
+func (b *cpuBudget) register(task string, weight int) {
+	if weight < 1 {
+		weight = 1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tasks[task] = &budgetTask{weight: weight, pass: b.pass}
+}
+
+// acquire bloquea hasta obtener un token para task. Devuelve false si stop
+// se cierra antes.
+func (b *cpuBudget) acquire(task string, stop <-chan struct{}) bool {
+	b.mu.Lock()
+	t := b.tasks[task]
+	// Solo hay tareas esperando con el presupuesto agotado: release les
+	// entrega cada token que se libera.
+	if b.inUse < b.capacity {
+		b.grantLocked(t)
+		b.mu.Unlock()
+		return true
+	}
+	w := make(chan struct{})
+	t.waiters = append(t.waiters, w)
+	b.mu.Unlock()
+
+	select {
+	case <-w:
+		return true
+	case <-stop:
+		b.mu.Lock()
+		for i, other := range t.waiters {
+			if other == w {
+				t.waiters = append(t.waiters[:i:i], t.waiters[i+1:]...)
+				b.mu.Unlock()
+				return false
+			}
+		}
+		b.mu.Unlock()
+		// el token llegó a la vez que stop: se devuelve
+		b.release(task)
+		return false
+	}
+}
+
+// release devuelve el token de task y lo entrega a la tarea en espera de
+// menor paso.
+func (b *cpuBudget) release(task string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inUse--
+	b.tasks[task].running--
+
+	var next *budgetTask
+	var nextName string
+	for name, t := range b.tasks {
+		if len(t.waiters) == 0 {
+			continue
+		}
+		if next == nil || t.pass < next.pass || (t.pass == next.pass && name < nextName) {
+			next, nextName = t, name
+		}
+	}
+	if next == nil {
+		return
+	}
+	w := next.waiters[0]
+	next.waiters = next.waiters[1:]
+	b.grantLocked(next)
+	close(w)
+}
+
+// grantLocked cuenta un token concedido a t y avanza su paso. Requiere b.mu.
+func (b *cpuBudget) grantLocked(t *budgetTask) {
+	if t.pass < b.pass {
+		t.pass = b.pass
+	}
+	b.pass = t.pass
+	t.pass += 1 / float64(t.weight)
+	t.running++
+	t.granted++
+	b.inUse++
+}
+
+// stats son las métricas de task que se incluyen en Stats de su pool.
+func (b *cpuBudget) stats(task string) map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.tasks[task]
+	return map[string]any{
+		"weight":          t.weight,
+		"running":         t.running,
+		"waiting":         len(t.waiters),
+		"granted":         t.granted,
+		"budget_in_use":   b.inUse,
+		"budget_capacity": b.capacity,
+	}
+}
@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCPUBudget_WeightedShare prueba que los tokens se repartan según el peso de cada tarea
+func TestCPUBudget_WeightedShare(t *testing.T) {
+	b := newCPUBudget(1)
+	b.register("heavy", 2)
+	b.register("light", 1)
+
+	// el único token está tomado: todo lo demás espera
+	if !b.acquire("light", nil) {
+		t.Fatal("acquire con presupuesto libre devolvió false")
+	}
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		for _, task := range []string{"heavy", "light"} {
+			wg.Add(1)
+			go func(task string) {
+				defer wg.Done()
+				b.acquire(task, nil)
+				mu.Lock()
+				order = append(order, task)
+				mu.Unlock()
+				b.release(task)
+			}(task)
+		}
+	}
+	waitUntil(t, func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return len(b.tasks["heavy"].waiters) == 6 && len(b.tasks["light"].waiters) == 6
+	})
+	b.release("light")
+	wg.Wait()
+
+	// en los primeros 9 turnos, heavy (peso 2) recibe el doble que light
+	heavy := 0
+	for _, task := range order[:9] {
+		if task == "heavy" {
+			heavy++
+		}
+	}
+	if heavy != 6 {
+		t.Errorf("orden = %v; heavy recibió %d de los primeros 9 turnos, se esperaban 6", order, heavy)
+	}
+}
+
+// TestCPUBudget_Stop prueba que un worker esperando un token se libere al cerrarse stop
+func TestCPUBudget_Stop(t *testing.T) {
+	b := newCPUBudget(1)
+	b.register("a", 1)
+	b.acquire("a", nil)
+
+	stop := make(chan struct{})
+	done := make(chan bool)
+	go func() { done <- b.acquire("a", stop) }()
+	time.Sleep(20 * time.Millisecond)
+	close(stop)
+	if <-done {
+		t.Fatal("acquire devolvió true tras cerrar stop")
+	}
+	b.release("a")
+	if !b.acquire("a", nil) {
+		t.Error("el token no volvió al presupuesto")
+	}
+}
+
+// TestManager_CPUBudget prueba que el presupuesto limite los jobs cpu entre pools y deje pasar a los io
+func TestManager_CPUBudget(t *testing.T) {
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	cpuTask := func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		<-release
+		return "ok", nil
+	}
+
+	m := NewManager("", time.Minute, time.Minute, WithCPUBudget(2))
+	m.Register("a", cpuTask, 3, 10, 5*time.Second, WithClass(CPUBound, 1))
+	m.Register("b", cpuTask, 3, 10, 5*time.Second, WithClass(CPUBound, 1))
+	m.Register("io", quickTask, 1, 10, time.Second)
+	defer m.Close()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		for _, task := range []string{"a", "b"} {
+			id, _, _ := m.Submit(task, nil, PrioNormal)
+			ids = append(ids, id)
+		}
+	}
+	waitUntil(t, func() bool { return running.Load() == 2 })
+	id, _, _ := m.Submit("io", nil, PrioNormal)
+	waitStatus(t, m, id, StatusDone)
+
+	close(release)
+	for _, id := range ids {
+		waitStatus(t, m, id, StatusDone)
+	}
+	if peak.Load() != 2 {
+		t.Errorf("jobs cpu simultáneos = %d; se esperaba un máximo de 2", peak.Load())
+	}
+	stats := m.WorkerStats()["a"].(map[string]any)["cpu"].(map[string]any)
+	if stats["granted"].(int64) != 3 || stats["budget_capacity"].(int) != 2 {
+		t.Errorf("stats cpu = %v", stats)
+	}
+}
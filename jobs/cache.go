@@ -0,0 +1,296 @@
+package jobs
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CacheConfig dimensiona la caché de resultados.
+type CacheConfig struct {
+	MaxBytes      int64  // tamaño máximo en memoria (suma de los resultados serializados)
+	SpillDir      string // directorio para los resultados desalojados (vacío = sin spill)
+	SpillMaxBytes int64  // tamaño máximo en disco (0 = 4 veces MaxBytes)
+}
+
+// CacheStats son las métricas de la caché expuestas en /metrics.
+type CacheStats struct {
+	Entries    int     `json:"entries"`
+	Bytes      int64   `json:"bytes"`
+	MaxBytes   int64   `json:"max_bytes"`
+	SpillItems int     `json:"spill_entries"`
+	SpillBytes int64   `json:"spill_bytes"`
+	Hits       int64   `json:"hits"`
+	SpillHits  int64   `json:"spill_hits"` // incluidas en Hits
+	Misses     int64   `json:"misses"`
+	Evictions  int64   `json:"evictions"`
+	HitRate    float64 `json:"hit_rate"`
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+// ResultCache guarda resultados serializados indexados por contenido
+// (tarea + parámetros). En memoria desaloja por LRU según el tamaño total;
+// con SpillDir, lo desalojado pasa a disco y vuelve a memoria al pedirse.
+type ResultCache struct {
+	mu    sync.Mutex
+	cfg   CacheConfig
+	lru   *list.List // frente = usado más recientemente
+	items map[string]*list.Element
+	bytes int64
+
+	// spill en disco: key -> tamaño, con orden de llegada para desalojar
+	spill      map[string]int64
+	spillOrder []string
+	spillBytes int64
+
+	hits, spillHits, misses, evictions int64
+}
+
+func NewResultCache(cfg CacheConfig) *ResultCache {
+	if cfg.SpillDir != "" && cfg.SpillMaxBytes <= 0 {
+		cfg.SpillMaxBytes = 4 * cfg.MaxBytes
+	}
+	c := &ResultCache{
+		cfg:   cfg,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+		spill: make(map[string]int64),
+	}
+	if cfg.SpillDir != "" {
+		if err := os.MkdirAll(cfg.SpillDir, 0755); err != nil {
+			fmt.Printf("[Cache] spill deshabilitado: %v\n", err)
+			c.cfg.SpillDir = ""
+		} else {
+			c.loadSpill()
+		}
+	}
+	return c
+}
+
+// CacheKey resume tarea y parámetros de forma independiente de su orden.
+func CacheKey(task string, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00", task)
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\x00", k, params[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get devuelve el resultado guardado bajo key, buscándolo también en el spill.
+func (c *ResultCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.lru.MoveToFront(el)
+		c.hits++
+		return el.Value.(*cacheEntry).data, true
+	}
+	if _, ok := c.spill[key]; ok {
+		data, err := os.ReadFile(c.spillPath(key))
+		c.dropSpillLocked(key)
+		if err == nil {
+			c.hits++
+			c.spillHits++
+			c.putLocked(key, data)
+			return data, true
+		}
+	}
+	c.misses++
+	return nil, false
+}
+
+// Put guarda un resultado. Los que superan el tamaño total no se guardan.
+func (c *ResultCache) Put(key string, data []byte) {
+	if int64(len(data)) > c.cfg.MaxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.bytes -= int64(len(el.Value.(*cacheEntry).data))
+		c.lru.Remove(el)
+		delete(c.items, key)
+	}
+	if _, ok := c.spill[key]; ok {
+		c.dropSpillLocked(key)
+	}
+	c.putLocked(key, data)
+}
+
+func (c *ResultCache) putLocked(key string, data []byte) {
+	c.items[key] = c.lru.PushFront(&cacheEntry{key: key, data: data})
+	c.bytes += int64(len(data))
+	for c.bytes > c.cfg.MaxBytes {
+		el := c.lru.Back()
+		e := el.Value.(*cacheEntry)
+		c.lru.Remove(el)
+		delete(c.items, e.key)
+		c.bytes -= int64(len(e.data))
+		c.evictions++
+		c.spillLocked(e)
+	}
+}
+
+// Stats devuelve un snapshot de las métricas.
+func (c *ResultCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := CacheStats{
+		Entries:    len(c.items),
+		Bytes:      c.bytes,
+		MaxBytes:   c.cfg.MaxBytes,
+		SpillItems: len(c.spill),
+		SpillBytes: c.spillBytes,
+		Hits:       c.hits,
+		SpillHits:  c.spillHits,
+		Misses:     c.misses,
+		Evictions:  c.evictions,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		st.HitRate = float64(c.hits) / float64(total)
+	}
+	return st
+}
+
+// -----------------------------------------------------------------------------
+// Spill en disco: un archivo <key>.json por resultado
+// -----------------------------------------------------------------------------
+
+func (c *ResultCache) spillPath(key string) string {
+	return filepath.Join(c.cfg.SpillDir, key+".json")
+}
+
+// loadSpill recupera el índice del spill de una ejecución anterior.
+func (c *ResultCache) loadSpill() {
+	entries, err := os.ReadDir(c.cfg.SpillDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		key, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			c.spill[key] = info.Size()
+			c.spillOrder = append(c.spillOrder, key)
+			c.spillBytes += info.Size()
+		}
+	}
+	c.trimSpillLocked()
+}
+
+func (c *ResultCache) spillLocked(e *cacheEntry) {
+	if c.cfg.SpillDir == "" {
+		return
+	}
+	if err := os.WriteFile(c.spillPath(e.key), e.data, 0644); err != nil {
+		fmt.Printf("[Cache] Error escribiendo spill: %v\n", err)
+		return
+	}
+	c.spill[e.key] = int64(len(e.data))
+	c.spillOrder = append(c.spillOrder, e.key)
+	c.spillBytes += int64(len(e.data))
+	c.trimSpillLocked()
+}
+
+// trimSpillLocked borra los archivos más antiguos hasta respetar SpillMaxBytes.
+func (c *ResultCache) trimSpillLocked() {
+	for c.spillBytes > c.cfg.SpillMaxBytes && len(c.spillOrder) > 0 {
+		c.dropSpillLocked(c.spillOrder[0])
+	}
+}
+
+func (c *ResultCache) dropSpillLocked(key string) {
+	for i, k := range c.spillOrder {
+		if k == key {
+			c.spillOrder = append(c.spillOrder[:i], c.spillOrder[i+1:]...)
+			break
+		}
+	}
+	c.spillBytes -= c.spill[key]
+	delete(c.spill, key)
+	os.Remove(c.spillPath(key))
+}
+
+// -----------------------------------------------------------------------------
+// Uso desde el Manager
+// -----------------------------------------------------------------------------
+
+// SetResultCache habilita la caché para las tareas registradas con
+// WithCache(true). Llamar antes de enviar jobs.
+func (m *Manager) SetResultCache(c *ResultCache) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cache = c
+}
+
+// CacheFor devuelve la caché si la tarea la usa, o nil. Las rutas
+// síncronas la comparten con los jobs bajo otro espacio de claves.
+func (m *Manager) CacheFor(task string) *ResultCache {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if tc, ok := m.tasks[task]; ok && tc.cache {
+		return m.cache
+	}
+	return nil
+}
+
+// CacheStats devuelve las métricas de la caché, o nil si no está habilitada.
+func (m *Manager) CacheStats() *CacheStats {
+	m.mu.RLock()
+	c := m.cache
+	m.mu.RUnlock()
+	if c == nil {
+		return nil
+	}
+	st := c.Stats()
+	return &st
+}
+
+// cachedResult busca el resultado de un job en la caché de su tarea.
+func (m *Manager) cachedResult(job *Job) (any, bool) {
+	c := m.CacheFor(job.Task)
+	if c == nil {
+		return nil, false
+	}
+	data, ok := c.Get(CacheKey(job.Task, job.Params))
+	if !ok {
+		return nil, false
+	}
+	var res any
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, false
+	}
+	return res, true
+}
+
+// storeResult guarda un resultado exitoso. Los resultados que informan un
+// error (clave "error") no se cachean: el reintento puede tener éxito.
+func (m *Manager) storeResult(job *Job, res any) {
+	c := m.CacheFor(job.Task)
+	if c == nil || resultError(res) != "" {
+		return
+	}
+	if data, err := json.Marshal(res); err == nil {
+		c.Put(CacheKey(job.Task, job.Params), data)
+	}
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestResultCache_LRU prueba el desalojo por tamaño y el orden LRU
+func TestResultCache_LRU(t *testing.T) {
+	c := NewResultCache(CacheConfig{MaxBytes: 10})
+	c.Put("a", []byte("aaaa"))
+	c.Put("b", []byte("bbbb"))
+	c.Get("a") // b pasa a ser el menos usado
+	c.Put("c", []byte("cccc"))
+
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("b debería haberse desalojado")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Errorf("a debería seguir en caché")
+	}
+	c.Put("big", make([]byte, 11)) // no entra: se ignora sin vaciar la caché
+
+	st := c.Stats()
+	if st.Entries != 2 || st.Bytes != 8 || st.Evictions != 1 || st.Hits != 2 || st.Misses != 1 {
+		t.Errorf("Stats = %+v", st)
+	}
+}
+
+// TestResultCache_Spill prueba que lo desalojado vuelva desde disco, también tras reiniciar
+func TestResultCache_Spill(t *testing.T) {
+	dir := t.TempDir()
+	c := NewResultCache(CacheConfig{MaxBytes: 4, SpillDir: dir})
+	c.Put("a", []byte("aaaa"))
+	c.Put("b", []byte("bbbb")) // a pasa a disco
+
+	if data, ok := c.Get("a"); !ok || string(data) != "aaaa" {
+		t.Fatalf("Get(a) = %q, %v; se esperaba recuperarlo del spill", data, ok)
+	}
+	if st := c.Stats(); st.SpillHits != 1 || st.SpillItems != 1 {
+		t.Errorf("Stats = %+v; se esperaba 1 spill hit y b en disco", st)
+	}
+
+	c = NewResultCache(CacheConfig{MaxBytes: 4, SpillDir: dir})
+	if data, ok := c.Get("b"); !ok || string(data) != "bbbb" {
+		t.Errorf("tras reiniciar Get(b) = %q, %v; se esperaba encontrarlo en disco", data, ok)
+	}
+}
+
+// TestManager_ResultCache prueba que un job repetido no vuelva a ejecutar la tarea
+func TestManager_ResultCache(t *testing.T) {
+	var runs atomic.Int32
+	count := func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		runs.Add(1)
+		if params["fail"] != "" {
+			return map[string]any{"error": "falló"}, nil
+		}
+		return map[string]any{"n": params["n"]}, nil
+	}
+
+	m := NewManager("", 1*time.Minute, 1*time.Minute)
+	m.SetResultCache(NewResultCache(CacheConfig{MaxBytes: 1 << 20}))
+	m.Register("count", count, 1, 10, time.Second, WithCache(true))
+	m.Register("nocache", count, 1, 10, time.Second)
+	defer m.Close()
+
+	run := func(task string, params url.Values) *Job {
+		t.Helper()
+		id, _, err := m.Submit(task, params, PrioNormal)
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		return waitStatus(t, m, id, StatusDone)
+	}
+
+	run("count", url.Values{"n": {"1"}})
+	j := run("count", url.Values{"n": {"1"}})
+	if !j.Cached || runs.Load() != 1 {
+		t.Errorf("job repetido: cached=%v ejecuciones=%d; se esperaba el resultado de la caché", j.Cached, runs.Load())
+	}
+	if res, _ := j.Result.(map[string]any); res["n"] != "1" {
+		t.Errorf("resultado de la caché = %v", j.Result)
+	}
+
+	// Sin WithCache, y con resultados de error, siempre se ejecuta
+	run("nocache", url.Values{"n": {"1"}})
+	run("count", url.Values{"fail": {"1"}})
+	run("count", url.Values{"fail": {"1"}})
+	if runs.Load() != 4 {
+		t.Errorf("ejecuciones = %d; se esperaban 4", runs.Load())
+	}
+	if st := m.CacheStats(); st == nil || st.Hits != 1 {
+		t.Errorf("CacheStats = %+v; se esperaba 1 hit", st)
+	}
+}
@@ -0,0 +1,197 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+)
+
+// Coalescing de envíos idénticos: si una tarea registrada con
+// WithCoalescing(true) ya tiene en curso un job con los mismos parámetros
+// (el líder), un envío nuevo no se encola; queda como seguidor con su
+// propio id y recibe el resultado del líder al terminar.
+//
+// m.inflight: clave de contenido -> líder en curso
+// m.followers: líder -> seguidores
+
+// coalesceKey identifica envíos idénticos (la prioridad no cambia el resultado).
+func coalesceKey(j *Job) string {
+	return CacheKey(j.Task, j.Params)
+}
+
+// Coalesces indica si la tarea comparte ejecuciones idénticas en curso.
+// Las rutas síncronas también lo consultan.
+func (m *Manager) Coalesces(task string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tc, ok := m.tasks[task]
+	return ok && tc.coalesce
+}
+
+// attachOrLead registra j como seguidor de un líder en curso y devuelve
+// true, o lo anota como líder de su clave y devuelve false.
+func (m *Manager) attachOrLead(j *Job) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.attachOrLeadLocked(j)
+}
+
+// attachOrLeadLocked es attachOrLead con m.mu ya tomado.
+func (m *Manager) attachOrLeadLocked(j *Job) bool {
+	tc, ok := m.tasks[j.Task]
+	if !ok || !tc.coalesce {
+		return false
+	}
+	key := coalesceKey(j)
+	if leader, ok := m.jobs[m.inflight[key]]; ok && !leader.Status.Terminal() {
+		j.CoalescedWith = leader.ID
+		j.Status = leader.Status
+		m.jobs[j.ID] = j
+		m.idx.add(j)
+		m.followers[leader.ID] = append(m.followers[leader.ID], j.ID)
+		fmt.Printf("[Manager] job %s se une al job %s en curso\n", j.ID, leader.ID)
+		return true
+	}
+	m.inflight[key] = j.ID
+	// el líder entra al mapa ya: un envío idéntico simultáneo debe encontrarlo
+	m.jobs[j.ID] = j
+	m.idx.add(j)
+	return false
+}
+
+// resolveFollowers copia el estado final del líder a sus seguidores.
+func (m *Manager) resolveFollowers(leader Job) {
+	m.mu.Lock()
+	ids := m.followers[leader.ID]
+	delete(m.followers, leader.ID)
+	if key := coalesceKey(&leader); m.inflight[key] == leader.ID {
+		delete(m.inflight, key)
+	}
+	var finals []Job
+	var resolved []string
+	now := time.Now()
+	for _, id := range ids {
+		f, ok := m.jobs[id]
+		if !ok || f.Status.Terminal() {
+			continue
+		}
+		f.Status = leader.Status
+		m.idx.add(f)
+		f.Result = leader.Result
+		f.Error = leader.Error
+		f.Progress = 100
+		f.Stage = leader.Stage
+		f.ETAMs = 0
+		f.Cached = leader.Cached
+		f.UpdatedAt = now
+		finals = append(finals, *f)
+		resolved = append(resolved, f.ID)
+	}
+	m.mu.Unlock()
+
+	if len(finals) == 0 {
+		return
+	}
+	m.persist(resolved...)
+	for _, f := range finals {
+		m.jobFinished(f)
+	}
+}
+
+// promoteFollower se invoca cuando un líder termina sin resultado para sus
+// seguidores (cancelado o rechazado por la cola): el primer seguidor activo
+// pasa a ser el líder, se encola y hereda al resto.
+func (m *Manager) promoteFollower(leader Job) {
+	m.mu.Lock()
+	ids := m.followers[leader.ID]
+	delete(m.followers, leader.ID)
+	key := coalesceKey(&leader)
+	if m.inflight[key] == leader.ID {
+		delete(m.inflight, key)
+	}
+	var next *Job
+	var rest []string
+	for _, id := range ids {
+		f, ok := m.jobs[id]
+		if !ok || f.Status.Terminal() {
+			continue
+		}
+		if next == nil {
+			next = f
+		} else {
+			f.CoalescedWith = next.ID
+			rest = append(rest, id)
+		}
+	}
+	// En el apagado los seguidores quedan persistidos tal cual
+	if next == nil || m.ShuttingDown() {
+		m.mu.Unlock()
+		return
+	}
+	next.CoalescedWith = ""
+	next.Status = StatusQueued
+	m.idx.add(next)
+	next.UpdatedAt = time.Now()
+	m.inflight[key] = next.ID
+	if len(rest) > 0 {
+		m.followers[next.ID] = rest
+	}
+	pool := m.pools[next.Task]
+	m.mu.Unlock()
+
+	fmt.Printf("[Manager] job %s reemplaza al job %s como líder\n", next.ID, leader.ID)
+	if err := pool.Queue.Push(next); err != nil {
+		m.mu.Lock()
+		next.Status = StatusError
+		m.idx.add(next)
+		next.Error = err.Error()
+		next.Progress = 100
+		final := *next
+		m.mu.Unlock()
+		m.persist(append(rest, next.ID)...)
+		m.jobFinished(final)
+		return
+	}
+	m.persist(append(rest, next.ID)...)
+}
+
+// relinkFollowersLocked reconstruye, al registrar una tarea, los vínculos
+// de los seguidores persistidos. Requiere m.mu tomado. Devuelve los líderes
+// ya terminados, cuyos seguidores hay que resolver sin el lock; los
+// seguidores cuyo líder se eliminó pasan a ser jobs en cola comunes.
+func (m *Manager) relinkFollowersLocked(task string) (done []Job) {
+	seen := map[string]bool{}
+	for _, f := range m.jobs {
+		if f.Task != task || f.CoalescedWith == "" || f.Status.Terminal() {
+			continue
+		}
+		leader, ok := m.jobs[f.CoalescedWith]
+		if !ok {
+			f.CoalescedWith = ""
+			f.Status = StatusQueued
+			m.idx.add(f)
+			continue
+		}
+		m.followers[leader.ID] = append(m.followers[leader.ID], f.ID)
+		if !leader.Status.Terminal() {
+			m.inflight[coalesceKey(leader)] = leader.ID
+		} else if !seen[leader.ID] {
+			seen[leader.ID] = true
+			done = append(done, *leader)
+		}
+	}
+	return done
+}
+
+// mirrorLeaderLocked muestra en un seguidor activo el avance de su líder.
+// Requiere m.mu tomado.
+func (m *Manager) mirrorLeaderLocked(cp *Job) {
+	if cp.CoalescedWith == "" || cp.Status.Terminal() {
+		return
+	}
+	if leader, ok := m.jobs[cp.CoalescedWith]; ok && !leader.Status.Terminal() {
+		cp.Status = leader.Status
+		cp.Progress = leader.Progress
+		cp.Stage = leader.Stage
+		cp.ETAMs = leader.ETAMs
+	}
+}
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gatedTask cuenta sus ejecuciones y termina cuando se cierra release.
+func gatedTask(runs *atomic.Int32, release chan struct{}) TaskFunc {
+	return func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		runs.Add(1)
+		select {
+		case <-release:
+			return map[string]any{"n": params["n"]}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// TestManager_CoalesceFollowers prueba que los envíos idénticos compartan una sola ejecución
+func TestManager_CoalesceFollowers(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	m := NewManager("", 1*time.Minute, 1*time.Minute)
+	m.Register("gated", gatedTask(&runs, release), 2, 10, 5*time.Second, WithCoalescing(true))
+	defer m.Close()
+
+	submit := func(n string) string {
+		t.Helper()
+		id, _, err := m.Submit("gated", url.Values{"n": {n}}, PrioNormal)
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		return id
+	}
+	leader := submit("1")
+	waitStatus(t, m, leader, StatusRunning)
+	f1, f2 := submit("1"), submit("1")
+	other := submit("2")
+	waitStatus(t, m, other, StatusRunning)
+
+	// Los seguidores reflejan el estado del líder
+	if j := waitStatus(t, m, f1, StatusRunning); j.CoalescedWith != leader {
+		t.Errorf("coalesced_with = %q; se esperaba %s", j.CoalescedWith, leader)
+	}
+
+	close(release)
+	for _, id := range []string{leader, f1, f2, other} {
+		j := waitStatus(t, m, id, StatusDone)
+		if res, _ := j.Result.(map[string]any); res["n"] == nil {
+			t.Errorf("job %s result = %v", id, j.Result)
+		}
+	}
+	if runs.Load() != 2 {
+		t.Errorf("ejecuciones = %d; se esperaban 2 (una por parámetros distintos)", runs.Load())
+	}
+
+	// Terminado el líder, un envío idéntico vuelve a ejecutarse
+	if j := waitStatus(t, m, submit("1"), StatusDone); j.CoalescedWith != "" || runs.Load() != 3 {
+		t.Errorf("envío posterior: coalesced_with=%q ejecuciones=%d", j.CoalescedWith, runs.Load())
+	}
+}
+
+// TestManager_CoalescePromote prueba que al cancelar el líder un seguidor tome su lugar
+func TestManager_CoalescePromote(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	m := NewManager("", 1*time.Minute, 1*time.Minute)
+	m.Register("gated", gatedTask(&runs, release), 1, 10, 5*time.Second, WithCoalescing(true))
+	defer m.Close()
+
+	params := url.Values{"n": {"1"}}
+	leader, _, _ := m.Submit("gated", params, PrioNormal)
+	waitStatus(t, m, leader, StatusRunning)
+	f1, _, _ := m.Submit("gated", params, PrioNormal)
+	f2, _, _ := m.Submit("gated", params, PrioNormal)
+
+	if _, err := m.Cancel(leader); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	j := waitStatus(t, m, f1, StatusRunning)
+	if j.CoalescedWith != "" {
+		t.Errorf("el seguidor promovido conserva coalesced_with = %q", j.CoalescedWith)
+	}
+	if j, _ := m.GetStatus(f2); j.CoalescedWith != f1 {
+		t.Errorf("f2 coalesced_with = %q; se esperaba el nuevo líder %s", j.CoalescedWith, f1)
+	}
+
+	close(release)
+	waitStatus(t, m, f2, StatusDone)
+	if runs.Load() != 2 {
+		t.Errorf("ejecuciones = %d; se esperaban 2", runs.Load())
+	}
+}
+
+// TestManager_CoalesceDisabled prueba que sin la opción cada envío se ejecute
+func TestManager_CoalesceDisabled(t *testing.T) {
+	m := NewManager("", 1*time.Minute, 1*time.Minute)
+	m.Register("block", blockingTask, 2, 10, 5*time.Second)
+	defer m.Close()
+
+	a, _, _ := m.Submit("block", url.Values{"n": {"1"}}, PrioNormal)
+	b, _, _ := m.Submit("block", url.Values{"n": {"1"}}, PrioNormal)
+	waitStatus(t, m, a, StatusRunning)
+	if j := waitStatus(t, m, b, StatusRunning); j.CoalescedWith != "" {
+		t.Errorf("coalesced_with = %q sin WithCoalescing", j.CoalescedWith)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build linux
+
+package jobs
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cpuTimes devuelve los ticks ociosos y totales de la línea "cpu" de
+// /proc/stat (todas las CPUs desde el arranque).
+func cpuTimes() (idle, total uint64, err error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() {
+		return 0, 0, errors.New("/proc/stat vacío")
+	}
+	fields := strings.Fields(sc.Text())
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, errors.New("/proc/stat con formato inesperado")
+	}
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		total += v
+		if i == 3 || i == 4 { // idle e iowait
+			idle += v
+		}
+	}
+	return idle, total, nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package jobs
+
+import "errors"
+
+func cpuTimes() (idle, total uint64, err error) {
+	return 0, 0, errors.New("uso de CPU no soportado en esta plataforma")
+}
@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("expresión cron inválida")
+
+// CronExpr es una expresión cron estándar de 5 campos:
+//
+//	minuto (0-59) hora (0-23) día-del-mes (1-31) mes (1-12) día-de-la-semana (0-7, 0 y 7 = domingo)
+//
+// Cada campo admite "*", valores, rangos "a-b", listas "a,b" y pasos "*/n",
+// "a-b/n" o "a/n". Como en cron, si día-del-mes y día-de-la-semana están
+// restringidos basta con que se cumpla uno. También se aceptan los alias
+// @hourly, @daily, @weekly, @monthly y @yearly.
+type CronExpr struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64 // bit i = valor i permitido
+	domStar, dowStar              bool
+}
+
+// Búsqueda máxima hacia adelante en Next: cubre expresiones como
+// "0 0 29 2 *" (solo años bisiestos).
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron interpreta una expresión de 5 campos o un alias.
+func ParseCron(expr string) (*CronExpr, error) {
+	expr = strings.TrimSpace(expr)
+	spec := expr
+	if alias, ok := cronAliases[spec]; ok {
+		spec = alias
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: se esperaban 5 campos en %q", ErrInvalidCron, expr)
+	}
+
+	c := &CronExpr{expr: expr}
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("%w (minuto): %v", ErrInvalidCron, err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("%w (hora): %v", ErrInvalidCron, err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("%w (día del mes): %v", ErrInvalidCron, err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("%w (mes): %v", ErrInvalidCron, err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("%w (día de la semana): %v", ErrInvalidCron, err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 también es domingo
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+
+	// Rechazar expresiones que nunca se cumplen (p.ej. 30 de febrero)
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%w: %q nunca se cumple", ErrInvalidCron, expr)
+	}
+	return c, nil
+}
+
+func (c *CronExpr) String() string { return c.expr }
+
+// parseCronField convierte un campo en un conjunto de bits entre min y max.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("paso inválido %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var errA, errB error
+			lo, errA = strconv.Atoi(a)
+			hi, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil || lo > hi {
+				return 0, fmt.Errorf("rango inválido %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("valor inválido %q", part)
+			}
+			// "a/n" recorre desde a hasta el máximo; sin paso es un único valor
+			lo = n
+			if !hasStep {
+				hi = n
+			}
+		}
+		if lo < min || hi > max {
+			return 0, fmt.Errorf("%q fuera de rango (%d-%d)", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// dayMatches aplica la regla de cron para día-del-mes y día-de-la-semana.
+func (c *CronExpr) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next devuelve el primer instante estrictamente posterior a t que cumple
+// la expresión, en la zona horaria de t. Devuelve la hora cero si no hay
+// ninguno dentro del límite de búsqueda.
+func (c *CronExpr) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
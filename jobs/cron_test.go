@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	tm, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("time.Parse(%s): %v", s, err)
+	}
+	return tm
+}
+
+// TestCronExpr_Next prueba el cálculo de la próxima ejecución
+func TestCronExpr_Next(t *testing.T) {
+	cases := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"* * * * *", "2025-03-10T10:15:30Z", "2025-03-10T10:16:00Z"},
+		{"*/15 * * * *", "2025-03-10T10:15:00Z", "2025-03-10T10:30:00Z"},
+		{"0 2 * * *", "2025-03-10T10:15:00Z", "2025-03-11T02:00:00Z"},
+		{"30 9 * * 1-5", "2025-03-14T10:00:00Z", "2025-03-17T09:30:00Z"}, // viernes -> lunes
+		{"0 0 * * 7", "2025-03-10T00:00:00Z", "2025-03-16T00:00:00Z"},    // 7 = domingo
+		{"0 0 1,15 * *", "2025-03-02T00:00:00Z", "2025-03-15T00:00:00Z"},
+		{"0 0 31 * *", "2025-04-01T00:00:00Z", "2025-05-31T00:00:00Z"},
+		{"0 0 29 2 *", "2025-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"0 12 13 * 5", "2025-03-10T00:00:00Z", "2025-03-13T12:00:00Z"}, // día 13 o viernes
+		{"5/20 8-10/2 * * *", "2025-03-10T08:45:00Z", "2025-03-10T10:05:00Z"},
+		{"@daily", "2025-12-31T23:59:00Z", "2026-01-01T00:00:00Z"},
+	}
+	for _, c := range cases {
+		expr, err := ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) devolvió un error: %v", c.expr, err)
+			continue
+		}
+		got := expr.Next(mustTime(t, c.from))
+		if want := mustTime(t, c.want); !got.Equal(want) {
+			t.Errorf("Next(%q, %s) = %s; se esperaba %s", c.expr, c.from, got.Format(time.RFC3339), c.want)
+		}
+	}
+}
+
+// TestParseCron_Invalid prueba que se rechacen expresiones mal formadas
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "0 0 30 2 *",
+	} {
+		if _, err := ParseCron(expr); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("ParseCron(%q) = %v; se esperaba ErrInvalidCron", expr, err)
+		}
+	}
+}
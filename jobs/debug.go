@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// PoolDump describe el estado interno de un WorkerPool en un instante dado.
+type PoolDump struct {
+	Workers   int               `json:"workers"`
+	Active    int64             `json:"active"`
+	Capacity  int               `json:"capacity"`
+	QueuedIDs []string          `json:"queued_ids"`
+	Running   map[string]string `json:"running"` // "worker-N" -> job id
+}
+
+// ManagerDump es el volcado completo del Manager que expone el listener
+// de administración y que se escribe a disco con SIGUSR1.
+type ManagerDump struct {
+	Time         time.Time           `json:"time"`
+	TotalJobs    int                 `json:"total_jobs"`
+	JobsByStatus map[JobStatus]int   `json:"jobs_by_status"`
+	Pools        map[string]PoolDump `json:"pools"`
+}
+
+// DebugDump arma un volcado de todos los pools: jobs en cola (en orden de
+// prioridad) y qué job está ejecutando cada worker.
+func (m *Manager) DebugDump() ManagerDump {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dump := ManagerDump{
+		Time:         time.Now(),
+		TotalJobs:    len(m.jobs),
+		JobsByStatus: make(map[JobStatus]int),
+		Pools:        make(map[string]PoolDump, len(m.pools)),
+	}
+
+	for _, j := range m.jobs {
+		dump.JobsByStatus[j.Status]++
+	}
+
+	for name, pool := range m.pools {
+		running := make(map[string]string)
+		for w, id := range pool.Running() {
+			running["worker-"+strconv.Itoa(w)] = id
+		}
+
+		dump.Pools[name] = PoolDump{
+			Workers:   pool.Size(),
+			Active:    atomic.LoadInt64(&pool.Active),
+			Capacity:  pool.Queue.Cap(),
+			QueuedIDs: pool.Queue.Snapshot(),
+			Running:   running,
+		}
+	}
+	return dump
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package jobs
+
+import "errors"
+
+func diskFree(dir string) (uint64, error) {
+	return 0, errors.New("diskFree no soportado en esta plataforma")
+}
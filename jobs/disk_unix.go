@@ -0,0 +1,15 @@
+//go:build unix
+
+package jobs
+
+import "syscall"
+
+// diskFree devuelve los bytes disponibles para usuarios no privilegiados
+// en el sistema de archivos que contiene dir.
+func diskFree(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
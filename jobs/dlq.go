@@ -0,0 +1,227 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrDeadLetterNotFound = errors.New("entrada no encontrada en la DLQ")
+
+// Motivos por los que un job termina en la dead-letter queue.
+const (
+	ReasonExhausted    = "attempts_exhausted" // agotó los intentos de su política
+	ReasonNotRetryable = "not_retryable"      // la clase de error no se reintenta
+	ReasonPanic        = "panic"              // la tarea entró en pánico
+	ReasonInterrupted  = "interrupted"        // un reinicio la cortó (RecoverFail)
+	ReasonLimit        = "limit_exceeded"     // excedió un límite de recursos
+)
+
+// DeadLetter es un job que falló de forma permanente. Se guarda aparte del
+// mapa de jobs, no lo afecta la limpieza por TTL y se puede reencolar.
+type DeadLetter struct {
+	Job    Job        `json:"job"`
+	Reason string     `json:"reason"`
+	Class  ErrorClass `json:"class"`
+	DeadAt time.Time  `json:"dead_at"`
+}
+
+// DeadLetterFilter selecciona entradas de la DLQ. Los campos vacíos no filtran.
+type DeadLetterFilter struct {
+	ID     string
+	Task   string
+	Reason string
+	Class  ErrorClass
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (f DeadLetterFilter) match(dl *DeadLetter) bool {
+	switch {
+	case f.ID != "" && dl.Job.ID != f.ID,
+		f.Task != "" && dl.Job.Task != f.Task,
+		f.Reason != "" && dl.Reason != f.Reason,
+		f.Class != "" && dl.Class != f.Class,
+		!f.Since.IsZero() && dl.DeadAt.Before(f.Since),
+		!f.Until.IsZero() && dl.DeadAt.After(f.Until):
+		return false
+	}
+	return true
+}
+
+// deadLetterStore persiste la DLQ en su propio archivo JSON.
+type deadLetterStore struct {
+	mu      sync.Mutex
+	file    string
+	entries map[string]*DeadLetter
+}
+
+// dlqFileFor deriva el archivo de la DLQ del archivo de jobs:
+// jobs_data.json -> jobs_data_dlq.json. Sin archivo de jobs, la DLQ vive en memoria.
+func dlqFileFor(file string) string {
+	if file == "" {
+		return ""
+	}
+	return strings.TrimSuffix(file, ".json") + "_dlq.json"
+}
+
+func newDeadLetterStore(file string) *deadLetterStore {
+	s := &deadLetterStore{file: file, entries: make(map[string]*DeadLetter)}
+	if file == "" {
+		return s
+	}
+	if data, err := os.ReadFile(file); err == nil && len(data) > 0 {
+		if err := json.Unmarshal(data, &s.entries); err != nil {
+			fmt.Printf("[DLQ] No se pudo leer %s: %v\n", file, err)
+		}
+	}
+	return s
+}
+
+// saveLocked reescribe el archivo de la DLQ. Requiere s.mu tomado.
+func (s *deadLetterStore) saveLocked() error {
+	if s.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.file, data, 0644)
+}
+
+func (s *deadLetterStore) add(dl *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[dl.Job.ID] = dl
+	return s.saveLocked()
+}
+
+// take retira una entrada de la DLQ y la devuelve. Quien la toma es el
+// único que puede reencolarla; si no lo logra, la devuelve con restore.
+func (s *deadLetterStore) take(id string) (*DeadLetter, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dl, ok := s.entries[id]
+	if !ok {
+		return nil, false
+	}
+	delete(s.entries, id)
+	if err := s.saveLocked(); err != nil {
+		fmt.Printf("[DLQ] Error persistiendo %s: %v\n", s.file, err)
+	}
+	return dl, true
+}
+
+// restore devuelve a la DLQ una entrada que take retiró.
+func (s *deadLetterStore) restore(dl *DeadLetter) {
+	if err := s.add(dl); err != nil {
+		fmt.Printf("[DLQ] Error persistiendo %s: %v\n", s.file, err)
+	}
+}
+
+// matchLocked devuelve las entradas que cumplen el filtro, de la más
+// reciente a la más antigua y acotadas por f.Limit. Requiere s.mu tomado.
+func (s *deadLetterStore) matchLocked(f DeadLetterFilter) []*DeadLetter {
+	var out []*DeadLetter
+	for _, dl := range s.entries {
+		if f.match(dl) {
+			out = append(out, dl)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].DeadAt.After(out[b].DeadAt) })
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[:f.Limit]
+	}
+	return out
+}
+
+// list devuelve las entradas que cumplen el filtro, de la más reciente a la más antigua.
+func (s *deadLetterStore) list(f DeadLetterFilter) []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []DeadLetter{}
+	for _, dl := range s.matchLocked(f) {
+		out = append(out, *dl)
+	}
+	return out
+}
+
+// purge elimina las entradas que cumplen el filtro y devuelve cuántas
+// borró. Con f.Limit borra solo las más recientes, las mismas que lista list.
+func (s *deadLetterStore) purge(f DeadLetterFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hits := s.matchLocked(f)
+	if len(hits) == 0 {
+		return 0, nil
+	}
+	for _, dl := range hits {
+		delete(s.entries, dl.Job.ID)
+	}
+	return len(hits), s.saveLocked()
+}
+
+// -----------------------------------------------------------------------------
+// API del Manager
+// -----------------------------------------------------------------------------
+
+// deadLetter guarda una copia del job fallido en la DLQ.
+func (m *Manager) deadLetter(j Job, class ErrorClass, reason string) {
+	dl := &DeadLetter{Job: j, Reason: reason, Class: class, DeadAt: time.Now()}
+	if err := m.dlq.add(dl); err != nil {
+		fmt.Printf("[DLQ] Error persistiendo %s: %v\n", m.dlq.file, err)
+	}
+	fmt.Printf("[DLQ] job %s (%s) movido a la DLQ: %s\n", j.ID, j.Task, reason)
+}
+
+// DeadLetters lista la DLQ según el filtro.
+func (m *Manager) DeadLetters(f DeadLetterFilter) []DeadLetter {
+	return m.dlq.list(f)
+}
+
+// RequeueDeadLetter crea un job nuevo a partir de una entrada de la DLQ,
+// con los parámetros originales sobrescritos por overrides, y retira la
+// entrada de la DLQ. Devuelve el id del job nuevo. La entrada sale de la
+// DLQ antes de encolar: dos reencolados simultáneos no crean dos jobs.
+func (m *Manager) RequeueDeadLetter(id string, overrides map[string]string) (string, error) {
+	dl, ok := m.dlq.take(id)
+	if !ok {
+		return "", ErrDeadLetterNotFound
+	}
+
+	// pasa por SubmitJob como un envío más: una tarea deshabilitada lo
+	// rechaza y los overrides se validan y estiman. Conserva los límites
+	// del job original salvo los que sobrescriba un max_* en overrides.
+	params := make(url.Values, len(dl.Job.Params)+len(overrides))
+	for k, v := range dl.Job.Params {
+		params.Set(k, v)
+	}
+	for k, v := range overrides {
+		params.Set(k, v)
+	}
+	jobID, _, err := m.SubmitJob(SubmitRequest{
+		Task:         dl.Job.Task,
+		Params:       params,
+		Priority:     dl.Job.Priority,
+		Limits:       dl.Job.Limits,
+		RequeuedFrom: dl.Job.ID,
+	})
+	if err != nil {
+		m.dlq.restore(dl)
+		return "", err
+	}
+	return jobID, nil
+}
+
+// PurgeDeadLetters elimina de la DLQ las entradas que cumplen el filtro.
+func (m *Manager) PurgeDeadLetters(f DeadLetterFilter) (int, error) {
+	return m.dlq.purge(f)
+}
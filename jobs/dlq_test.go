@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitDeadLetter espera a que el job aparezca en la DLQ.
+func waitDeadLetter(t *testing.T, m *Manager, jobID string) DeadLetter {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if dl := m.DeadLetters(DeadLetterFilter{ID: jobID}); len(dl) == 1 {
+			return dl[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("el job %s no llegó a la DLQ", jobID)
+	return DeadLetter{}
+}
+
+// TestManager_DeadLetterReasons prueba el motivo registrado para cada tipo de fallo
+func TestManager_DeadLetterReasons(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("boom", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		panic("explotó")
+	}, 1, 4, 1*time.Second)
+	manager.Register("bad", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		return nil, errors.New("parámetros inválidos")
+	}, 1, 4, 1*time.Second, WithRetry(RetryPolicy{MaxAttempts: 3, RetryOn: []ErrorClass{ClassIO}}))
+	manager.Register("flaky", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		return nil, errors.New("siempre falla")
+	}, 1, 4, 1*time.Second, WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: 10 * time.Millisecond}))
+	defer manager.Close()
+
+	cases := []struct {
+		task   string
+		reason string
+		class  ErrorClass
+	}{
+		{"boom", ReasonPanic, ClassPanic},
+		{"bad", ReasonNotRetryable, ClassTask},
+		{"flaky", ReasonExhausted, ClassTask},
+	}
+	for _, c := range cases {
+		jobID, _, _ := manager.Submit(c.task, url.Values{}, PrioNormal)
+		dl := waitDeadLetter(t, manager, jobID)
+		if dl.Reason != c.reason || dl.Class != c.class {
+			t.Errorf("%s: DLQ = (%s, %s); se esperaba (%s, %s)", c.task, dl.Reason, dl.Class, c.reason, c.class)
+		}
+	}
+}
+
+// TestManager_DeadLetterRequeue prueba el reencolado con parámetros editados
+func TestManager_DeadLetterRequeue(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("needsx", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		if params["x"] != "ok" {
+			return nil, errors.New("x inválido")
+		}
+		return "hecho", nil
+	}, 1, 4, 1*time.Second)
+	defer manager.Close()
+
+	jobID, _, _ := manager.Submit("needsx", url.Values{"x": {"mal"}, "y": {"1"}}, PrioHigh)
+	waitDeadLetter(t, manager, jobID)
+
+	if _, err := manager.RequeueDeadLetter("no-existe", nil); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("RequeueDeadLetter(no-existe) = %v; se esperaba ErrDeadLetterNotFound", err)
+	}
+
+	newID, err := manager.RequeueDeadLetter(jobID, map[string]string{"x": "ok"})
+	if err != nil {
+		t.Fatalf("RequeueDeadLetter: %v", err)
+	}
+	job := waitStatus(t, manager, newID, StatusDone)
+	if job.RequeuedFrom != jobID || job.Params["y"] != "1" || job.Priority != PrioHigh {
+		t.Errorf("job reencolado = %+v; se esperaba el origen, y=1 y prioridad alta", job)
+	}
+	if n := len(manager.DeadLetters(DeadLetterFilter{})); n != 0 {
+		t.Errorf("DLQ tras reencolar tiene %d entradas; se esperaban 0", n)
+	}
+}
+
+// TestManager_DeadLetterPersistence prueba que la DLQ sobreviva al reinicio y a la limpieza por TTL
+func TestManager_DeadLetterPersistence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	fail := func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		return nil, errors.New("falla")
+	}
+
+	manager := NewManager(file, 1*time.Millisecond, 1*time.Minute)
+	manager.Register("fail", fail, 1, 4, 1*time.Second)
+	jobID, _, _ := manager.Submit("fail", url.Values{}, PrioNormal)
+	waitDeadLetter(t, manager, jobID)
+
+	time.Sleep(5 * time.Millisecond)
+	manager.CleanupOnce()
+	if _, err := manager.GetStatus(jobID); err == nil {
+		t.Errorf("el job debería haberse eliminado por TTL")
+	}
+	manager.Close()
+
+	reloaded := NewManager(file, 1*time.Minute, 1*time.Minute)
+	defer reloaded.Close()
+	if dl := reloaded.DeadLetters(DeadLetterFilter{Task: "fail"}); len(dl) != 1 || dl[0].Job.ID != jobID {
+		t.Fatalf("DLQ recargada = %+v; se esperaba el job %s", dl, jobID)
+	}
+
+	if n, _ := reloaded.PurgeDeadLetters(DeadLetterFilter{Reason: ReasonPanic}); n != 0 {
+		t.Errorf("PurgeDeadLetters(panic) = %d; se esperaba 0", n)
+	}
+	if n, _ := reloaded.PurgeDeadLetters(DeadLetterFilter{ID: jobID}); n != 1 {
+		t.Errorf("PurgeDeadLetters(id) = %d; se esperaba 1", n)
+	}
+}
+
+// TestManager_DeadLetterPurgeLimit prueba que limit acote el purge a las
+// entradas más recientes en lugar de vaciar la DLQ
+func TestManager_DeadLetterPurgeLimit(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	defer manager.Close()
+	now := time.Now()
+	for i, id := range []string{"vieja", "media", "nueva"} {
+		manager.dlq.add(&DeadLetter{Job: Job{ID: id, Task: "fail"}, Reason: ReasonExhausted,
+			DeadAt: now.Add(time.Duration(i) * time.Second)})
+	}
+
+	if n, _ := manager.PurgeDeadLetters(DeadLetterFilter{Limit: 1}); n != 1 {
+		t.Fatalf("PurgeDeadLetters(limit=1) = %d; se esperaba 1", n)
+	}
+	left := manager.DeadLetters(DeadLetterFilter{})
+	if len(left) != 2 || left[0].Job.ID != "media" || left[1].Job.ID != "vieja" {
+		t.Errorf("DLQ tras el purge = %+v; se esperaban media y vieja", left)
+	}
+}
+
+// TestManager_DeadLetterRequeueOnce prueba que reencolados simultáneos de la
+// misma entrada creen un solo job
+func TestManager_DeadLetterRequeueOnce(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("ok", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		return "hecho", nil
+	}, 1, 64, 1*time.Second)
+	defer manager.Close()
+	manager.dlq.add(&DeadLetter{Job: Job{ID: "dead-1", Task: "ok"}, Reason: ReasonExhausted, DeadAt: time.Now()})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var created []string
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if id, err := manager.RequeueDeadLetter("dead-1", nil); err == nil {
+				mu.Lock()
+				created = append(created, id)
+				mu.Unlock()
+			} else if !errors.Is(err, ErrDeadLetterNotFound) {
+				t.Errorf("RequeueDeadLetter: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(created) != 1 {
+		t.Errorf("jobs creados = %v; se esperaba uno solo", created)
+	}
+}
+
+// TestManager_DeadLetterRequeueDisabled prueba que reencolar respete una
+// tarea deshabilitada y deje la entrada en la DLQ
+func TestManager_DeadLetterRequeueDisabled(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("ok", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		return "hecho", nil
+	}, 1, 4, 1*time.Second)
+	defer manager.Close()
+	manager.dlq.add(&DeadLetter{Job: Job{ID: "dead-1", Task: "ok"}, Reason: ReasonExhausted, DeadAt: time.Now()})
+
+	manager.SetTaskEnabled("ok", false)
+	if _, err := manager.RequeueDeadLetter("dead-1", nil); !errors.Is(err, ErrTaskDisabled) {
+		t.Fatalf("RequeueDeadLetter con la tarea deshabilitada = %v; se esperaba ErrTaskDisabled", err)
+	}
+	if n := len(manager.DeadLetters(DeadLetterFilter{ID: "dead-1"})); n != 1 {
+		t.Fatalf("la entrada salió de la DLQ sin crear un job")
+	}
+
+	manager.SetTaskEnabled("ok", true)
+	newID, err := manager.RequeueDeadLetter("dead-1", nil)
+	if err != nil {
+		t.Fatalf("RequeueDeadLetter: %v", err)
+	}
+	if job := waitStatus(t, manager, newID, StatusDone); job.RequeuedFrom != "dead-1" {
+		t.Errorf("requeued_from = %q; se esperaba dead-1", job.RequeuedFrom)
+	}
+}
@@ -0,0 +1,135 @@
+package jobs
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Espacio libre mínimo en el disco de persistencia antes de declarar
+// el servidor como no listo.
+const minFreeDiskBytes = 16 * 1024 * 1024
+
+// HealthCheck es el resultado de un chequeo individual de readiness.
+type HealthCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// SetCritical marca tareas como críticas: si todos sus pools están
+// saturados, /readyz falla.
+func (m *Manager) SetCritical(names ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, n := range names {
+		m.critical[n] = true
+	}
+}
+
+// BeginShutdown marca el inicio del apagado. Desde ese momento el
+// servidor deja de estar listo para recibir tráfico.
+func (m *Manager) BeginShutdown() {
+	m.shuttingDown.Store(true)
+}
+
+func (m *Manager) ShuttingDown() bool {
+	return m.shuttingDown.Load()
+}
+
+// recordPersist guarda el resultado de la última escritura del archivo de jobs.
+func (m *Manager) recordPersist(err error) {
+	m.persistMu.Lock()
+	defer m.persistMu.Unlock()
+	if err != nil {
+		if m.persistErr == nil {
+			fmt.Printf("[Manager] Error persistiendo jobs en %s: %v\n", m.file, err)
+		}
+		m.persistErr = err
+		m.persistFailures++
+		return
+	}
+	m.persistErr = nil
+	m.persistFailures = 0
+	m.lastPersistOK = time.Now()
+}
+
+// Readiness ejecuta todos los chequeos de readiness. El servidor está
+// listo solo si todos devuelven OK.
+func (m *Manager) Readiness() []HealthCheck {
+	checks := []HealthCheck{
+		m.checkShutdown(),
+		m.checkPersistence(),
+		m.checkDisk(),
+	}
+	return append(checks, m.checkPools()...)
+}
+
+func (m *Manager) checkShutdown() HealthCheck {
+	if m.ShuttingDown() {
+		return HealthCheck{Name: "shutdown", Reason: "el servidor se está apagando"}
+	}
+	return HealthCheck{Name: "shutdown", OK: true}
+}
+
+func (m *Manager) checkPersistence() HealthCheck {
+	m.persistMu.Lock()
+	defer m.persistMu.Unlock()
+	if m.persistErr != nil {
+		return HealthCheck{
+			Name:   "persistence",
+			Reason: fmt.Sprintf("%d escrituras fallidas de %s: %v", m.persistFailures, m.file, m.persistErr),
+		}
+	}
+	return HealthCheck{Name: "persistence", OK: true}
+}
+
+func (m *Manager) checkDisk() HealthCheck {
+	if m.file == "" {
+		return HealthCheck{Name: "disk", OK: true}
+	}
+	free, err := diskFree(filepath.Dir(m.file))
+	if err != nil {
+		// plataforma sin soporte: no se puede afirmar que el disco esté lleno
+		return HealthCheck{Name: "disk", OK: true}
+	}
+	if free < minFreeDiskBytes {
+		return HealthCheck{Name: "disk", Reason: fmt.Sprintf("disco lleno: %d bytes libres", free)}
+	}
+	return HealthCheck{Name: "disk", OK: true}
+}
+
+// checkPools falla por cada tarea crítica cuyos pools están todos
+// saturados (todos los workers ocupados y la cola llena).
+func (m *Manager) checkPools() []HealthCheck {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.critical))
+	for name := range m.critical {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []HealthCheck
+	for _, name := range names {
+		pool, ok := m.pools[name]
+		if !ok {
+			out = append(out, HealthCheck{Name: "pool:" + name, Reason: "tarea crítica sin pool registrado"})
+			continue
+		}
+		// Saturado = no admite más jobs de prioridad normal
+		active, size := atomic.LoadInt64(&pool.Active), pool.Size()
+		if active >= int64(size) && pool.Queue.Full(PrioNormal) {
+			out = append(out, HealthCheck{
+				Name:   "pool:" + name,
+				Reason: fmt.Sprintf("pool saturado: %d/%d workers ocupados, cola normal %d/%d", active, size, pool.Queue.Depths()["normal"], pool.Queue.ClassCap()),
+			})
+			continue
+		}
+		out = append(out, HealthCheck{Name: "pool:" + name, OK: true})
+	}
+	return out
+}
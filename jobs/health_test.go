@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func failingChecks(m *Manager) map[string]string {
+	out := map[string]string{}
+	for _, c := range m.Readiness() {
+		if !c.OK {
+			out[c.Name] = c.Reason
+		}
+	}
+	return out
+}
+
+// TestReadiness_Persistence prueba que los errores de escritura se reporten
+func TestReadiness_Persistence(t *testing.T) {
+	// Directorio inexistente: toda escritura falla
+	file := filepath.Join(t.TempDir(), "no-existe", "jobs.json")
+	manager := NewManager(file, 1*time.Minute, 1*time.Minute)
+	manager.Register("mock", mockTask, 1, 4, 1*time.Second)
+	defer manager.Close()
+
+	if failing := failingChecks(manager); len(failing) != 0 {
+		t.Fatalf("Readiness inicial falló: %v", failing)
+	}
+
+	if _, _, err := manager.Submit("mock", url.Values{"n": {"1"}}, PrioNormal); err != nil {
+		t.Fatalf("Submit falló: %v", err)
+	}
+	if _, ok := failingChecks(manager)["persistence"]; !ok {
+		t.Errorf("Readiness no reportó el fallo de persistencia")
+	}
+}
+
+// TestReadiness_ShutdownAndSaturation prueba el apagado y los pools críticos saturados
+func TestReadiness_ShutdownAndSaturation(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	block := make(chan struct{})
+	manager.Register("slow", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		select {
+		case <-block:
+		case <-ctx.Done():
+		}
+		return nil, nil
+	}, 1, 1, 5*time.Second)
+	manager.SetCritical("slow")
+	defer manager.Close()
+	defer close(block)
+
+	manager.Submit("slow", url.Values{}, PrioNormal)
+	time.Sleep(50 * time.Millisecond) // el worker toma el primer job
+	manager.Submit("slow", url.Values{}, PrioNormal)
+
+	if _, ok := failingChecks(manager)["pool:slow"]; !ok {
+		t.Errorf("Readiness no reportó el pool crítico saturado")
+	}
+
+	manager.BeginShutdown()
+	if _, ok := failingChecks(manager)["shutdown"]; !ok {
+		t.Errorf("Readiness no reportó el apagado")
+	}
+}
@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrIdempotencyConflict   = errors.New("idempotency key ya usada con parámetros distintos")
+	ErrInvalidIdempotencyKey = errors.New("idempotency key inválida (1 a 255 caracteres)")
+)
+
+// Tiempo durante el que se recuerda una idempotency key.
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// Parámetro que transporta la key; no forma parte de la huella del envío.
+const IdempotencyParam = "idempotency_key"
+
+type idemEntry struct {
+	JobID       string    `json:"job_id"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// idemStore recuerda key -> job con TTL y se persiste en su propio archivo.
+// Su lock se mantiene durante todo el envío con key, así dos reintentos
+// simultáneos no crean dos jobs.
+type idemStore struct {
+	mu      sync.Mutex
+	file    string
+	ttl     time.Duration
+	entries map[string]*idemEntry
+}
+
+// idempotencyFileFor deriva el archivo de keys del archivo de jobs:
+// jobs_data.json -> jobs_data_idempotency.json.
+func idempotencyFileFor(file string) string {
+	if file == "" {
+		return ""
+	}
+	return strings.TrimSuffix(file, ".json") + "_idempotency.json"
+}
+
+func newIdemStore(file string, ttl time.Duration) *idemStore {
+	s := &idemStore{file: file, ttl: ttl, entries: make(map[string]*idemEntry)}
+	if file == "" {
+		return s
+	}
+	if data, err := os.ReadFile(file); err == nil && len(data) > 0 {
+		if err := json.Unmarshal(data, &s.entries); err != nil {
+			fmt.Printf("[Idempotency] No se pudo leer %s: %v\n", file, err)
+		}
+	}
+	return s
+}
+
+// saveLocked reescribe el archivo de keys. Requiere s.mu tomado.
+func (s *idemStore) saveLocked() {
+	if s.file == "" {
+		return
+	}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err == nil {
+		err = os.WriteFile(s.file, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("[Idempotency] Error persistiendo %s: %v\n", s.file, err)
+	}
+}
+
+// expire elimina las keys vencidas.
+func (s *idemStore) expire(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for k, e := range s.entries {
+		if now.Sub(e.CreatedAt) > s.ttl {
+			delete(s.entries, k)
+			n++
+		}
+	}
+	if n > 0 {
+		s.saveLocked()
+	}
+}
+
+// fingerprint resume tarea, prioridad, parámetros (sin la key), límites y
+// diferimiento de forma independiente del orden de los parámetros. Sin
+// límites ni diferimiento da la misma huella que antes de incluirlos, así
+// las keys ya persistidas siguen valiendo.
+func fingerprint(task string, prio JobPriority, params map[string]string, limits *ResourceLimits, schedule string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != IdempotencyParam {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00", task, prio)
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\x00", k, params[k])
+	}
+	if limits != nil {
+		fmt.Fprintf(h, "\x01limits=%d/%d/%d\x00", limits.Memory, limits.Disk, limits.Output)
+	}
+	if schedule != "" {
+		fmt.Fprintf(h, "\x01%s\x00", schedule)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// submitSchedule es el diferimiento del envío tal como se pidió: delay=10m
+// reintentado más tarde es el mismo envío aunque venza en otro momento.
+func submitSchedule(req SubmitRequest) string {
+	if v := req.Params.Get("delay"); v != "" {
+		return "delay=" + v
+	}
+	if v := req.Params.Get("run_at"); v != "" {
+		return "run_at=" + v
+	}
+	if !req.RunAt.IsZero() {
+		return "run_at=" + req.RunAt.UTC().Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+// submitIdempotent envuelve la creación del job: si la key ya se usó con
+// la misma huella y el job sigue existiendo, devuelve ese job; con otra
+// huella, ErrIdempotencyConflict.
+func (m *Manager) submitIdempotent(key, schedule string, j *Job, create func() (JobStatus, error)) (string, JobStatus, error) {
+	if len(key) == 0 || len(key) > 255 {
+		return "", "", ErrInvalidIdempotencyKey
+	}
+	fp := fingerprint(j.Task, j.Priority, j.Params, j.Limits, schedule)
+
+	s := m.idem
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && time.Since(e.CreatedAt) <= s.ttl {
+		if e.Fingerprint != fp {
+			return "", "", ErrIdempotencyConflict
+		}
+		if prev, err := m.GetStatus(e.JobID); err == nil {
+			fmt.Printf("[Idempotency] key %q repetida: se devuelve el job %s\n", key, prev.ID)
+			return prev.ID, prev.Status, nil
+		}
+		// el job ya se eliminó por TTL: la key se reutiliza para uno nuevo
+	}
+
+	status, err := create()
+	if err != nil {
+		return "", "", err
+	}
+	s.entries[key] = &idemEntry{JobID: j.ID, Fingerprint: fp, CreatedAt: time.Now()}
+	s.saveLocked()
+	return j.ID, status, nil
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestManager_IdempotencyReplay prueba que un reintento idéntico devuelva el job original
+func TestManager_IdempotencyReplay(t *testing.T) {
+	m := NewManager("", 1*time.Minute, 1*time.Minute)
+	m.Register("quick", quickTask, 1, 10, time.Second)
+	defer m.Close()
+
+	req := SubmitRequest{Task: "quick", Params: url.Values{"n": {"1"}, "m": {"2"}}, IdempotencyKey: "k1"}
+	first, _, err := m.SubmitJob(req)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	// Mismos parámetros en otro orden y con la key como parámetro: mismo job
+	again, _, err := m.SubmitJob(SubmitRequest{Task: "quick", Params: url.Values{"m": {"2"}, "n": {"1"}, IdempotencyParam: {"k1"}}, IdempotencyKey: "k1"})
+	if err != nil || again != first {
+		t.Fatalf("reintento = %s, %v; se esperaba %s", again, err, first)
+	}
+	if j, _ := m.GetStatus(first); j.Params[IdempotencyParam] != "" {
+		t.Errorf("la key no debería guardarse entre los parámetros: %v", j.Params)
+	}
+
+	req.Params = url.Values{"n": {"3"}}
+	if _, _, err := m.SubmitJob(req); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("key reutilizada con otros parámetros: err = %v; se esperaba conflicto", err)
+	}
+	// los límites y el diferimiento también forman parte del envío
+	for name, other := range map[string]url.Values{
+		"max_memory": {"n": {"1"}, "m": {"2"}, "max_memory": {"64MB"}},
+		"delay":      {"n": {"1"}, "m": {"2"}, "delay": {"1h"}},
+	} {
+		req := SubmitRequest{Task: "quick", Params: other, IdempotencyKey: "k1"}
+		if name == "delay" {
+			req.RunAt = time.Now().Add(time.Hour)
+		}
+		if _, _, err := m.SubmitJob(req); !errors.Is(err, ErrIdempotencyConflict) {
+			t.Errorf("key reutilizada con otro %s: err = %v; se esperaba conflicto", name, err)
+		}
+	}
+	// delay se compara tal como se pidió, no por el momento en que vence
+	delayed := SubmitRequest{Task: "quick", Params: url.Values{"delay": {"30m"}}, RunAt: time.Now().Add(30 * time.Minute), IdempotencyKey: "k2"}
+	first, _, _ = m.SubmitJob(delayed)
+	delayed.RunAt = delayed.RunAt.Add(time.Minute)
+	if again, _, err := m.SubmitJob(delayed); err != nil || again != first {
+		t.Errorf("reintento con delay = %s, %v; se esperaba %s", again, err, first)
+	}
+	if _, _, err := m.SubmitJob(SubmitRequest{Task: "quick", IdempotencyKey: string(make([]byte, 256))}); !errors.Is(err, ErrInvalidIdempotencyKey) {
+		t.Errorf("key demasiado larga: err = %v", err)
+	}
+}
+
+// TestManager_IdempotencyPersistAndExpire prueba que las keys sobrevivan al reinicio y venzan con su TTL
+func TestManager_IdempotencyPersistAndExpire(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	m := NewManager(file, 1*time.Minute, 1*time.Minute)
+	m.Register("quick", quickTask, 1, 10, time.Second)
+	req := SubmitRequest{Task: "quick", Params: url.Values{"n": {"1"}}, IdempotencyKey: "k1"}
+	first, _, err := m.SubmitJob(req)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	waitStatus(t, m, first, StatusDone)
+	m.Close()
+
+	m = NewManager(file, 1*time.Minute, 1*time.Minute)
+	m.Register("quick", quickTask, 1, 10, time.Second)
+	defer m.Close()
+	if again, status, err := m.SubmitJob(req); err != nil || again != first || status != StatusDone {
+		t.Fatalf("reintento tras reinicio = %s %s, %v; se esperaba %s done", again, status, err, first)
+	}
+
+	// Vencida la key, el mismo envío crea un job nuevo
+	m.idem.expire(time.Now().Add(defaultIdempotencyTTL + time.Minute))
+	if again, _, err := m.SubmitJob(req); err != nil || again == first {
+		t.Errorf("envío con key vencida = %s, %v; se esperaba un job nuevo", again, err)
+	}
+}
@@ -1,24 +1,33 @@
 package jobs
 
-import "time"
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
 
 type JobStatus string
 type JobPriority int
 
 const (
-	StatusQueued   JobStatus = "queued"
-	StatusRunning  JobStatus = "running"
-	StatusDone     JobStatus = "done"
-	StatusError    JobStatus = "error"
-	StatusCanceled JobStatus = "canceled"
+	StatusQueued    JobStatus = "queued"
+	StatusRunning   JobStatus = "running"
+	StatusDone      JobStatus = "done"
+	StatusError     JobStatus = "error"
+	StatusCanceled  JobStatus = "canceled"
+	StatusTimeout   JobStatus = "timeout"
+	StatusRetrying  JobStatus = "retrying"  // esperando el próximo intento
+	StatusScheduled JobStatus = "scheduled" // esperando su hora de ejecución (run_at)
 
 	PrioLow    JobPriority = 0
 	PrioNormal JobPriority = 1
 	PrioHigh   JobPriority = 2
 )
 
-// La firma de tus tareas se mantiene.
-type TaskFunc func(params map[string]string, j *Job) (any, error)
+// TaskFunc recibe un contexto que se cancela con /jobs/cancel, al vencer
+// el timeout de la tarea o durante el apagado. Las tareas largas deben
+// revisarlo para detenerse a tiempo, y reportar su avance por p.
+type TaskFunc func(ctx context.Context, params map[string]string, p *Progress) (any, error)
 
 type Job struct {
 	ID        string            `json:"id"`
@@ -27,10 +36,57 @@ type Job struct {
 	Status    JobStatus         `json:"status"`
 	Priority  JobPriority       `json:"priority"`
 	Progress  int               `json:"progress"` // 0..100
+	Stage     string            `json:"stage,omitempty"`
 	ETAMs     int64             `json:"eta_ms"`
 	Result    any               `json:"result"`
 	Error     string            `json:"error"`
+	ErrorCode LimitCode         `json:"error_code,omitempty"` // límite excedido (ver limits.go)
+
+	// Límites de recursos fijados en el envío (max_memory, max_disk, max_output).
+	Limits *ResourceLimits `json:"limits,omitempty"`
+
+	// Ejecución diferida: hora a partir de la cual el job pasa a la cola.
+	RunAt *time.Time `json:"run_at,omitempty"`
+
+	// Reintentos: número de intento actual, errores de cada intento fallido
+	// y, en estado "retrying", cuándo se ejecuta el próximo.
+	Attempt       int            `json:"attempt"`
+	Errors        []AttemptError `json:"errors,omitempty"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty"`
+
+	// Id del job de la DLQ del que se reencoló este job, si corresponde.
+	RequeuedFrom string `json:"requeued_from,omitempty"`
+
+	// Lote con el que se envió este job, si corresponde (ver batch.go).
+	Batch string `json:"batch_id,omitempty"`
+
+	// Workflow y paso que lanzaron este job, si corresponde.
+	Workflow string `json:"workflow_id,omitempty"`
+	Step     string `json:"step,omitempty"`
+
+	// El resultado salió de la caché, sin ejecutar la tarea.
+	Cached bool `json:"cached,omitempty"`
+
+	// Job en curso idéntico cuyo resultado recibe este job (ver coalesce.go).
+	CoalescedWith string `json:"coalesced_with,omitempty"`
+
+	// Último estado intermedio guardado por la tarea con Progress.Checkpoint;
+	// con RecoverResume se conserva si el servidor cae durante la ejecución.
+	Checkpoint json.RawMessage `json:"checkpoint,omitempty"`
+
+	// En "running": worker dueño del job y vencimiento de su lease, que
+	// renuevan sus heartbeats (ver lease.go).
+	Lease *Lease `json:"lease,omitempty"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-}
\ No newline at end of file
+}
+
+// Terminal indica si el estado es final (el job ya no va a cambiar).
+func (s JobStatus) Terminal() bool {
+	switch s {
+	case StatusDone, StatusError, StatusCanceled, StatusTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Leases de ejecución: un job en "running" pertenece al worker que lo
+// ejecuta (una goroutine del servidor, un proceso aislado o un nodo remoto)
+// mientras este lo renueve con heartbeats. Los heartbeats no dependen del
+// progreso de la tarea: un sortfile largo que no informa avance conserva su
+// lease, y un worker que muere o se cuelga lo pierde en un TTL.
+//
+//	goroutine local   el worker renueva mientras espera a la tarea (runJob)
+//	proceso aislado   el hijo manda "heartbeat" por el pipe (runRequest)
+//	nodo remoto       el nodo manda "heartbeat" por la conexión (runRequest)
+//
+// Un lease vencido cancela la ejecución y aplica la acción de la tarea:
+// LeaseRequeue devuelve el job a la cola, LeaseFail lo hace fallar.
+
+var ErrLeaseExpired = errors.New("lease vencido: el worker dejó de enviar heartbeats")
+
+const (
+	defaultLeaseTTL = 30 * time.Second       // cuánto dura un lease sin heartbeats
+	minLeaseTTL     = 100 * time.Millisecond // por debajo, los heartbeats saturan al Manager
+)
+
+// defaultLeaseAttempts acota los intentos de un job que pierde su lease
+// una y otra vez (p.ej. uno que tira abajo su nodo) en las tareas sin
+// política de reintentos.
+const defaultLeaseAttempts = 3
+
+// LeaseAction es lo que se hace con un job cuyo lease venció.
+type LeaseAction string
+
+const (
+	LeaseRequeue LeaseAction = "requeue" // vuelve a la cola sin registrar un error (por defecto), hasta agotar los intentos
+	LeaseFail    LeaseAction = "fail"    // falla con ErrLeaseExpired y pasa por la política de reintentos
+)
+
+// Lease es el dueño de un job en ejecución y hasta cuándo lo es.
+type Lease struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// WithLease fija el vencimiento de los leases y la acción por defecto al
+// vencer (30s y LeaseRequeue si no se indica). Los heartbeats se envían
+// cada tercio del TTL. Un TTL menor a 100ms se ignora.
+func WithLease(ttl time.Duration, action LeaseAction) ManagerOption {
+	return func(m *Manager) {
+		switch {
+		case ttl >= minLeaseTTL:
+			m.leaseTTL = ttl
+		case ttl != 0:
+			fmt.Printf("[Manager] lease de %v menor al mínimo de %v, se usa %v\n", ttl, minLeaseTTL, m.leaseTTL)
+		}
+		switch action {
+		case LeaseRequeue, LeaseFail:
+			m.leaseAction = action
+		default:
+			fmt.Printf("[Manager] acción de lease desconocida %q, se usa %s\n", action, LeaseRequeue)
+		}
+	}
+}
+
+// WithLeaseAction cambia, para una tarea, qué se hace con sus jobs cuando
+// vence el lease.
+func WithLeaseAction(action LeaseAction) TaskOption {
+	return func(tc *taskConf) {
+		switch action {
+		case LeaseRequeue, LeaseFail:
+			tc.leaseAction = action
+		}
+	}
+}
+
+// newLeaseLocked crea el lease de un job que pasa a running. Requiere m.mu
+// tomado.
+func (m *Manager) newLeaseLocked(j *Job, owner string) {
+	j.Lease = &Lease{Owner: owner, ExpiresAt: time.Now().Add(m.leaseTTL)}
+}
+
+// renewLease extiende el lease de un job en ejecución. El Lease se
+// reemplaza en lugar de modificarse: las copias del Job que devuelve
+// GetStatus comparten el puntero.
+func (m *Manager) renewLease(jobID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j, ok := m.jobs[jobID]; ok && j.Status == StatusRunning && j.Lease != nil {
+		j.Lease = &Lease{Owner: j.Lease.Owner, ExpiresAt: time.Now().Add(m.leaseTTL)}
+	}
+}
+
+// setLeaseOwner cambia el dueño del lease, p.ej. cuando un pool aislado
+// entrega el job a uno de sus procesos.
+func (m *Manager) setLeaseOwner(jobID, owner string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j, ok := m.jobs[jobID]; ok && j.Status == StatusRunning && j.Lease != nil {
+		j.Lease = &Lease{Owner: owner, ExpiresAt: j.Lease.ExpiresAt}
+	}
+}
+
+// heartbeat renueva el lease de jobID hasta que ctx se cancela. Es el
+// heartbeat de los workers que ejecutan la tarea en una goroutine local.
+func (m *Manager) heartbeat(ctx context.Context, jobID string) {
+	t := time.NewTicker(m.leaseTTL / 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			m.renewLease(jobID)
+		}
+	}
+}
+
+func (m *Manager) leaseLoop() {
+	t := time.NewTicker(m.leaseTTL / 4)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.stopCleanup:
+			return
+		case now := <-t.C:
+			m.expireLeases(now)
+		}
+	}
+}
+
+// expireLeases cancela, con causa ErrLeaseExpired, la ejecución de los jobs
+// cuyo lease venció antes de now; runJob aplica luego la acción de la
+// tarea. Un job en running sin ejecución registrada (nadie va a cerrarlo)
+// recibe la acción directamente.
+func (m *Manager) expireLeases(now time.Time) {
+	type expired struct {
+		id     string
+		cancel context.CancelCauseFunc
+		err    error
+	}
+	var list []expired
+	m.mu.Lock()
+	for id, j := range m.jobs {
+		if j.Status != StatusRunning || j.Lease == nil || now.Before(j.Lease.ExpiresAt) {
+			continue
+		}
+		err := fmt.Errorf("%w (%s, sin heartbeat en %v)", ErrLeaseExpired, j.Lease.Owner, m.leaseTTL)
+		list = append(list, expired{id, m.running[id], err})
+	}
+	m.mu.Unlock()
+
+	for _, e := range list {
+		fmt.Printf("[Manager] job %s: %v\n", e.id, e.err)
+		if e.cancel != nil {
+			e.cancel(e.err)
+		} else {
+			m.leaseExpired(e.id, e.err)
+		}
+	}
+}
+
+// leaseExpired aplica la acción de la tarea a un job que perdió su lease.
+// LeaseRequeue pasa a LeaseFail al llegar al tope de intentos de la tarea
+// (su RetryPolicy o defaultLeaseAttempts): el job va a la DLQ como agotado.
+func (m *Manager) leaseExpired(jobID string, cause error) {
+	m.mu.RLock()
+	action, attempt, limit := m.leaseAction, 0, defaultLeaseAttempts
+	if j, ok := m.jobs[jobID]; ok {
+		attempt = j.Attempt
+		if tc, ok := m.tasks[j.Task]; ok {
+			if tc.leaseAction != "" {
+				action = tc.leaseAction
+			}
+			if tc.retry.MaxAttempts > 1 {
+				limit = tc.retry.MaxAttempts
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	if action == LeaseRequeue && attempt >= limit {
+		fmt.Printf("[Manager] job %s perdió el lease en %d intentos: no vuelve a la cola\n", jobID, attempt)
+		action = LeaseFail
+	}
+	if action == LeaseFail {
+		m.fail(jobID, StatusError, cause)
+		return
+	}
+	m.requeueLost(jobID, cause)
+}
+
+// requeueLost devuelve a la cola un job cuyo lease venció. El intento
+// cuenta, pero el error no pasa por la política de reintentos: el job no
+// falló, se perdió su worker. Conserva el checkpoint solo con RecoverResume.
+func (m *Manager) requeueLost(jobID string, cause error) {
+	m.mu.Lock()
+	j, ok := m.jobs[jobID]
+	if !ok || j.Status != StatusRunning {
+		m.mu.Unlock()
+		return
+	}
+	tc, pool := m.tasks[j.Task], m.pools[j.Task]
+	if tc.recovery != RecoverResume {
+		j.Checkpoint = nil
+	}
+	j.Status = StatusQueued
+	m.idx.add(j)
+	j.Lease = nil
+	j.Progress = 0
+	j.Stage = ""
+	j.ETAMs = 0
+	j.UpdatedAt = time.Now()
+	m.mu.Unlock()
+
+	fmt.Printf("[Manager] job %s vuelve a la cola: %v\n", jobID, cause)
+	if err := pool.Queue.Push(j); err != nil {
+		// cola llena: entra cuando haya lugar, como un reintento
+		m.mu.Lock()
+		j.Status = StatusRetrying
+		m.idx.add(j)
+		next := time.Now().Add(time.Second)
+		j.NextAttemptAt = &next
+		m.mu.Unlock()
+		m.scheduleRetry(jobID, time.Second)
+	}
+	m.persist(jobID)
+}
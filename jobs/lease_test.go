@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// napTask duerme ms milisegundos sin informar progreso
+func napTask(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+	ms, _ := strconv.Atoi(params["ms"])
+	select {
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+		return map[string]any{"pid": os.Getpid()}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TestLease_SilentTaskKeepsLease prueba que una tarea larga sin progreso
+// conserva el lease gracias a los heartbeats del worker
+func TestLease_SilentTaskKeepsLease(t *testing.T) {
+	m := NewManager("", time.Minute, time.Minute, WithLease(100*time.Millisecond, LeaseFail))
+	defer m.Close()
+	m.Register("nap", napTask, 1, 4, 5*time.Second)
+
+	id, _, _ := m.Submit("nap", url.Values{"ms": {"600"}}, PrioNormal)
+	waitStatus(t, m, id, StatusRunning)
+	j, _ := m.GetStatus(id)
+	if j.Lease == nil || !strings.HasPrefix(j.Lease.Owner, "worker-0 (pid ") || !j.Lease.ExpiresAt.After(time.Now()) {
+		t.Fatalf("lease = %+v; se esperaba uno vigente del worker-0", j.Lease)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	m.CleanupOnce()
+	if j, _ := m.GetStatus(id); j.Status != StatusRunning {
+		t.Fatalf("status = %s tras tres TTL sin progreso; se esperaba running", j.Status)
+	}
+	j = waitStatus(t, m, id, StatusDone)
+	if j.Lease != nil || j.Attempt != 1 {
+		t.Errorf("lease = %+v en el intento %d; se esperaba sin lease en el intento 1", j.Lease, j.Attempt)
+	}
+}
+
+// TestLease_StoppedProcess prueba que un proceso aislado detenido pierde el
+// lease: se lo mata y el job vuelve a la cola
+func TestLease_StoppedProcess(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SIGSTOP solo en Linux")
+	}
+	m := NewManager("", time.Minute, time.Minute, WithLease(300*time.Millisecond, LeaseRequeue))
+	defer m.Close()
+	registerIsolated(m)
+
+	id, _, _ := m.Submit("nap", url.Values{"ms": {"800"}}, PrioNormal)
+	var pid int
+	waitUntil(t, func() bool {
+		j, _ := m.GetStatus(id)
+		if j.Lease == nil || !strings.HasPrefix(j.Lease.Owner, "proceso ") {
+			return false
+		}
+		pid, _ = strconv.Atoi(strings.TrimPrefix(j.Lease.Owner, "proceso "))
+		return true
+	})
+	syscall.Kill(pid, syscall.SIGSTOP)
+
+	j := waitStatus(t, m, id, StatusDone)
+	if j.Attempt != 2 || j.Error != "" {
+		t.Errorf("intento %d, error %q; se esperaba el intento 2 sin error", j.Attempt, j.Error)
+	}
+	if got := int(j.Result.(map[string]any)["pid"].(float64)); got == pid {
+		t.Errorf("el job terminó en el proceso detenido %d", pid)
+	}
+}
+
+// TestLease_OrphanAction prueba la acción de cada tarea sobre un job en
+// running cuyo lease venció sin que nadie lo ejecute
+func TestLease_OrphanAction(t *testing.T) {
+	m := NewManager("", time.Minute, time.Minute)
+	defer m.Close()
+	block := func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	m.Register("requeue", block, 1, 4, 5*time.Second)
+	m.Register("fail", block, 1, 4, 5*time.Second, WithLeaseAction(LeaseFail))
+	// sin workers libres: los huérfanos esperan en la cola
+	m.Submit("requeue", nil, PrioNormal)
+	m.Submit("fail", nil, PrioNormal)
+
+	expired := time.Now().Add(-time.Second)
+	for _, task := range []string{"requeue", "fail"} {
+		m.mu.Lock()
+		m.jobs["orphan-"+task] = &Job{ID: "orphan-" + task, Task: task, Status: StatusRunning, Attempt: 1,
+			Lease: &Lease{Owner: "worker-9 (pid 1)", ExpiresAt: expired}, CreatedAt: expired}
+		m.mu.Unlock()
+	}
+	m.CleanupOnce()
+
+	j, _ := m.GetStatus("orphan-requeue")
+	if j.Status != StatusQueued || j.Lease != nil || j.Error != "" {
+		t.Errorf("requeue: status %s, lease %+v, error %q; se esperaba queued sin lease ni error", j.Status, j.Lease, j.Error)
+	}
+	j, _ = m.GetStatus("orphan-fail")
+	if j.Status != StatusError || j.Lease != nil || !strings.Contains(j.Error, ErrLeaseExpired.Error()) {
+		t.Errorf("fail: status %s, lease %+v, error %q; se esperaba error por lease vencido", j.Status, j.Lease, j.Error)
+	}
+	if len(j.Errors) != 1 || j.Errors[0].Class != ClassTimeout {
+		t.Errorf("errores = %+v; se esperaba uno de clase timeout", j.Errors)
+	}
+}
+
+// TestLease_RequeueCap prueba que un job que pierde el lease en todos sus
+// intentos deje de volver a la cola y termine en la DLQ
+func TestLease_RequeueCap(t *testing.T) {
+	m := NewManager("", time.Minute, time.Minute)
+	defer m.Close()
+	block := func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	m.Register("poison", block, 1, 4, 5*time.Second)
+	m.Register("retried", block, 1, 4, 5*time.Second, WithRetry(RetryPolicy{MaxAttempts: 5}))
+	m.Submit("poison", nil, PrioNormal)
+	m.Submit("retried", nil, PrioNormal)
+
+	expired := time.Now().Add(-time.Second)
+	orphan := func(id, task string, attempt int) {
+		m.mu.Lock()
+		m.jobs[id] = &Job{ID: id, Task: task, Status: StatusRunning, Attempt: attempt,
+			Lease: &Lease{Owner: "nodo x (pid 1)", ExpiresAt: expired}, CreatedAt: expired}
+		m.mu.Unlock()
+	}
+	orphan("poison-1", "poison", defaultLeaseAttempts)
+	orphan("retried-3", "retried", 3) // la política de la tarea permite más
+	m.CleanupOnce()
+
+	j, _ := m.GetStatus("poison-1")
+	if j.Status != StatusError || !strings.Contains(j.Error, ErrLeaseExpired.Error()) {
+		t.Errorf("poison: status %s, error %q; se esperaba error por lease vencido", j.Status, j.Error)
+	}
+	if dl := m.DeadLetters(DeadLetterFilter{ID: "poison-1"}); len(dl) != 1 || dl[0].Reason != ReasonExhausted {
+		t.Errorf("DLQ = %+v; se esperaba poison-1 con reason %s", dl, ReasonExhausted)
+	}
+	if j, _ := m.GetStatus("retried-3"); j.Status != StatusQueued {
+		t.Errorf("retried: status %s; se esperaba queued (intento 3 de 5)", j.Status)
+	}
+}
+
+// TestLease_MinTTL prueba que un TTL demasiado chico se ignora en lugar de
+// hacer entrar en pánico a los tickers de los heartbeats
+func TestLease_MinTTL(t *testing.T) {
+	m := NewManager("", time.Minute, time.Minute, WithLease(2*time.Nanosecond, LeaseRequeue))
+	defer m.Close()
+	if m.leaseTTL != defaultLeaseTTL {
+		t.Fatalf("leaseTTL = %v; se esperaba %v", m.leaseTTL, defaultLeaseTTL)
+	}
+	m.Register("nap", napTask, 1, 4, 5*time.Second)
+	id, _, _ := m.Submit("nap", url.Values{"ms": {"10"}}, PrioNormal)
+	waitStatus(t, m, id, StatusDone)
+}
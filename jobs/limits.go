@@ -0,0 +1,236 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Límites de recursos por job: memoria, tamaño del resultado y bytes
+// escritos a disco. La tarea los fija con WithLimits y cada envío puede
+// bajarlos (nunca subirlos) con max_memory, max_output y max_disk.
+//
+// Se controlan en tres momentos:
+//   - al enviar y antes de ejecutar, con la estimación de WithEstimator;
+//   - durante la ejecución, con lo que la tarea declara por
+//     Progress.ReserveMemory y Progress.WriteDisk;
+//   - al terminar, con el tamaño del resultado serializado.
+//
+// Un job que excede un límite falla sin reintentos y con error_code
+// memory_limit, disk_limit u output_limit.
+
+// LimitCode identifica el límite excedido.
+type LimitCode string
+
+const (
+	LimitMemory LimitCode = "memory_limit"
+	LimitDisk   LimitCode = "disk_limit"
+	LimitOutput LimitCode = "output_limit"
+	LimitCPU    LimitCode = "cpu_limit" // RLIMIT_CPU de un proceso worker (ver process.go)
+)
+
+var (
+	ErrLimitExceeded = errors.New("límite de recursos excedido")
+	ErrInvalidLimit  = errors.New("límite de recursos inválido")
+)
+
+// Parámetros de envío que fijan los límites del job (no llegan a la tarea).
+var limitParams = map[string]LimitCode{
+	"max_memory": LimitMemory,
+	"max_disk":   LimitDisk,
+	"max_output": LimitOutput,
+}
+
+// ResourceLimits son los límites en bytes; 0 = sin límite.
+type ResourceLimits struct {
+	Memory int64 `json:"memory,omitempty"`
+	Disk   int64 `json:"disk,omitempty"`
+	Output int64 `json:"output,omitempty"`
+}
+
+// ResourceUsage es el uso estimado o medido de un job, en bytes.
+type ResourceUsage struct {
+	Memory int64
+	Disk   int64
+	Output int64
+}
+
+// LimitError describe un límite excedido.
+type LimitError struct {
+	Code      LimitCode
+	Limit     int64
+	Used      int64
+	Estimated bool // rechazado antes de ejecutar, por la estimación
+}
+
+func (e *LimitError) Error() string {
+	if e.Code == LimitCPU {
+		return fmt.Sprintf("%s: el job superó %d s de CPU", e.Code, e.Limit)
+	}
+	verb := "usó"
+	if e.Estimated {
+		verb = "necesitaría"
+	}
+	return fmt.Sprintf("%s: el job %s %s y el límite es %s", e.Code, verb, formatBytes(e.Used), formatBytes(e.Limit))
+}
+
+func (e *LimitError) Unwrap() error { return ErrLimitExceeded }
+
+// ErrorCode devuelve el código de un error de límite ("" si no lo es).
+func ErrorCode(err error) LimitCode {
+	var le *LimitError
+	if errors.As(err, &le) {
+		return le.Code
+	}
+	return ""
+}
+
+// tighten combina los límites de la tarea con los del job: gana el menor.
+func (l ResourceLimits) tighten(job *ResourceLimits) ResourceLimits {
+	if job == nil {
+		return l
+	}
+	min := func(a, b int64) int64 {
+		if b > 0 && (a == 0 || b < a) {
+			return b
+		}
+		return a
+	}
+	return ResourceLimits{
+		Memory: min(l.Memory, job.Memory),
+		Disk:   min(l.Disk, job.Disk),
+		Output: min(l.Output, job.Output),
+	}
+}
+
+// override devuelve l con los límites que fija over en su lugar. Con ambos
+// nil devuelve nil.
+func (l *ResourceLimits) override(over *ResourceLimits) *ResourceLimits {
+	if l == nil {
+		return over
+	}
+	out := *l
+	if over != nil {
+		if over.Memory > 0 {
+			out.Memory = over.Memory
+		}
+		if over.Disk > 0 {
+			out.Disk = over.Disk
+		}
+		if over.Output > 0 {
+			out.Output = over.Output
+		}
+	}
+	return &out
+}
+
+// check compara un uso con los límites.
+func (l ResourceLimits) check(u ResourceUsage, estimated bool) error {
+	switch {
+	case l.Memory > 0 && u.Memory > l.Memory:
+		return &LimitError{Code: LimitMemory, Limit: l.Memory, Used: u.Memory, Estimated: estimated}
+	case l.Disk > 0 && u.Disk > l.Disk:
+		return &LimitError{Code: LimitDisk, Limit: l.Disk, Used: u.Disk, Estimated: estimated}
+	case l.Output > 0 && u.Output > l.Output:
+		return &LimitError{Code: LimitOutput, Limit: l.Output, Used: u.Output, Estimated: estimated}
+	}
+	return nil
+}
+
+// splitLimitParams separa de params los límites del job. Devuelve una
+// copia sin esos parámetros y nil si el envío no fija ninguno.
+func splitLimitParams(params map[string]string) (map[string]string, *ResourceLimits, error) {
+	var limits *ResourceLimits
+	clean := make(map[string]string, len(params))
+	for k, v := range params {
+		code, ok := limitParams[k]
+		if !ok {
+			clean[k] = v
+			continue
+		}
+		n, err := ParseBytes(v)
+		if err != nil || n <= 0 {
+			return nil, nil, fmt.Errorf("%w: %s=%q", ErrInvalidLimit, k, v)
+		}
+		if limits == nil {
+			limits = &ResourceLimits{}
+		}
+		switch code {
+		case LimitMemory:
+			limits.Memory = n
+		case LimitDisk:
+			limits.Disk = n
+		case LimitOutput:
+			limits.Output = n
+		}
+	}
+	return clean, limits, nil
+}
+
+// ParseBytes interpreta un tamaño: bytes ("1048576") o con sufijo KB, MB
+// o GB en potencias de 1024 ("512KB", "64MB").
+func ParseBytes(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * mult, nil
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// limitsFor devuelve los límites efectivos de un job de la tarea task.
+func (m *Manager) limitsFor(task string, job *ResourceLimits) (ResourceLimits, *taskConf) {
+	m.mu.RLock()
+	tc := m.tasks[task]
+	m.mu.RUnlock()
+	if tc == nil {
+		return ResourceLimits{}.tighten(job), nil
+	}
+	return tc.limits.tighten(job), tc
+}
+
+// checkEstimate rechaza un job cuya estimación excede sus límites.
+func (m *Manager) checkEstimate(task string, params map[string]string, job *ResourceLimits) error {
+	limits, tc := m.limitsFor(task, job)
+	if tc == nil || tc.estimate == nil || limits == (ResourceLimits{}) {
+		return nil
+	}
+	return limits.check(tc.estimate(params), true)
+}
+
+// checkOutput controla el tamaño del resultado serializado.
+func (m *Manager) checkOutput(job *Job, res any) error {
+	limits, _ := m.limitsFor(job.Task, job.Limits)
+	if limits.Output == 0 {
+		return nil
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil
+	}
+	return limits.check(ResourceUsage{Output: int64(len(data))}, false)
+}
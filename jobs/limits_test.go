@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseBytes prueba los tamaños con y sin sufijo
+func TestParseBytes(t *testing.T) {
+	cases := map[string]int64{"512": 512, "2KB": 2 << 10, "64mb": 64 << 20, "1 GB": 1 << 30, "10B": 10}
+	for in, want := range cases {
+		if got, err := ParseBytes(in); err != nil || got != want {
+			t.Errorf("ParseBytes(%q) = %d, %v; se esperaba %d", in, got, err, want)
+		}
+	}
+	if _, err := ParseBytes("mucho"); err == nil {
+		t.Error("ParseBytes(\"mucho\"): se esperaba un error")
+	}
+}
+
+// memTask declara mb megabytes de memoria y devuelve out bytes de resultado
+func memTask(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+	mb, _ := strconv.Atoi(params["mb"])
+	if err := p.ReserveMemory(int64(mb) << 20); err != nil {
+		return nil, err
+	}
+	out, _ := strconv.Atoi(params["out"])
+	return strings.Repeat("x", out), nil
+}
+
+func limitedManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager("", time.Minute, time.Minute)
+	m.Register("mem", memTask, 1, 10, time.Second,
+		WithLimits(ResourceLimits{Memory: 4 << 20, Output: 1 << 10}),
+		WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+		WithEstimator(func(p map[string]string) ResourceUsage {
+			est, _ := strconv.Atoi(p["est"])
+			return ResourceUsage{Memory: int64(est) << 20}
+		}))
+	return m
+}
+
+// TestManager_LimitsOnSubmit prueba el rechazo por estimación y los límites por job
+func TestManager_LimitsOnSubmit(t *testing.T) {
+	m := limitedManager(t)
+	defer m.Close()
+
+	_, _, err := m.Submit("mem", url.Values{"est": {"8"}}, PrioNormal)
+	var le *LimitError
+	if !errors.As(err, &le) || le.Code != LimitMemory || !le.Estimated {
+		t.Fatalf("estimación de 8 MB con límite de 4 MB: err = %v", err)
+	}
+
+	// un job puede bajar el límite de la tarea, pero no subirlo
+	if _, _, err := m.Submit("mem", url.Values{"est": {"2"}, "max_memory": {"1MB"}}, PrioNormal); ErrorCode(err) != LimitMemory {
+		t.Errorf("max_memory=1MB con 2 MB estimados: err = %v", err)
+	}
+	if _, _, err := m.Submit("mem", url.Values{"est": {"8"}, "max_memory": {"1GB"}}, PrioNormal); ErrorCode(err) != LimitMemory {
+		t.Errorf("max_memory=1GB no debería subir el límite de la tarea: err = %v", err)
+	}
+	if _, _, err := m.Submit("mem", url.Values{"max_disk": {"-1"}}, PrioNormal); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("max_disk=-1: err = %v; se esperaba ErrInvalidLimit", err)
+	}
+
+	id, _, err := m.Submit("mem", url.Values{"est": {"2"}, "max_output": {"2KB"}}, PrioNormal)
+	if err != nil {
+		t.Fatalf("Submit dentro de los límites: %v", err)
+	}
+	j := waitStatus(t, m, id, StatusDone)
+	if _, ok := j.Params["max_output"]; ok || j.Limits == nil || j.Limits.Output != 2<<10 {
+		t.Errorf("job = params %v, limits %+v; el límite debía quedar fuera de los parámetros", j.Params, j.Limits)
+	}
+}
+
+// TestManager_LimitsAtRuntime prueba que un job que cruza un límite falle sin reintentos y con su código
+func TestManager_LimitsAtRuntime(t *testing.T) {
+	m := limitedManager(t)
+	defer m.Close()
+
+	cases := []struct {
+		params url.Values
+		code   LimitCode
+	}{
+		{url.Values{"mb": {"8"}}, LimitMemory},
+		{url.Values{"out": {"4096"}}, LimitOutput},
+		{url.Values{"out": {"512"}, "max_output": {"100"}}, LimitOutput},
+	}
+	for _, c := range cases {
+		id, _, err := m.Submit("mem", c.params, PrioNormal)
+		if err != nil {
+			t.Fatalf("Submit %v: %v", c.params, err)
+		}
+		j := waitStatus(t, m, id, StatusError)
+		if j.ErrorCode != c.code || j.Attempt != 1 {
+			t.Errorf("%v: error_code = %q, intento %d; se esperaba %s sin reintentos", c.params, j.ErrorCode, j.Attempt, c.code)
+		}
+		if dl := m.DeadLetters(DeadLetterFilter{ID: id}); len(dl) != 1 || dl[0].Reason != ReasonLimit {
+			t.Errorf("%v: DLQ = %+v; se esperaba el motivo %s", c.params, dl, ReasonLimit)
+		}
+	}
+}
+
+// TestManager_LimitsOnRequeue prueba que reencolar desde la DLQ conserve los
+// límites del job y trate los overrides como un envío
+func TestManager_LimitsOnRequeue(t *testing.T) {
+	m := limitedManager(t)
+	defer m.Close()
+	m.dlq.add(&DeadLetter{Job: Job{ID: "dead-1", Task: "mem", Params: map[string]string{"est": "1"},
+		Limits: &ResourceLimits{Memory: 2 << 20}}, Reason: ReasonLimit, DeadAt: time.Now()})
+
+	if _, err := m.RequeueDeadLetter("dead-1", map[string]string{"est": "3"}); ErrorCode(err) != LimitMemory {
+		t.Fatalf("override est=3 con el límite de 2 MB del job: err = %v", err)
+	}
+	id, err := m.RequeueDeadLetter("dead-1", map[string]string{"max_output": "512"})
+	if err != nil {
+		t.Fatalf("RequeueDeadLetter: %v", err)
+	}
+	j := waitStatus(t, m, id, StatusDone)
+	if _, ok := j.Params["max_output"]; ok || j.Limits == nil || *j.Limits != (ResourceLimits{Memory: 2 << 20, Output: 512}) {
+		t.Errorf("job = params %v, limits %+v; se esperaban los límites del original más max_output", j.Params, j.Limits)
+	}
+}
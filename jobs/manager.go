@@ -1,13 +1,14 @@
 package jobs
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
 	"net/url"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,8 +17,17 @@ var (
 	ErrBackpressure  = errors.New("cola llena: backpressure")
 	ErrJobNotFound   = errors.New("job no encontrado")
 	ErrNotCancelable = errors.New("job no cancelable")
+
+	// Causas de cancelación del contexto que recibe cada TaskFunc
+	ErrJobCanceled = errors.New("job cancelado")
+	ErrJobTimeout  = errors.New("timeout")
+	ErrShutdown    = errors.New("apagado del servidor")
 )
 
+// Tiempo que runJob espera a que una tarea cancelada retorne antes de
+// liberar al worker.
+const cancelGrace = 2 * time.Second
+
 // -----------------------------------------------------------------------------
 // Configuración por tarea
 // -----------------------------------------------------------------------------
@@ -25,21 +35,66 @@ type taskConf struct {
 	fn         TaskFunc
 	timeout    time.Duration
 	pool       *WorkerPool
+	retry      RetryPolicy
+	cache      bool // resultados en la caché del Manager (ver cache.go)
+	coalesce   bool // envíos idénticos comparten la ejecución (ver coalesce.go)
+	recovery   RecoveryPolicy // jobs interrumpidos por una caída (ver recovery.go)
+	autoscale  *ScalePolicy   // nil = pool de tamaño fijo (ver autoscale.go)
+	disabled   bool           // los envíos se rechazan con ErrTaskDisabled (ver pooladmin.go)
+	class      TaskClass      // cpu = comparte el presupuesto de CPU (ver budget.go)
+	weight     int
+	limits     ResourceLimits // por job (ver limits.go)
+	estimate   func(params map[string]string) ResourceUsage
+	isolation  *ProcessLimits // nil = goroutines del servidor (ver process.go)
+	remoteOnly bool           // sin workers locales (ver remote.go)
+
+	leaseAction LeaseAction // "" = la del Manager (ver lease.go)
 }
 
 
 
 type ManagerInterface interface {
 	Submit(task string, params url.Values, prio JobPriority) (string, JobStatus, error)
+	SubmitJob(req SubmitRequest) (string, JobStatus, error)
 	GetStatus(jobID string) (*Job, error)
 	GetResult(jobID string) (*Job, error)
 	Cancel(jobID string) (JobStatus, error)
-	Register(name string, task TaskFunc, workers int, queueDepth int, timeout time.Duration)
+	Register(name string, task TaskFunc, workers int, queueDepth int, timeout time.Duration, opts ...TaskOption)
 	Close()
 	WorkerStats() map[string]any
 	QueueSizes() map[string]int
+	QueueDepthsByPriority() map[string]map[string]int
 	JobsSnapshot() map[string]*Job
 	CleanupOnce()
+	DebugDump() ManagerDump
+	Readiness() []HealthCheck
+	DeadLetters(f DeadLetterFilter) []DeadLetter
+	RequeueDeadLetter(id string, overrides map[string]string) (string, error)
+	PurgeDeadLetters(f DeadLetterFilter) (int, error)
+	CreateSchedule(spec ScheduleSpec) (*Schedule, error)
+	Schedules() []Schedule
+	GetSchedule(id string) (*Schedule, error)
+	UpdateSchedule(id string, u ScheduleUpdate) (*Schedule, error)
+	DeleteSchedule(id string) error
+	SubmitWorkflow(spec WorkflowSpec) (*Workflow, error)
+	GetWorkflow(id string) (*Workflow, error)
+	CancelWorkflow(id string) (*Workflow, error)
+	SubmitBatch(spec BatchSpec) (*BatchResult, error)
+	GetBatch(id string) (*BatchStatus, error)
+	CancelBatch(id string) (int, error)
+	CacheFor(task string) *ResultCache
+	CacheStats() *CacheStats
+	Coalesces(task string) bool
+	RecoveryReport() map[string]RecoveryStats
+	ListJobs(q JobQuery) (JobPage, error)
+	PoolStates() map[string]PoolState
+	ResizePool(task string, workers, queueCap int) (PoolState, error)
+	PausePool(task string) (PoolState, error)
+	ResumePool(task string) (PoolState, error)
+	DrainPool(task string, timeout time.Duration) (PoolState, error)
+	SetTaskEnabled(task string, enabled bool) (PoolState, error)
+	WorkerStatus() map[string][]WorkerInfo
+	Nodes() []NodeInfo
 }
 // -----------------------------------------------------------------------------
 // Manager: maneja jobs, pools, persistencia y limpieza
@@ -50,41 +105,108 @@ type Manager struct {
 	tasks map[string]*taskConf
 	jobs  map[string]*Job
 	pools map[string]*WorkerPool
+	idx   jobIndex // jobs por estado y por tarea, para ListJobs (ver query.go)
 
 	file            string
 	ttl             time.Duration
 	cleanupInterval time.Duration
 	stopCleanup     chan struct{}
+
+	// salud de la persistencia y del ciclo de vida (ver health.go)
+	persistMu       sync.Mutex
+	persistErr      error
+	persistFailures int
+	lastPersistOK   time.Time
+	critical        map[string]bool
+	shuttingDown    atomic.Bool
+
+	// contexto raíz de todas las ejecuciones: se cancela en Close
+	ctx     context.Context
+	stopAll context.CancelCauseFunc
+	running map[string]context.CancelCauseFunc // job -> cancelación
+
+	dlq   *deadLetterStore // jobs fallidos de forma permanente (ver dlq.go)
+	sched *scheduler       // jobs diferidos y reintentos pendientes (ver scheduler.go)
+
+	// Jobs recurrentes (ver schedules.go): cronSched usa ids de schedule
+	cron      *cronTable
+	cronSched *scheduler
+
+	wf *workflowTable // DAGs de pasos (ver workflow.go)
+
+	idem *idemStore // idempotency key -> job (ver idempotency.go)
+
+	cache *ResultCache // resultados de tareas deterministas; nil = sin caché
+
+	// Almacenamiento de jobs (ver store.go): persist vuelca ahí los cambios
+	store         Store
+	storeMu       sync.Mutex  // serializa persist
+	fsync         FsyncPolicy // del WALStore por defecto
+	snapshotEvery int
+
+	// Envíos idénticos en curso (ver coalesce.go)
+	inflight  map[string]string   // clave de contenido -> líder
+	followers map[string][]string // líder -> seguidores
+
+	recovery map[string]RecoveryStats // lo recuperado al registrar cada tarea
+
+	cpu *cpuBudget // tokens de los jobs de clase cpu (ver budget.go)
+
+	nodes *nodeTable // nodos remotos conectados (ver remote.go)
+
+	// Leases de los jobs en ejecución (ver lease.go)
+	leaseTTL    time.Duration
+	leaseAction LeaseAction
 }
 
 // NewManager inicializa el Manager con persistencia y limpieza periódica
-func NewManager(file string, ttl, cleanupInterval time.Duration) *Manager {
+func NewManager(file string, ttl, cleanupInterval time.Duration, opts ...ManagerOption) *Manager {
 	m := &Manager{
 		tasks:           make(map[string]*taskConf),
 		jobs:            make(map[string]*Job),
+		idx:             newJobIndex(),
 		pools:           make(map[string]*WorkerPool),
 		file:            file,
 		ttl:             ttl,
 		cleanupInterval: cleanupInterval,
 		stopCleanup:     make(chan struct{}),
-	}
-
-	// Cargar jobs persistidos
-	if _, err := os.Stat(file); err == nil {
-		if data, err := os.ReadFile(file); err == nil && len(data) > 0 {
-			_ = json.Unmarshal(data, &m.jobs)
-		}
-	}
-
-	// Arranca limpieza automática
+		critical:        make(map[string]bool),
+		running:         make(map[string]context.CancelCauseFunc),
+		inflight:        make(map[string]string),
+		followers:       make(map[string][]string),
+		recovery:        make(map[string]RecoveryStats),
+		dlq:             newDeadLetterStore(dlqFileFor(file)),
+		cron:            newCronTable(schedulesFileFor(file)),
+		wf:              newWorkflowTable(workflowsFileFor(file)),
+		idem:            newIdemStore(idempotencyFileFor(file), defaultIdempotencyTTL),
+		cpu:             newCPUBudget(0),
+		nodes:           newNodeTable(),
+		leaseTTL:        defaultLeaseTTL,
+		leaseAction:     LeaseRequeue,
+	}
+	m.ctx, m.stopAll = context.WithCancelCause(context.Background())
+	m.sched = newScheduler(m.requeue)
+	m.cronSched = newScheduler(m.fireSchedule)
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	// Cargar jobs persistidos (y migrar un jobstore.json anterior)
+	m.openStore()
+
+	// Arranca limpieza automática y el planificador de jobs diferidos
 	go m.cleanupLoop()
+	go m.leaseLoop()
+	go m.sched.run()
+	go m.cronSched.run()
 	return m
 }
 
 // -----------------------------------------------------------------------------
 // Registro de tareas y creación de pools
 // -----------------------------------------------------------------------------
-func (m *Manager) Register(name string, fn TaskFunc, workers, queueDepth int, timeout time.Duration) {
+func (m *Manager) Register(name string, fn TaskFunc, workers, queueDepth int, timeout time.Duration, opts ...TaskOption) {
 	if workers <= 0 {
 		workers = 1
 	}
@@ -92,181 +214,430 @@ func (m *Manager) Register(name string, fn TaskFunc, workers, queueDepth int, ti
 		queueDepth = 1
 	}
 
-	queue := make(chan *Job, queueDepth)
+	queue := NewPriorityQueue(queueDepth, defaultAging)
 
 	pool := NewWorkerPool(name, workers, queue, m)
-	pool.Start()
+	tc := &taskConf{fn: fn, timeout: timeout, pool: pool}
+	for _, opt := range opts {
+		opt(tc)
+	}
+	if tc.autoscale != nil {
+		pool.scaler = newAutoscaler(*tc.autoscale)
+		pool.initial = pool.scaler.policy.clamp(workers)
+	}
+	if tc.class == CPUBound {
+		m.cpu.register(name, tc.weight)
+		pool.budget = m.cpu
+	}
+	if tc.remoteOnly {
+		pool.scaler = nil
+		pool.initial = 0
+	}
+	if tc.isolation != nil {
+		pool.procs = newProcPool(name, *tc.isolation, pool.Size)
+		tc.fn = pool.procs.run
+	}
 
 	m.mu.Lock()
-	m.tasks[name] = &taskConf{fn: fn, timeout: timeout, pool: pool}
+	m.tasks[name] = tc
 	m.pools[name] = pool
 	m.mu.Unlock()
+
+	// Los jobs persistidos de la tarea se recuperan recién ahora que su
+	// pool existe (ver recovery.go); uno diferido que venció durante el
+	// reinicio se encola de inmediato.
+	m.recoverTask(name, tc)
+	pool.Start()
+	if pool.procs != nil {
+		pool.procs.prestart(pool.Size())
+	}
+	m.armSchedules(name)
+	m.resumeWorkflows(name)
 }
 
 // -----------------------------------------------------------------------------
 // Envío y ejecución de trabajos
 // -----------------------------------------------------------------------------
 func (m *Manager) Submit(task string, params url.Values, prio JobPriority) (string, JobStatus, error) {
-	m.mu.RLock()
-	tc, ok := m.tasks[task]
-	m.mu.RUnlock()
-	if !ok {
-		return "", "", ErrTaskNotFound
-	}
+	return m.SubmitJob(SubmitRequest{Task: task, Params: params, Priority: prio})
+}
+
+// SubmitRequest describe un envío con todas sus opciones.
+type SubmitRequest struct {
+	Task     string
+	Params   url.Values
+	Priority JobPriority
+	RunAt    time.Time // cero o en el pasado = ejecutar en cuanto haya un worker libre
+
+	// Workflow y paso al que pertenece el job (ver workflow.go)
+	Workflow string
+	Step     string
+
+	// IdempotencyKey hace seguro reintentar el envío: mientras la key esté
+	// vigente, un envío idéntico devuelve el job original (ver idempotency.go).
+	IdempotencyKey string
+
+	// Limits son los límites del job (ver limits.go); max_memory, max_disk y
+	// max_output en Params los sobrescriben uno por uno.
+	Limits *ResourceLimits
 
+	// RequeuedFrom es la entrada de la DLQ de la que sale el job (ver dlq.go)
+	RequeuedFrom string
+}
+
+// submitReserved son los parámetros de /jobs/submit que enrutan el envío y
+// no llegan a la tarea: no forman parte de los parámetros del job, así que
+// tampoco de las claves de caché y coalescing.
+var submitReserved = map[string]bool{"task": true, "prio": true, "run_at": true, "delay": true, IdempotencyParam: true}
+
+// SubmitJob crea un job. Con RunAt en el futuro queda "scheduled" y el
+// planificador lo pasa a la cola de su pool al vencer.
+func (m *Manager) SubmitJob(req SubmitRequest) (string, JobStatus, error) {
 	pp := map[string]string{}
-	for k, v := range params {
+	for k, v := range req.Params {
 		if len(v) > 0 {
 			pp[k] = v[0]
 		}
 	}
+	j, err := m.newSubmitJob(req.Task, pp, req.Priority, req.Limits)
+	if err != nil {
+		return "", "", err
+	}
+	j.Workflow, j.Step = req.Workflow, req.Step
+	j.RequeuedFrom = req.RequeuedFrom
+	create := func() (JobStatus, error) {
+		if req.RunAt.After(j.CreatedAt) {
+			runAt := req.RunAt
+			j.Status = StatusScheduled
+			j.RunAt = &runAt
+			return StatusScheduled, m.scheduleNew(j)
+		}
+		if m.attachOrLead(j) {
+			m.persist(j.ID)
+			return j.Status, nil
+		}
+		return StatusQueued, m.enqueueNew(j)
+	}
+
+	if req.IdempotencyKey != "" {
+		return m.submitIdempotent(req.IdempotencyKey, submitSchedule(req), j, create)
+	}
+	status, err := create()
+	if err != nil {
+		return "", "", err
+	}
+	return j.ID, status, nil
+}
+
+// newSubmitJob valida un envío y arma su job sin registrarlo: la tarea
+// tiene que aceptar envíos, los parámetros reservados no llegan a la tarea
+// y los límites se separan de los parámetros y se comparan con la
+// estimación. Lo comparten SubmitJob y SubmitBatch.
+func (m *Manager) newSubmitJob(task string, params map[string]string, prio JobPriority, limits *ResourceLimits) (*Job, error) {
+	if err := m.acceptsTask(task); err != nil {
+		return nil, err
+	}
+	pp := make(map[string]string, len(params))
+	for k, v := range params {
+		if !submitReserved[k] {
+			pp[k] = v
+		}
+	}
+	pp, own, err := splitLimitParams(pp)
+	if err != nil {
+		return nil, err
+	}
+	limits = limits.override(own)
+	if err := m.checkEstimate(task, pp, limits); err != nil {
+		return nil, err
+	}
+	j := newJob(task, pp, prio)
+	j.Limits = limits
+	return j, nil
+}
 
-	j := &Job{
+func newJob(task string, params map[string]string, prio JobPriority) *Job {
+	now := time.Now()
+	return &Job{
 		ID:        genID(),
 		Task:      task,
-		Params:    pp,
+		Params:    params,
 		Status:    StatusQueued,
 		Priority:  prio,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
+}
 
-	// Encolar sin bloquear
-	select {
-	case tc.pool.Queue <- j:
-	default:
-		return "", "", ErrBackpressure
+// enqueueNew registra un job nuevo y lo encola en el pool de su tarea.
+func (m *Manager) enqueueNew(j *Job) error {
+	m.mu.RLock()
+	tc, ok := m.tasks[j.Task]
+	m.mu.RUnlock()
+	if !ok {
+		return ErrTaskNotFound
 	}
 
+	// El job se registra antes de encolarlo: un worker libre puede tomarlo
+	// de inmediato y necesita encontrarlo en el mapa.
 	m.mu.Lock()
 	m.jobs[j.ID] = j
+	m.idx.add(j)
 	m.mu.Unlock()
-	m.persist()
 
-	return j.ID, j.Status, nil
+	// Encolar sin bloquear (backpressure por clase de prioridad)
+	if err := tc.pool.Queue.Push(j); err != nil {
+		m.mu.Lock()
+		delete(m.jobs, j.ID)
+		m.idx.remove(j)
+		m.mu.Unlock()
+		// si era líder, un seguidor que se unió entretanto toma su lugar
+		m.promoteFollower(*j)
+		return err
+	}
+	m.persist(j.ID)
+	return nil
 }
 
+// scheduleNew registra un job diferido y lo entrega al planificador.
+// No ocupa lugar en la cola hasta que vence.
+func (m *Manager) scheduleNew(j *Job) error {
+	m.mu.Lock()
+	if _, ok := m.tasks[j.Task]; !ok {
+		m.mu.Unlock()
+		return ErrTaskNotFound
+	}
+	m.jobs[j.ID] = j
+	m.idx.add(j)
+	m.mu.Unlock()
+
+	m.sched.add(j.ID, *j.RunAt)
+	m.persist(j.ID)
+	return nil
+}
 
 // runJob ejecuta una tarea concreta asociada a un Job dentro del manager.
-// Se encarga de llamar a la función de tarea, manejar errores, y actualizar el estado.
+// La tarea recibe un contexto que se cancela con /jobs/cancel, al vencer el
+// timeout o durante el apagado; el estado final distingue cada caso.
 func (m *Manager) runJob(job *Job) {
+	m.runJobWith(job, nil)
+}
+
+// runJobWith es runJob con fn en lugar de la TaskFunc registrada (nil = la
+// registrada): la usan los nodos remotos (ver remote.go; el cliente está en node.go).
+func (m *Manager) runJobWith(job *Job, fn TaskFunc) {
 	m.mu.RLock()
 	tc, ok := m.tasks[job.Task]
+	attempt := job.Attempt
 	m.mu.RUnlock()
 	if !ok {
 		m.finishWithError(job.ID, fmt.Errorf("tarea '%s' no registrada", job.Task))
 		return
 	}
+	// Las goroutines locales renuevan el lease desde aquí; los procesos
+	// aislados y los nodos lo renuevan con sus propios heartbeats.
+	ownBeats := fn != nil || tc.isolation != nil
+	if fn == nil {
+		fn = tc.fn
+	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			m.finishWithError(job.ID, fmt.Errorf("panic en tarea '%s': %v", job.Task, r))
-		}
-	}()
-
-	// Canal para recibir resultado o error
-	resultCh := make(chan any, 1)
-	errCh := make(chan error, 1)
+	if res, ok := m.cachedResult(job); ok {
+		m.mu.Lock()
+		job.Cached = true
+		m.mu.Unlock()
+		m.finishWithResult(job.ID, res)
+		return
+	}
 
-	// Ejecutar tarea concurrentemente (sin bloquear worker)
-	go func() {
-		res, err := tc.fn(job.Params, job)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		resultCh <- res
-	}()
+	// Los archivos de entrada pueden haber crecido desde el envío
+	if err := m.checkEstimate(job.Task, job.Params, job.Limits); err != nil {
+		m.fail(job.ID, StatusError, err)
+		return
+	}
 
 	timeout := tc.timeout
 	if timeout <= 0 {
 		timeout = 60 * time.Second
 	}
 
-	select {
-	case res := <-resultCh:
-		m.finishWithResult(job.ID, res)
-	case err := <-errCh:
-		m.finishWithError(job.ID, err)
-	case <-time.After(timeout):
-		m.finishWithError(job.ID, fmt.Errorf("timeout tras %v", timeout))
-	}
-}
-
+	ctx, cancel := context.WithCancelCause(m.ctx)
+	ctx, cancelTimeout := context.WithTimeoutCause(ctx, timeout, ErrJobTimeout)
+	defer cancelTimeout()
+	defer cancel(nil)
 
-// RunJob ejecuta el trabajo directamente (usado por los workers)
-func (m *Manager) RunJob(j *Job) {
-	m.setStatus(j.ID, StatusRunning)
-
-	m.mu.RLock()
-	tc, ok := m.tasks[j.Task]
-	m.mu.RUnlock()
-	if !ok {
-		m.finishWithError(j.ID, fmt.Errorf("tarea desconocida"))
-		return
+	m.mu.Lock()
+	m.running[job.ID] = cancel
+	m.mu.Unlock()
+	defer func() {
+		// Si el job volvió a la cola y otro worker ya lo tomó (otro
+		// intento), la cancelación registrada es la de ese worker.
+		m.mu.Lock()
+		if j, ok := m.jobs[job.ID]; !ok || j.Status != StatusRunning || j.Attempt == attempt {
+			delete(m.running, job.ID)
+		}
+		m.mu.Unlock()
+	}()
+	if !ownBeats {
+		go m.heartbeat(ctx, job.ID)
 	}
 
-	resultCh := make(chan any, 1)
-	errCh := make(chan error, 1)
+	type outcome struct {
+		res any
+		err error
+	}
+	done := make(chan outcome, 1)
 
+	// Ejecutar tarea concurrentemente; los panics se reportan como error
 	go func() {
-		res, err := tc.fn(j.Params, j)
-		if err != nil {
-			errCh <- err
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: &panicError{fmt.Sprintf("panic en tarea '%s': %v", job.Task, r)}}
+			}
+		}()
+		res, err := fn(ctx, job.Params, newProgress(m, job))
+		done <- outcome{res, err}
+	}()
+
+	select {
+	case out := <-done:
+		if ctx.Err() != nil {
+			m.finishInterrupted(job.ID, context.Cause(ctx), timeout)
 			return
 		}
-		resultCh <- res
-	}()
+		if errors.Is(out.err, ErrLeaseExpired) {
+			m.leaseExpired(job.ID, out.err)
+			return
+		}
+		if out.err != nil {
+			m.fail(job.ID, StatusError, out.err)
+			return
+		}
+		if err := m.checkOutput(job, out.res); err != nil {
+			m.fail(job.ID, StatusError, err)
+			return
+		}
+		m.storeResult(job, out.res)
+		m.finishWithResult(job.ID, out.res)
 
-	timeout := tc.timeout
-	if timeout <= 0 {
-		timeout = 60 * time.Second
+	case <-ctx.Done():
+		m.finishInterrupted(job.ID, context.Cause(ctx), timeout)
+		// Las tareas revisan el contexto; se les da un margen para que
+		// retornen antes de liberar al worker.
+		select {
+		case <-done:
+		case <-time.After(cancelGrace):
+			fmt.Printf("[Manager] job %s no respondió a la cancelación en %v\n", job.ID, cancelGrace)
+		}
 	}
+}
 
-	select {
-	case res := <-resultCh:
-		m.finishWithResult(j.ID, res)
-		m.persist()
-	case err := <-errCh:
-		m.finishWithError(j.ID, err)
-		m.persist()
-	case <-time.After(timeout):
-		m.finishWithError(j.ID, fmt.Errorf("timeout (%s)", timeout))
-		m.persist()
+// RunJob ejecuta el trabajo directamente (fuera de un pool).
+func (m *Manager) RunJob(j *Job) {
+	if m.startJob(j.ID, fmt.Sprintf("RunJob (pid %d)", os.Getpid())) {
+		m.runJob(j)
 	}
 }
 
 // -----------------------------------------------------------------------------
 // Utilidades de control de jobs
 // -----------------------------------------------------------------------------
-func (m *Manager) setStatus(jobID string, st JobStatus) {
+
+// startJob pasa un job de queued a running con un lease a nombre de owner.
+// Devuelve false si el job ya no está en cola (por ejemplo, se canceló
+// mientras esperaba): el worker lo salta.
+func (m *Manager) startJob(jobID, owner string) bool {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	if j, ok := m.jobs[jobID]; ok {
-		j.Status = st
-		j.UpdatedAt = time.Now()
-	}
+	j, ok := m.jobs[jobID]
+	if !ok || j.Status != StatusQueued {
+		m.mu.Unlock()
+		return false
+	}
+	j.Status = StatusRunning
+	m.idx.add(j)
+	j.Attempt++
+	m.newLeaseLocked(j, owner)
+	j.UpdatedAt = time.Now()
+	m.mu.Unlock()
+	m.persist(jobID)
+	return true
 }
 
+// Los finish* solo actúan sobre jobs en ejecución: un job cancelado
+// mientras corría conserva el estado "canceled".
 func (m *Manager) finishWithResult(jobID string, res any) {
+	var final *Job
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	if j, ok := m.jobs[jobID]; ok {
+	if j, ok := m.jobs[jobID]; ok && j.Status == StatusRunning {
 		j.Status = StatusDone
+		m.idx.add(j)
+		j.Lease = nil
 		j.Result = res
 		j.Progress = 100
+		j.ETAMs = 0
 		j.UpdatedAt = time.Now()
+		cp := *j
+		final = &cp
+	}
+	m.mu.Unlock()
+	m.persist(jobID)
+	if final != nil {
+		m.jobFinished(*final)
 	}
 }
 
 func (m *Manager) finishWithError(jobID string, err error) {
+	m.finishWithStatus(jobID, StatusError, err)
+}
+
+// finishInterrupted cierra un job cuyo contexto se canceló, según la causa.
+func (m *Manager) finishInterrupted(jobID string, cause error, timeout time.Duration) {
+	switch {
+	case errors.Is(cause, ErrJobTimeout):
+		m.fail(jobID, StatusTimeout, fmt.Errorf("%w tras %v", ErrJobTimeout, timeout))
+	case errors.Is(cause, ErrShutdown):
+		// No es un estado final: el job queda "running" en el store y al
+		// reiniciar lo resuelve la política de recuperación de su tarea.
+		fmt.Printf("[Manager] job %s interrumpido por el apagado\n", jobID)
+	case errors.Is(cause, ErrLeaseExpired):
+		m.leaseExpired(jobID, cause)
+	default:
+		// Cancel ya dejó el job en "canceled" con su error
+		m.finishWithStatus(jobID, StatusCanceled, ErrJobCanceled)
+	}
+}
+
+func (m *Manager) finishWithStatus(jobID string, st JobStatus, err error) {
+	var final *Job
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	if j, ok := m.jobs[jobID]; ok {
-		j.Status = StatusError
+	if j, ok := m.jobs[jobID]; ok && j.Status == StatusRunning {
+		j.Status = st
+		m.idx.add(j)
+		j.Lease = nil
 		j.Error = err.Error()
 		j.Progress = 100
+		j.ETAMs = 0
 		j.UpdatedAt = time.Now()
+		cp := *j
+		final = &cp
+	}
+	m.mu.Unlock()
+	m.persist(jobID)
+	if final != nil {
+		m.jobFinished(*final)
+	}
+}
+
+// jobFinished se invoca, sin m.mu tomado, cada vez que un job llega a un
+// estado final. Es el único punto donde el resto del Manager reacciona al
+// cierre de un job (p.ej. para avanzar su workflow).
+func (m *Manager) jobFinished(j Job) {
+	if j.Status == StatusCanceled {
+		m.promoteFollower(j)
+	} else {
+		m.resolveFollowers(j)
+	}
+	if j.Workflow != "" {
+		m.advanceWorkflow(j.Workflow)
 	}
 }
 
@@ -278,6 +649,7 @@ func (m *Manager) GetStatus(jobID string) (*Job, error) {
 		return nil, ErrJobNotFound
 	}
 	cp := *j
+	m.mirrorLeaderLocked(&cp)
 	return &cp, nil
 }
 
@@ -286,39 +658,60 @@ func (m *Manager) GetResult(jobID string) (*Job, error) {
 }
 
 func (m *Manager) Cancel(jobID string) (JobStatus, error) {
+	final, err := m.cancel(jobID)
+	if err != nil {
+		return "", err
+	}
+	// persist toma su propio RLock: no se puede llamar con el lock tomado
+	m.persist(jobID)
+	m.jobFinished(final)
+	return final.Status, nil
+}
+
+// cancel marca el job como cancelado, lo retira de su cola y cancela su
+// contexto si está corriendo. No persiste: el llamador persiste una vez y
+// luego invoca jobFinished con el job devuelto.
+func (m *Manager) cancel(jobID string) (Job, error) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	j, ok := m.jobs[jobID]
 	if !ok {
-		return "", ErrJobNotFound
+		m.mu.Unlock()
+		return Job{}, ErrJobNotFound
 	}
-	if j.Status != StatusRunning && j.Status != StatusQueued {
-		return "", ErrNotCancelable
+	switch j.Status {
+	case StatusRunning, StatusQueued, StatusRetrying, StatusScheduled:
+	default:
+		m.mu.Unlock()
+		return Job{}, ErrNotCancelable
 	}
 	j.Status = StatusCanceled
+	m.idx.add(j)
+	j.Lease = nil
+	j.NextAttemptAt = nil
+	j.Error = ErrJobCanceled.Error()
 	j.Progress = 100
 	j.UpdatedAt = time.Now()
-	m.persist()
-	return j.Status, nil
-}
+	pool := m.pools[j.Task]
+	cancel := m.running[jobID]
+	final := *j
+	m.mu.Unlock()
 
-// -----------------------------------------------------------------------------
-// Persistencia y limpieza
-// -----------------------------------------------------------------------------
-func (m *Manager) persist() {
-	if m.file == "" {
-		return
+	// Un job en ejecución recibe la cancelación por su contexto
+	if cancel != nil {
+		cancel(ErrJobCanceled)
 	}
 
-	m.mu.RLock() 
-    defer m.mu.RUnlock()
-
-	data, err := json.MarshalIndent(m.jobs, "", "  ")
-	if err == nil {
-		_ = os.WriteFile(m.file, data, 0644)
+	// Un job en cola ya no ocupa lugar ni llega a ejecutarse
+	if pool != nil {
+		pool.Queue.Remove(jobID)
 	}
+	return final, nil
 }
 
+// -----------------------------------------------------------------------------
+// Limpieza (la persistencia está en store.go)
+// -----------------------------------------------------------------------------
+
 func (m *Manager) cleanupLoop() {
 	t := time.NewTicker(m.cleanupInterval)
 	defer t.Stop()
@@ -328,28 +721,29 @@ func (m *Manager) cleanupLoop() {
 			return
 		case <-t.C:
 			m.cleanupOnce()
+			m.snapshot()
 		}
 	}
 }
 
 func (m *Manager) cleanupOnce() {
+	m.idem.expire(time.Now())
 	if m.ttl <= 0 {
 		return
 	}
 	cut := time.Now().Add(-m.ttl)
 
 	m.mu.Lock()
-	changed := false
+	var removed []string
 	for id, j := range m.jobs {
-		if (j.Status == StatusDone || j.Status == StatusError || j.Status == StatusCanceled) && j.UpdatedAt.Before(cut) {
+		if j.Status.Terminal() && j.UpdatedAt.Before(cut) {
 			delete(m.jobs, id)
-			changed = true
+			m.idx.remove(j)
+			removed = append(removed, id)
 		}
 	}
 	m.mu.Unlock()
-	if changed {
-		m.persist()
-	}
+	m.persist(removed...)
 }
 
 // -----------------------------------------------------------------------------
@@ -366,7 +760,16 @@ func (m *Manager) WorkerStats() map[string]any {
 func (m *Manager) QueueSizes() map[string]int {
 	out := make(map[string]int)
 	for name, pool := range m.pools {
-		out[name] = len(pool.Queue)
+		out[name] = pool.Queue.Len()
+	}
+	return out
+}
+
+// QueueDepthsByPriority devuelve, por tarea, la profundidad de cada clase de prioridad.
+func (m *Manager) QueueDepthsByPriority() map[string]map[string]int {
+	out := make(map[string]map[string]int)
+	for name, pool := range m.pools {
+		out[name] = pool.Queue.Depths()
 	}
 	return out
 }
@@ -386,10 +789,21 @@ func (m *Manager) JobsSnapshot() map[string]*Job {
 // Shutdown ordenado
 // -----------------------------------------------------------------------------
 func (m *Manager) Close() {
+	m.BeginShutdown()
+	m.stopAll(ErrShutdown)
+	m.closeNodes()
 	close(m.stopCleanup)
+	m.sched.Stop()
+	m.cronSched.Stop()
 	for _, pool := range m.pools {
 		pool.Stop()
 	}
+	// Los workers terminan de cerrar sus jobs antes de cerrar el store
+	for _, pool := range m.pools {
+		pool.Wait()
+	}
+	m.nodes.wg.Wait()
+	m.closeStore()
 }
 
 // -----------------------------------------------------------------------------
@@ -400,41 +814,35 @@ func genID() string {
 }
 
 // CleanupOnce ejecuta una limpieza manual de los jobs expirados o colgados.
+// - Aplica la acción de su tarea a los jobs "running" con el lease vencido.
 // - Elimina jobs completados, cancelados o con error que superen el TTL.
-// - Marca como error los jobs "running" que llevan demasiado tiempo activos.
 func (m *Manager) CleanupOnce() {
+	m.expireLeases(time.Now())
 	if m.ttl <= 0 {
 		return
 	}
 
 	cutoff := time.Now().Add(-m.ttl)
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
-	changed := false
+	var changed []string
 	for id, job := range m.jobs {
 		switch job.Status {
-		case StatusDone, StatusError, StatusCanceled:
+		case StatusDone, StatusError, StatusCanceled, StatusTimeout:
 			// Si el job terminó y su última actualización es anterior al cutoff, se borra
 			if job.UpdatedAt.Before(cutoff) {
 				delete(m.jobs, id)
-				changed = true
-			}
-
-		case StatusRunning:
-			// Si un job lleva más del TTL corriendo, se marca como error
-			if job.UpdatedAt.Before(cutoff) {
-				job.Status = StatusError
-				job.Error = "limpieza automática: job colgado (timeout global)"
-				job.Progress = 100
-				job.UpdatedAt = time.Now()
-				changed = true
+				m.idx.remove(job)
+				changed = append(changed, id)
 			}
 		}
 	}
 
-	if changed {
-		m.persist()
-		fmt.Printf("[Manager] Limpieza ejecutada, jobs restantes: %d\n", len(m.jobs))
+	remaining := len(m.jobs)
+	m.mu.Unlock()
+
+	if len(changed) > 0 {
+		m.persist(changed...)
+		fmt.Printf("[Manager] Limpieza ejecutada, jobs restantes: %d\n", remaining)
 	}
 }
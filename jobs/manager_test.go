@@ -1,14 +1,15 @@
 package jobs
 
 import (
+	"context"
 	"net/url"
 	"testing"
 	"time"
 )
 
-func mockTask(params map[string]string, job *Job) (any, error) {
+func mockTask(ctx context.Context, params map[string]string, p *Progress) (any, error) {
 	time.Sleep(50 * time.Millisecond) 
-	job.Progress = 100
+	p.Set(100)
 	return map[string]any{"n": params["n"]}, nil
 }
 
@@ -129,4 +130,73 @@ func TestManager_Get_Cancel(t *testing.T) {
 	}
 
 	time.Sleep(100 * time.Millisecond) 
-}
\ No newline at end of file
+}
+
+// blockingTask corre hasta que su contexto se cancela
+func blockingTask(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func waitStatus(t *testing.T, m *Manager, jobID string, want JobStatus) *Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := m.GetStatus(jobID)
+		if err != nil {
+			t.Fatalf("GetStatus(%s) devolvió un error: %v", jobID, err)
+		}
+		if job.Status == want {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Job %s status = %s; se esperaba %s", jobID, job.Status, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestManager_CancelRunning prueba que Cancel llegue a la tarea por el contexto
+// y que un job cancelado en cola nunca se ejecute
+func TestManager_CancelRunning(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("block", blockingTask, 1, 2, 5*time.Second)
+	defer manager.Close()
+
+	running, _, _ := manager.Submit("block", url.Values{}, PrioNormal)
+	waitStatus(t, manager, running, StatusRunning)
+	queued, _, _ := manager.Submit("block", url.Values{}, PrioNormal)
+
+	if _, err := manager.Cancel(queued); err != nil {
+		t.Fatalf("Cancel(queued) devolvió un error: %v", err)
+	}
+	if _, err := manager.Cancel(running); err != nil {
+		t.Fatalf("Cancel(running) devolvió un error: %v", err)
+	}
+
+	job := waitStatus(t, manager, running, StatusCanceled)
+	if job.Error != ErrJobCanceled.Error() {
+		t.Errorf("Job cancelado error = %q; se esperaba %q", job.Error, ErrJobCanceled)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if job, _ := manager.GetStatus(queued); job.Status != StatusCanceled {
+		t.Errorf("Job cancelado en cola status = %s; se esperaba %s", job.Status, StatusCanceled)
+	}
+	if n := manager.QueueSizes()["block"]; n != 0 {
+		t.Errorf("Cola tras cancelar = %d; se esperaba 0", n)
+	}
+}
+
+// TestManager_Timeout prueba que un timeout termine en estado "timeout" y no "canceled"
+func TestManager_Timeout(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("block", blockingTask, 1, 1, 50*time.Millisecond)
+	defer manager.Close()
+
+	jobID, _, _ := manager.Submit("block", url.Values{}, PrioNormal)
+	job := waitStatus(t, manager, jobID, StatusTimeout)
+	if job.Error == "" {
+		t.Errorf("Job con timeout no tiene mensaje de error")
+	}
+}
@@ -0,0 +1,204 @@
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Node es el lado cliente del protocolo de remote.go: un proceso que se
+// conecta al Manager, anuncia sus tareas y ejecuta los jobs que le asigna.
+// Se le registran las mismas tareas que al Manager y conserva las que
+// anuncia; las opciones de Register no aplican (los límites del job llegan
+// con cada pedido y las tareas corren en goroutines del nodo).
+//
+//	node := jobs.NewNode("nodo-1", map[string]int{"pi": 4})
+//	registerTasks(node)
+//	node.Run(ctx, "manager:7070")
+type Node struct {
+	name  string
+	slots map[string]int
+	fns   map[string]TaskFunc
+
+	// RetryEvery es la espera entre intentos de conexión (1s por defecto).
+	RetryEvery time.Duration
+
+	// Token es el token compartido que pide el Manager (ver WithNodeToken).
+	Token string
+}
+
+func NewNode(name string, slots map[string]int) *Node {
+	return &Node{name: name, slots: slots, fns: make(map[string]TaskFunc), RetryEvery: time.Second}
+}
+
+// Register guarda fn si el nodo anuncia la tarea name.
+func (n *Node) Register(name string, fn TaskFunc, workers int, queueDepth int, timeout time.Duration, opts ...TaskOption) {
+	if _, ok := n.slots[name]; ok {
+		n.fns[name] = fn
+	}
+}
+
+// Run se conecta a addr y atiende jobs hasta que ctx se cancela. Si la
+// conexión se corta, los jobs en curso se cancelan (el Manager ya los
+// devolvió a la cola) y se reintenta la conexión. Un token rechazado
+// termina Run con ErrNodeUnauthorized.
+func (n *Node) Run(ctx context.Context, addr string) error {
+	if missing := n.missing(); len(missing) > 0 {
+		return fmt.Errorf("el nodo anuncia tareas que no tiene registradas: %v", missing)
+	}
+	for {
+		err := n.serve(ctx, addr)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrNodeUnauthorized) {
+			// reintentar con el mismo token no sirve
+			return err
+		}
+		fmt.Printf("[Node:%s] conexión con %s perdida: %v; reintento en %v\n", n.name, addr, err, n.RetryEvery)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(n.RetryEvery):
+		}
+	}
+}
+
+func (n *Node) missing() []string {
+	var out []string
+	for task := range n.slots {
+		if _, ok := n.fns[task]; !ok {
+			out = append(out, task)
+		}
+	}
+	return out
+}
+
+// nodeConn es una conexión del nodo con el Manager.
+type nodeConn struct {
+	n    *Node
+	conn net.Conn
+
+	wmu sync.Mutex
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+}
+
+// serve atiende una conexión hasta que se corta o ctx se cancela.
+func (n *Node) serve(ctx context.Context, addr string) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	c := &nodeConn{n: n, conn: conn, running: make(map[string]context.CancelFunc)}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 64*1024), 1<<30)
+	if err := c.send(nodeMsg{procMsg: procMsg{Type: "hello"}, Node: n.name, PID: os.Getpid(), Tasks: n.slots, Token: n.Token}); err != nil {
+		return err
+	}
+	if !sc.Scan() {
+		return fmt.Errorf("sin respuesta al saludo: %v", sc.Err())
+	}
+	var welcome nodeMsg
+	if err := json.Unmarshal(sc.Bytes(), &welcome); err != nil {
+		return err
+	}
+	if welcome.Type != "welcome" {
+		if welcome.Error == ErrNodeUnauthorized.Error() {
+			return ErrNodeUnauthorized
+		}
+		return fmt.Errorf("rechazado por el Manager: %s", welcome.Error)
+	}
+	lease := time.Duration(welcome.LeaseMs) * time.Millisecond
+	fmt.Printf("[Node:%s] conectado a %s (lease %v): %v\n", n.name, addr, lease, n.slots)
+
+	for task, slots := range n.slots {
+		for i := 0; i < slots; i++ {
+			c.send(nodeMsg{procMsg: procMsg{Type: "lease"}, Task: task})
+		}
+	}
+
+	for sc.Scan() {
+		var msg nodeMsg
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			fmt.Printf("[Node:%s] mensaje inválido: %v\n", n.name, err)
+			continue
+		}
+		switch msg.Type {
+		case "job":
+			if msg.Request != nil {
+				c.start(ctx, msg.Task, *msg.Request)
+			}
+		case "cancel":
+			c.mu.Lock()
+			if cancel, ok := c.running[msg.JobID]; ok {
+				cancel()
+			}
+			c.mu.Unlock()
+		}
+	}
+	err = sc.Err()
+	if err == nil {
+		err = fmt.Errorf("conexión cerrada por el Manager")
+	}
+
+	// sin conexión, los jobs en curso ya no pueden informar su resultado
+	c.mu.Lock()
+	for _, cancel := range c.running {
+		cancel()
+	}
+	c.mu.Unlock()
+	return err
+}
+
+func (c *nodeConn) send(msg nodeMsg) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err = c.conn.Write(append(data, '\n'))
+	return err
+}
+
+// start ejecuta un job asignado. Mientras corre, runRequest manda los
+// heartbeats del lease; al terminar envía el resultado y pide otro job.
+func (c *nodeConn) start(parent context.Context, task string, req procRequest) {
+	fn := c.n.fns[task]
+	ctx, cancel := context.WithCancel(parent)
+	c.mu.Lock()
+	c.running[req.JobID] = cancel
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.running, req.JobID)
+			c.mu.Unlock()
+			cancel()
+		}()
+
+		fmt.Printf("[Node:%s] ejecutando job %s (%s)\n", c.n.name, req.JobID, task)
+		relay := func(msg procMsg) { c.send(nodeMsg{procMsg: msg, JobID: req.JobID}) }
+		msg := runRequest(ctx, fn, task, req, relay)
+		if parent.Err() != nil {
+			return
+		}
+		// si el Manager lo canceló o venció el lease, descarta el resultado;
+		// el slot queda libre igual
+		c.send(nodeMsg{procMsg: msg, JobID: req.JobID})
+		c.send(nodeMsg{procMsg: procMsg{Type: "lease"}, Task: task})
+	}()
+}
@@ -0,0 +1,64 @@
+package jobs
+
+// TaskOption configura aspectos opcionales de una tarea al registrarla:
+//
+//	m.Register("sortfile", fn, 1, 2, 2*time.Minute, jobs.WithRetry(policy))
+type TaskOption func(*taskConf)
+
+// WithRetry asigna la política de reintentos de la tarea.
+func WithRetry(p RetryPolicy) TaskOption {
+	return func(tc *taskConf) { tc.retry = p }
+}
+
+// WithCache indica si los resultados de la tarea se guardan en la caché
+// del Manager (ver cache.go). Solo para tareas deterministas: mismo
+// resultado para los mismos parámetros.
+func WithCache(enabled bool) TaskOption {
+	return func(tc *taskConf) { tc.cache = enabled }
+}
+
+// WithCoalescing indica si los envíos idénticos de la tarea comparten la
+// ejecución en curso (ver coalesce.go), tanto en jobs como en la ruta
+// síncrona. Como WithCache, solo tiene sentido en tareas deterministas.
+func WithCoalescing(enabled bool) TaskOption {
+	return func(tc *taskConf) { tc.coalesce = enabled }
+}
+
+// WithRecovery fija qué hacer con los jobs de la tarea que quedaron en
+// ejecución tras una caída (por defecto RecoverRetry, ver recovery.go).
+func WithRecovery(p RecoveryPolicy) TaskOption {
+	return func(tc *taskConf) { tc.recovery = p }
+}
+
+// WithAutoscale hace que el pool de la tarea ajuste sus workers entre
+// p.Min y p.Max según la cola y el uso de CPU (ver autoscale.go). Los
+// workers de Register son el tamaño inicial.
+func WithAutoscale(p ScalePolicy) TaskOption {
+	return func(tc *taskConf) { tc.autoscale = &p }
+}
+
+// WithClass declara la clase de la tarea y su peso. Los jobs de clase cpu
+// comparten el presupuesto global de CPU del Manager, que se reparte entre
+// las tareas en proporción a su peso (ver budget.go).
+func WithClass(class TaskClass, weight int) TaskOption {
+	return func(tc *taskConf) { tc.class, tc.weight = class, weight }
+}
+
+// WithLimits fija los límites de recursos de los jobs de la tarea. Cada
+// envío puede bajarlos con max_memory, max_disk y max_output (ver limits.go).
+func WithLimits(l ResourceLimits) TaskOption {
+	return func(tc *taskConf) { tc.limits = l }
+}
+
+// WithEstimator asigna la estimación de recursos con la que se rechaza un
+// job antes de ejecutarlo, al enviarlo y al sacarlo de la cola.
+func WithEstimator(fn func(params map[string]string) ResourceUsage) TaskOption {
+	return func(tc *taskConf) { tc.estimate = fn }
+}
+
+// WithIsolation ejecuta los jobs de la tarea en procesos hijos con los
+// rlimits de l, en lugar de goroutines del servidor (ver process.go). El
+// binario debe atender WorkerTask con un WorkerHost al arrancar.
+func WithIsolation(l ProcessLimits) TaskOption {
+	return func(tc *taskConf) { tc.isolation = &l }
+}
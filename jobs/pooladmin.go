@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Administración de pools en caliente (rutas /pools del listener de
+// administración): cambiar workers y capacidad de la cola sin reiniciar,
+// pausar y reanudar la ejecución, drenar y deshabilitar una tarea durante
+// un mantenimiento. Los cambios no se persisten: al reiniciar rige lo
+// registrado con Register.
+
+var (
+	ErrTaskDisabled    = errors.New("tarea deshabilitada por mantenimiento")
+	ErrInvalidPoolSize = errors.New("tamaño de pool inválido")
+	ErrDrainTimeout    = errors.New("el pool no terminó de drenar a tiempo")
+)
+
+// PoolState es el estado administrable de un pool.
+type PoolState struct {
+	Task          string `json:"task"`
+	Workers       int    `json:"workers"`
+	Active        int64  `json:"active"`
+	Queued        int    `json:"queued"`
+	QueueCapacity int    `json:"queue_capacity"` // por clase de prioridad
+	Paused        bool   `json:"paused"`
+	Disabled      bool   `json:"disabled"`
+}
+
+// drainPoll es cada cuánto DrainPool revisa si el pool terminó.
+const drainPoll = 20 * time.Millisecond
+
+// taskFor busca la configuración de una tarea registrada.
+func (m *Manager) taskFor(task string) (*taskConf, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tc, ok := m.tasks[task]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return tc, nil
+}
+
+func (m *Manager) poolState(task string, tc *taskConf) PoolState {
+	m.mu.RLock()
+	disabled := tc.disabled
+	m.mu.RUnlock()
+	p := tc.pool
+	return PoolState{
+		Task:          task,
+		Workers:       p.Size(),
+		Active:        atomic.LoadInt64(&p.Active),
+		Queued:        p.Queue.Len(),
+		QueueCapacity: p.Queue.ClassCap(),
+		Paused:        p.Queue.Paused(),
+		Disabled:      disabled,
+	}
+}
+
+// PoolStates devuelve el estado de cada pool, por nombre de tarea.
+func (m *Manager) PoolStates() map[string]PoolState {
+	m.mu.RLock()
+	tasks := make(map[string]*taskConf, len(m.tasks))
+	for name, tc := range m.tasks {
+		tasks[name] = tc
+	}
+	m.mu.RUnlock()
+
+	out := make(map[string]PoolState, len(tasks))
+	for name, tc := range tasks {
+		out[name] = m.poolState(name, tc)
+	}
+	return out
+}
+
+// acceptsTask rechaza los envíos a una tarea deshabilitada. Una tarea no
+// registrada pasa: ese error lo da el camino de siempre.
+func (m *Manager) acceptsTask(task string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if tc, ok := m.tasks[task]; ok && tc.disabled {
+		return fmt.Errorf("%w: %s", ErrTaskDisabled, task)
+	}
+	return nil
+}
+
+// ResizePool cambia la cantidad de workers y/o la capacidad por clase de
+// la cola (0 = sin cambio). Los jobs en cola se conservan aunque excedan
+// la nueva capacidad. Un pool con autoescalado solo admite un tamaño
+// dentro de su [Min, Max]; el autoescalado sigue actuando desde ahí.
+func (m *Manager) ResizePool(task string, workers, queueCap int) (PoolState, error) {
+	if workers < 0 || queueCap < 0 {
+		return PoolState{}, ErrInvalidPoolSize
+	}
+	tc, err := m.taskFor(task)
+	if err != nil {
+		return PoolState{}, err
+	}
+	pool := tc.pool
+	if workers > 0 {
+		if s := pool.scaler; s != nil && (workers < s.policy.Min || workers > s.policy.Max) {
+			return PoolState{}, fmt.Errorf("%w: %d fuera del rango de autoescalado [%d, %d]",
+				ErrInvalidPoolSize, workers, s.policy.Min, s.policy.Max)
+		}
+		from := pool.Size()
+		pool.resize(workers)
+		fmt.Printf("[WorkerPool:%s] workers %d -> %d (admin)\n", task, from, workers)
+	}
+	if queueCap > 0 {
+		from := pool.Queue.ClassCap()
+		pool.Queue.SetCapacity(queueCap)
+		fmt.Printf("[WorkerPool:%s] capacidad de cola %d -> %d por clase (admin)\n", task, from, queueCap)
+	}
+	return m.poolState(task, tc), nil
+}
+
+// PausePool deja de ejecutar jobs de la tarea: los envíos se siguen
+// aceptando y encolando, y los jobs en ejecución terminan normalmente.
+func (m *Manager) PausePool(task string) (PoolState, error) {
+	tc, err := m.taskFor(task)
+	if err != nil {
+		return PoolState{}, err
+	}
+	tc.pool.Queue.Pause()
+	fmt.Printf("[WorkerPool:%s] pausado\n", task)
+	return m.poolState(task, tc), nil
+}
+
+// ResumePool reanuda la ejecución de un pool pausado.
+func (m *Manager) ResumePool(task string) (PoolState, error) {
+	tc, err := m.taskFor(task)
+	if err != nil {
+		return PoolState{}, err
+	}
+	tc.pool.Queue.Resume()
+	fmt.Printf("[WorkerPool:%s] reanudado\n", task)
+	return m.poolState(task, tc), nil
+}
+
+// SetTaskEnabled habilita o deshabilita los envíos a una tarea. Deshabilitada,
+// Submit, los lotes y los schedules devuelven ErrTaskDisabled y los pasos
+// de workflow esperan; lo ya encolado se sigue ejecutando.
+func (m *Manager) SetTaskEnabled(task string, enabled bool) (PoolState, error) {
+	m.mu.Lock()
+	tc, ok := m.tasks[task]
+	if ok {
+		tc.disabled = !enabled
+	}
+	m.mu.Unlock()
+	if !ok {
+		return PoolState{}, ErrTaskNotFound
+	}
+	if enabled {
+		fmt.Printf("[Manager] tarea %s habilitada\n", task)
+	} else {
+		fmt.Printf("[Manager] tarea %s deshabilitada\n", task)
+	}
+	return m.poolState(task, tc), nil
+}
+
+// DrainPool deshabilita la tarea, reanuda el pool si estaba pausado y
+// espera hasta timeout a que no queden jobs en cola ni en ejecución. La
+// tarea queda deshabilitada hasta SetTaskEnabled. Los jobs diferidos y los
+// reintentos pendientes no cuentan: entran a la cola al vencer.
+func (m *Manager) DrainPool(task string, timeout time.Duration) (PoolState, error) {
+	tc, err := m.taskFor(task)
+	if err != nil {
+		return PoolState{}, err
+	}
+	m.SetTaskEnabled(task, false)
+	tc.pool.Queue.Resume()
+	fmt.Printf("[WorkerPool:%s] drenando (%d en cola)\n", task, tc.pool.Queue.Len())
+
+	deadline := time.Now().Add(timeout)
+	for m.pendingFor(task) > 0 {
+		if time.Now().After(deadline) {
+			return m.poolState(task, tc), ErrDrainTimeout
+		}
+		time.Sleep(drainPoll)
+	}
+	fmt.Printf("[WorkerPool:%s] drenado\n", task)
+	return m.poolState(task, tc), nil
+}
+
+// pendingFor cuenta los jobs de la tarea que esperan o se ejecutan.
+func (m *Manager) pendingFor(task string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n := 0
+	for _, j := range m.jobs {
+		if j.Task == task && (j.Status == StatusQueued || j.Status == StatusRunning) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestManager_PausePool prueba que un pool pausado acepte jobs sin ejecutarlos hasta reanudarlo
+func TestManager_PausePool(t *testing.T) {
+	m := NewManager("", time.Minute, time.Minute)
+	m.Register("quick", quickTask, 2, 10, time.Second)
+	defer m.Close()
+
+	if st, err := m.PausePool("quick"); err != nil || !st.Paused {
+		t.Fatalf("PausePool = %+v, %v", st, err)
+	}
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, _, err := m.Submit("quick", nil, PrioNormal)
+		if err != nil {
+			t.Fatalf("Submit con el pool pausado: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if st := m.PoolStates()["quick"]; st.Queued != 3 || st.Active != 0 {
+		t.Fatalf("pool pausado = %+v; se esperaban 3 en cola y ninguno activo", st)
+	}
+
+	m.ResumePool("quick")
+	for _, id := range ids {
+		waitStatus(t, m, id, StatusDone)
+	}
+}
+
+// TestManager_ResizePool prueba el cambio de workers y de capacidad de la cola en caliente
+func TestManager_ResizePool(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	m := NewManager("", time.Minute, time.Minute)
+	m.Register("gated", gatedTask(&runs, release), 1, 2, 5*time.Second)
+	defer m.Close()
+
+	// un job ejecutándose y dos en cola: la clase normal está llena
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, _, err := m.Submit("gated", nil, PrioNormal)
+		if err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+		ids = append(ids, id)
+		waitUntil(t, func() bool { return runs.Load() == 1 })
+	}
+	if _, _, err := m.Submit("gated", nil, PrioNormal); !errors.Is(err, ErrBackpressure) {
+		t.Fatalf("cola llena: err = %v; se esperaba ErrBackpressure", err)
+	}
+
+	st, err := m.ResizePool("gated", 3, 5)
+	if err != nil || st.Workers != 3 || st.QueueCapacity != 5 {
+		t.Fatalf("ResizePool = %+v, %v", st, err)
+	}
+	waitUntil(t, func() bool { return runs.Load() == 3 })
+	if _, _, err := m.Submit("gated", nil, PrioNormal); err != nil {
+		t.Errorf("Submit tras agrandar la cola: %v", err)
+	}
+
+	close(release)
+	for _, id := range ids {
+		waitStatus(t, m, id, StatusDone)
+	}
+	if st, _ := m.ResizePool("gated", 1, 0); st.Workers != 1 || st.QueueCapacity != 5 {
+		t.Errorf("achicar = %+v; se esperaba 1 worker y la capacidad sin cambios", st)
+	}
+	if _, err := m.ResizePool("nada", 1, 0); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("tarea inexistente: err = %v", err)
+	}
+}
+
+// TestManager_DrainPool prueba que drenar ejecute lo encolado y deje la tarea deshabilitada
+func TestManager_DrainPool(t *testing.T) {
+	m := NewManager("", time.Minute, time.Minute)
+	m.Register("quick", quickTask, 1, 10, time.Second)
+	defer m.Close()
+
+	m.PausePool("quick")
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, _, _ := m.Submit("quick", nil, PrioNormal)
+		ids = append(ids, id)
+	}
+
+	st, err := m.DrainPool("quick", 2*time.Second)
+	if err != nil || st.Queued != 0 || st.Paused || !st.Disabled {
+		t.Fatalf("DrainPool = %+v, %v", st, err)
+	}
+	for _, id := range ids {
+		waitStatus(t, m, id, StatusDone)
+	}
+	if _, _, err := m.Submit("quick", nil, PrioNormal); !errors.Is(err, ErrTaskDisabled) {
+		t.Errorf("Submit tras drenar: err = %v; se esperaba ErrTaskDisabled", err)
+	}
+	if res, _ := m.SubmitBatch(BatchSpec{Jobs: []BatchItem{{Task: "quick"}}}); res.Accepted != 0 {
+		t.Errorf("lote a una tarea deshabilitada: %d aceptados", res.Accepted)
+	}
+
+	m.SetTaskEnabled("quick", true)
+	id, _, err := m.Submit("quick", nil, PrioNormal)
+	if err != nil {
+		t.Fatalf("Submit tras habilitar: %v", err)
+	}
+	waitStatus(t, m, id, StatusDone)
+}
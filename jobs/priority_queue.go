@@ -0,0 +1,389 @@
+package jobs
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrQueueClosed = errors.New("cola cerrada")
+
+// Tiempo de espera que equivale a subir una clase de prioridad.
+// Un job "low" que lleva 2*defaultAging en cola compite con un "high" recién llegado.
+const defaultAging = 10 * time.Second
+
+// String devuelve el nombre usado en la API (?prio=high|normal|low).
+func (p JobPriority) String() string {
+	switch p {
+	case PrioHigh:
+		return "high"
+	case PrioLow:
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+// ParsePriority interpreta el nombre usado en la API; cualquier otro valor
+// (incluido el vacío) es normal.
+func ParsePriority(s string) JobPriority {
+	switch s {
+	case "high":
+		return PrioHigh
+	case "low":
+		return PrioLow
+	default:
+		return PrioNormal
+	}
+}
+
+// Orden en el que se listan y recorren las clases (de mayor a menor).
+var priorityClasses = []JobPriority{PrioHigh, PrioNormal, PrioLow}
+
+type queueItem struct {
+	job        *Job
+	enqueuedAt time.Time
+}
+
+// PriorityQueue reemplaza al antiguo `chan *Job` de cada WorkerPool.
+// Mantiene una cola FIFO por clase de prioridad, cada una con su propia
+// capacidad (y por lo tanto su propio backpressure). Los workers sacan
+// primero de la clase más alta; el aging evita que los jobs de baja
+// prioridad esperen indefinidamente.
+type PriorityQueue struct {
+	mu       sync.Mutex
+	classes  map[JobPriority][]queueItem
+	capacity int // por clase
+	aging    time.Duration
+	closed   bool
+	paused   bool // Pop no entrega jobs hasta Resume (ver pooladmin.go)
+
+	// Workers bloqueados en Pop. Solo hay waiters con la cola vacía, y
+	// Push les entrega el job directamente (igual que un canal sin buffer
+	// con un receptor esperando): el job no ocupa lugar en la cola.
+	waiters []chan *Job
+}
+
+// NewPriorityQueue crea una cola con `capacity` lugares por clase.
+// aging <= 0 deshabilita el envejecimiento.
+func NewPriorityQueue(capacity int, aging time.Duration) *PriorityQueue {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &PriorityQueue{
+		classes:  make(map[JobPriority][]queueItem, len(priorityClasses)),
+		capacity: capacity,
+		aging:    aging,
+	}
+}
+
+// normalize lleva prioridades fuera de rango a la clase más cercana.
+func normalize(p JobPriority) JobPriority {
+	if p > PrioHigh {
+		return PrioHigh
+	}
+	if p < PrioLow {
+		return PrioLow
+	}
+	return p
+}
+
+// Push encola sin bloquear. Devuelve ErrBackpressure si la clase del job está llena.
+func (q *PriorityQueue) Push(j *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pushLocked(j)
+}
+
+// fitsLocked indica si todos los jobs entrarían ahora en la cola: los
+// primeros se entregan a los workers en espera y el resto debe caber en
+// la capacidad de su clase. Requiere q.mu tomado.
+func (q *PriorityQueue) fitsLocked(jobs []*Job) error {
+	if q.closed {
+		return ErrQueueClosed
+	}
+	free := len(q.waiters)
+	if q.paused {
+		free = 0
+	}
+	need := map[JobPriority]int{}
+	for _, j := range jobs {
+		if free > 0 {
+			free--
+			continue
+		}
+		prio := normalize(j.Priority)
+		need[prio]++
+		if len(q.classes[prio])+need[prio] > q.capacity {
+			return ErrBackpressure
+		}
+	}
+	return nil
+}
+
+// pushAll encola jobs en una o más colas de forma atómica: entran todos o
+// ninguno. Las colas se bloquean en el orden recibido, que el llamador debe
+// mantener estable para no cruzarse con otro pushAll.
+func pushAll(order []*PriorityQueue, byQueue map[*PriorityQueue][]*Job) error {
+	for _, q := range order {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+	}
+	for _, q := range order {
+		if err := q.fitsLocked(byQueue[q]); err != nil {
+			return err
+		}
+	}
+	for _, q := range order {
+		for _, j := range byQueue[q] {
+			q.pushLocked(j)
+		}
+	}
+	return nil
+}
+
+// pushLocked es Push con q.mu ya tomado.
+func (q *PriorityQueue) pushLocked(j *Job) error {
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if len(q.waiters) > 0 && !q.paused {
+		w := q.waiters[0]
+		q.waiters = q.waiters[1:]
+		w <- j // buffer de 1: no bloquea
+		return nil
+	}
+	prio := normalize(j.Priority)
+	if len(q.classes[prio]) >= q.capacity {
+		return ErrBackpressure
+	}
+	q.classes[prio] = append(q.classes[prio], queueItem{job: j, enqueuedAt: time.Now()})
+	return nil
+}
+
+// Pop bloquea hasta que haya un job disponible, se cierre la cola o se
+// cierre stop. El bool es false en los dos últimos casos.
+func (q *PriorityQueue) Pop(stop <-chan struct{}) (*Job, bool) {
+	q.mu.Lock()
+	// pausada, el worker espera como con la cola vacía: Resume le entrega un job
+	if !q.paused {
+		if j := q.popLocked(time.Now()); j != nil {
+			q.mu.Unlock()
+			return j, true
+		}
+	}
+	if q.closed {
+		q.mu.Unlock()
+		return nil, false
+	}
+	w := make(chan *Job, 1)
+	q.waiters = append(q.waiters, w)
+	q.mu.Unlock()
+
+	select {
+	case j, ok := <-w:
+		return j, ok
+	case <-stop:
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for i, other := range q.waiters {
+			if other == w {
+				q.waiters = append(q.waiters[:i:i], q.waiters[i+1:]...)
+				return nil, false
+			}
+		}
+		// Push ya entregó un job a este waiter: se devuelve a la cola
+		if j, ok := <-w; ok && j != nil {
+			prio := normalize(j.Priority)
+			q.classes[prio] = append([]queueItem{{job: j, enqueuedAt: time.Now()}}, q.classes[prio]...)
+		}
+		return nil, false
+	}
+}
+
+// TryPop saca un job si hay alguno disponible, sin bloquear.
+func (q *PriorityQueue) TryPop() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.paused {
+		return nil
+	}
+	return q.popLocked(time.Now())
+}
+
+// popLocked elige la cabeza de clase con mayor prioridad efectiva
+// (clase + tiempo esperado / aging). En empate gana la clase más alta.
+func (q *PriorityQueue) popLocked(now time.Time) *Job {
+	best := JobPriority(-1)
+	bestScore := -1.0
+	for _, prio := range priorityClasses {
+		items := q.classes[prio]
+		if len(items) == 0 {
+			continue
+		}
+		score := float64(prio)
+		if q.aging > 0 {
+			score += float64(now.Sub(items[0].enqueuedAt)) / float64(q.aging)
+		}
+		if score > bestScore {
+			best, bestScore = prio, score
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	item := q.classes[best][0]
+	q.classes[best][0] = queueItem{}
+	q.classes[best] = q.classes[best][1:]
+	return item.job
+}
+
+// Remove quita de la cola el job con ese id (por ejemplo, al cancelarlo).
+func (q *PriorityQueue) Remove(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for prio, items := range q.classes {
+		for i, it := range items {
+			if it.job.ID == id {
+				q.classes[prio] = append(items[:i:i], items[i+1:]...)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Len es la cantidad total de jobs en cola (todas las clases).
+func (q *PriorityQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for _, items := range q.classes {
+		n += len(items)
+	}
+	return n
+}
+
+// OldestWait es cuánto lleva esperando el job más antiguo en cola (0 si está vacía).
+func (q *PriorityQueue) OldestWait() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var oldest time.Time
+	for _, items := range q.classes {
+		if len(items) > 0 && (oldest.IsZero() || items[0].enqueuedAt.Before(oldest)) {
+			oldest = items[0].enqueuedAt
+		}
+	}
+	if oldest.IsZero() {
+		return 0
+	}
+	return time.Since(oldest)
+}
+
+// Cap es la capacidad total (capacidad por clase * número de clases).
+func (q *PriorityQueue) Cap() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.capacity * len(priorityClasses)
+}
+
+// ClassCap es la capacidad de cada clase de prioridad.
+func (q *PriorityQueue) ClassCap() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.capacity
+}
+
+// Full indica si la clase dada no admite más jobs.
+func (q *PriorityQueue) Full(prio JobPriority) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.classes[normalize(prio)]) >= q.capacity
+}
+
+// Depths devuelve la profundidad de cada clase ("high", "normal", "low").
+func (q *PriorityQueue) Depths() map[string]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make(map[string]int, len(priorityClasses))
+	for _, prio := range priorityClasses {
+		out[prio.String()] = len(q.classes[prio])
+	}
+	return out
+}
+
+// Snapshot devuelve los ids en cola, de la clase más alta a la más baja.
+func (q *PriorityQueue) Snapshot() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var ids []string
+	for _, prio := range priorityClasses {
+		for _, it := range q.classes[prio] {
+			ids = append(ids, it.job.ID)
+		}
+	}
+	return ids
+}
+
+// Pause deja de entregar jobs: Push sigue encolando (con su capacidad) y
+// los workers quedan bloqueados en Pop hasta Resume.
+func (q *PriorityQueue) Pause() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = true
+}
+
+// Resume reanuda la entrega y reparte lo encolado entre los workers en espera.
+func (q *PriorityQueue) Resume() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = false
+	now := time.Now()
+	for len(q.waiters) > 0 {
+		j := q.popLocked(now)
+		if j == nil {
+			return
+		}
+		w := q.waiters[0]
+		q.waiters = q.waiters[1:]
+		w <- j
+	}
+}
+
+// Paused indica si la cola está pausada.
+func (q *PriorityQueue) Paused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused
+}
+
+// SetCapacity cambia la capacidad por clase. Los jobs en cola pasan a
+// arreglos nuevos en el mismo orden y con su antigüedad; si exceden la
+// nueva capacidad se conservan igual, y la clase no admite más hasta
+// bajar del límite.
+func (q *PriorityQueue) SetCapacity(capacity int) {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.capacity = capacity
+	for prio, items := range q.classes {
+		q.classes[prio] = append(make([]queueItem, 0, capacity), items...)
+	}
+}
+
+// Close despierta a todos los workers bloqueados en Pop; los jobs que
+// quedan en cola siguen disponibles para TryPop/Pop hasta vaciarse.
+func (q *PriorityQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	for _, w := range q.waiters {
+		close(w)
+	}
+	q.waiters = nil
+}
@@ -0,0 +1,127 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func pqJob(id string, prio JobPriority) *Job {
+	return &Job{ID: id, Priority: prio}
+}
+
+// TestPriorityQueue_Order prueba que high salga antes que normal y low
+func TestPriorityQueue_Order(t *testing.T) {
+	q := NewPriorityQueue(4, 0)
+	q.Push(pqJob("low", PrioLow))
+	q.Push(pqJob("normal-1", PrioNormal))
+	q.Push(pqJob("high", PrioHigh))
+	q.Push(pqJob("normal-2", PrioNormal))
+
+	expect := []string{"high", "normal-1", "normal-2", "low"}
+	for _, id := range expect {
+		j := q.TryPop()
+		if j == nil || j.ID != id {
+			t.Fatalf("TryPop = %v; se esperaba %s", j, id)
+		}
+	}
+	if j := q.TryPop(); j != nil {
+		t.Errorf("TryPop en cola vacía = %s; se esperaba nil", j.ID)
+	}
+}
+
+// TestPriorityQueue_Aging prueba que un job low que esperó lo suficiente no quede postergado
+func TestPriorityQueue_Aging(t *testing.T) {
+	q := NewPriorityQueue(4, 10*time.Millisecond)
+	q.Push(pqJob("low", PrioLow))
+	time.Sleep(30 * time.Millisecond) // low acumula más de 2 clases de aging
+	q.Push(pqJob("high", PrioHigh))
+
+	if j := q.TryPop(); j == nil || j.ID != "low" {
+		t.Errorf("TryPop = %v; se esperaba el job low envejecido", j)
+	}
+}
+
+// TestPriorityQueue_Backpressure prueba que la capacidad sea por clase
+func TestPriorityQueue_Backpressure(t *testing.T) {
+	q := NewPriorityQueue(1, 0)
+	if err := q.Push(pqJob("n1", PrioNormal)); err != nil {
+		t.Fatalf("Push(n1) falló: %v", err)
+	}
+	if err := q.Push(pqJob("n2", PrioNormal)); err != ErrBackpressure {
+		t.Errorf("Push(n2) err = %v; se esperaba %v", err, ErrBackpressure)
+	}
+	if err := q.Push(pqJob("h1", PrioHigh)); err != nil {
+		t.Errorf("Push(h1) con la clase high vacía falló: %v", err)
+	}
+	depths := q.Depths()
+	if depths["normal"] != 1 || depths["high"] != 1 || depths["low"] != 0 {
+		t.Errorf("Depths = %v; se esperaba normal=1 high=1 low=0", depths)
+	}
+}
+
+// TestPriorityQueue_PopHandoff prueba que un worker esperando reciba el job directamente
+func TestPriorityQueue_PopHandoff(t *testing.T) {
+	q := NewPriorityQueue(1, 0)
+	stop := make(chan struct{})
+	got := make(chan *Job, 1)
+	go func() {
+		j, _ := q.Pop(stop)
+		got <- j
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	q.Push(pqJob("a", PrioNormal))
+	// el job se entregó al worker, así que la cola sigue teniendo lugar
+	if err := q.Push(pqJob("b", PrioNormal)); err != nil {
+		t.Errorf("Push(b) err = %v; se esperaba nil", err)
+	}
+	if j := <-got; j == nil || j.ID != "a" {
+		t.Errorf("Pop = %v; se esperaba a", j)
+	}
+
+	close(stop)
+	if _, ok := q.Pop(stop); !ok {
+		t.Errorf("Pop con stop cerrado y cola no vacía debería devolver el job pendiente")
+	}
+	q.Close()
+	if _, ok := q.Pop(make(chan struct{})); ok {
+		t.Errorf("Pop en cola cerrada y vacía devolvió ok")
+	}
+}
+
+// TestPriorityQueue_PauseAndCapacity prueba que una cola pausada retenga los jobs y que SetCapacity los conserve
+func TestPriorityQueue_PauseAndCapacity(t *testing.T) {
+	q := NewPriorityQueue(4, 0)
+	got := make(chan *Job, 1)
+	go func() {
+		j, _ := q.Pop(nil)
+		got <- j
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	q.Pause()
+	q.Push(pqJob("a", PrioNormal))
+	q.Push(pqJob("b", PrioNormal))
+	select {
+	case j := <-got:
+		t.Fatalf("Pop entregó %s con la cola pausada", j.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if q.TryPop() != nil || q.Len() != 2 {
+		t.Fatalf("cola pausada: Len = %d; se esperaban 2 jobs retenidos", q.Len())
+	}
+
+	// achicar por debajo de lo encolado no pierde jobs pero frena los envíos
+	q.SetCapacity(1)
+	if q.Len() != 2 || q.Push(pqJob("c", PrioNormal)) != ErrBackpressure {
+		t.Errorf("SetCapacity(1): Len = %d; se esperaban 2 jobs y backpressure", q.Len())
+	}
+
+	q.Resume()
+	if j := <-got; j.ID != "a" {
+		t.Errorf("Resume entregó %s; se esperaba a", j.ID)
+	}
+	if j := q.TryPop(); j == nil || j.ID != "b" {
+		t.Errorf("TryPop tras Resume = %v; se esperaba b", j)
+	}
+}
@@ -0,0 +1,575 @@
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Aislamiento por proceso: los jobs de una tarea registrada con
+// WithIsolation no corren en goroutines del servidor sino en procesos
+// hijos, el mismo binario relanzado en modo worker (WorkerEnv indica la
+// tarea). El Manager les habla por dos pipes con un mensaje JSON por
+// línea: el hijo lee pedidos del fd 3 y escribe progreso, checkpoints y
+// el resultado en el fd 4. Stdout y stderr se heredan, así que los logs
+// del hijo salen junto a los del servidor.
+//
+// A diferencia de una goroutine, un proceso se puede matar: un timeout,
+// un cancel o el apagado lo terminan con SIGKILL y el próximo job lanza
+// otro. Un panic en cualquier goroutine de la tarea solo tira abajo al
+// hijo y el job falla con clase panic. Cada hijo corre con los rlimits de
+// ProcessLimits.
+
+// WorkerEnv es la variable de entorno con la que se lanza un proceso
+// worker; su valor es la tarea que atiende.
+const WorkerEnv = "P1_WORKER_TASK"
+
+// workerLimitsEnv lleva los ProcessLimits del hijo, en JSON.
+const workerLimitsEnv = "P1_WORKER_LIMITS"
+
+// Descriptores de los pipes en el hijo (ExtraFiles empieza en 3).
+const (
+	workerRequestFD = 3
+	workerReplyFD   = 4
+)
+
+var ErrWorkerExited = errors.New("el proceso worker terminó inesperadamente")
+
+// ProcessLimits son los rlimits de los procesos worker; 0 = heredado del
+// servidor. CPUSeconds rige por job: el hijo lo renueva antes de cada uno.
+type ProcessLimits struct {
+	CPUSeconds uint64 `json:"cpu_seconds,omitempty"` // RLIMIT_CPU
+	Memory     uint64 `json:"memory,omitempty"`      // RLIMIT_DATA, bytes
+	OpenFiles  uint64 `json:"open_files,omitempty"`  // RLIMIT_NOFILE
+}
+
+// WorkerInfo describe un worker de un pool para /status.
+type WorkerInfo struct {
+	PID    int    `json:"worker_pid"`
+	Status string `json:"status"` // busy | idle
+	JobID  string `json:"job_id,omitempty"`
+	Node   string `json:"node,omitempty"` // nodo remoto (ver remote.go)
+}
+
+// Registrar es lo que necesita el código que registra tareas: lo cumplen
+// el Manager y el WorkerHost de un proceso worker.
+type Registrar interface {
+	Register(name string, task TaskFunc, workers int, queueDepth int, timeout time.Duration, opts ...TaskOption)
+}
+
+// -----------------------------------------------------------------------------
+// Protocolo
+// -----------------------------------------------------------------------------
+
+// procRequest es el pedido del Manager al hijo: un job por vez.
+type procRequest struct {
+	JobID      string            `json:"job_id"`
+	Params     map[string]string `json:"params"`
+	Limits     ResourceLimits    `json:"limits"`
+	Checkpoint json.RawMessage   `json:"checkpoint,omitempty"`
+	LeaseMs    int64             `json:"lease_ms,omitempty"` // TTL del lease: heartbeats cada tercio
+}
+
+// procMsg es un mensaje del hijo: progress y checkpoint mientras corre el
+// job; result o error lo terminan.
+type procMsg struct {
+	Type     string          `json:"type"`
+	Fraction float64         `json:"fraction"`
+	Stage    string          `json:"stage,omitempty"`
+	SetStage bool            `json:"set_stage,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"` // checkpoint o resultado
+	Error    string          `json:"error,omitempty"`
+	Class    ErrorClass      `json:"class,omitempty"`
+	Limit    *LimitError     `json:"limit,omitempty"`
+	Exit     bool            `json:"exit,omitempty"` // el hijo termina tras este mensaje
+}
+
+// remoteError es un error de tarea devuelto por un hijo; conserva la clase
+// con la que lo clasificó el hijo para la política de reintentos.
+type remoteError struct {
+	msg   string
+	class ErrorClass
+}
+
+func (e *remoteError) Error() string { return e.msg }
+
+// err reconstruye en el Manager el error enviado por el hijo.
+func (msg procMsg) err() error {
+	switch {
+	case msg.Limit != nil:
+		return msg.Limit
+	case msg.Class == ClassPanic:
+		return &panicError{msg.Error}
+	}
+	return &remoteError{msg: msg.Error, class: msg.Class}
+}
+
+// apply aplica al Progress del job un mensaje de su ejecutor. done indica
+// que el mensaje cierra el job, con res o err.
+func (msg procMsg) apply(p *Progress) (done bool, res any, err error) {
+	switch msg.Type {
+	case "progress":
+		p.report(msg.Fraction, msg.Stage, msg.SetStage)
+	case "checkpoint":
+		p.Checkpoint(msg.Data)
+	case "heartbeat":
+		p.heartbeat()
+	case "error":
+		return true, nil, msg.err()
+	case "result":
+		if err := json.Unmarshal(msg.Data, &res); err != nil {
+			return true, nil, err
+		}
+		return true, res, nil
+	}
+	return false, nil, nil
+}
+
+// errorMsg arma el mensaje de error de un job en el hijo.
+func errorMsg(err error) procMsg {
+	msg := procMsg{Type: "error", Error: err.Error(), Class: classifyError(err)}
+	var le *LimitError
+	if errors.As(err, &le) {
+		msg.Limit = le
+	}
+	return msg
+}
+
+// -----------------------------------------------------------------------------
+// Lado del Manager: pool de procesos
+// -----------------------------------------------------------------------------
+
+// procPool lanza y reutiliza los procesos hijos de una tarea aislada.
+type procPool struct {
+	task   string
+	limits ProcessLimits
+	size   func() int // workers del pool: tope de hijos ociosos que se conservan
+
+	mu     sync.Mutex
+	idle   []*childProc
+	all    map[*childProc]struct{}
+	closed bool
+}
+
+// childProc es un proceso worker vivo.
+type childProc struct {
+	cmd  *exec.Cmd
+	req  *os.File // fd 3 del hijo, lado de escritura
+	msgs chan procMsg
+	done chan struct{} // se cierra cuando el proceso terminó
+	err  error         // resultado de Wait, válido tras done
+
+	jobID string // "" = ocioso; protegido por procPool.mu
+}
+
+func newProcPool(task string, limits ProcessLimits, size func() int) *procPool {
+	return &procPool{task: task, limits: limits, size: size, all: make(map[*childProc]struct{})}
+}
+
+// spawn lanza un hijo nuevo.
+func (pp *procPool) spawn() (*childProc, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	limits, err := json.Marshal(pp.limits)
+	if err != nil {
+		return nil, err
+	}
+	reqR, reqW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	repR, repW, err := os.Pipe()
+	if err != nil {
+		reqR.Close()
+		reqW.Close()
+		return nil, err
+	}
+
+	cmd := exec.Command(exe)
+	cmd.Env = append(os.Environ(), WorkerEnv+"="+pp.task, workerLimitsEnv+"="+string(limits))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{reqR, repW}
+	err = cmd.Start()
+	// los extremos del hijo ya están duplicados en él
+	reqR.Close()
+	repW.Close()
+	if err != nil {
+		reqW.Close()
+		repR.Close()
+		return nil, err
+	}
+
+	c := &childProc{cmd: cmd, req: reqW, msgs: make(chan procMsg, 16), done: make(chan struct{})}
+	go c.read(repR)
+	go func() {
+		c.err = cmd.Wait()
+		close(c.done)
+	}()
+	fmt.Printf("[Worker:%s] proceso %d iniciado\n", pp.task, cmd.Process.Pid)
+	return c, nil
+}
+
+// read decodifica los mensajes del hijo; msgs se cierra con el pipe.
+func (c *childProc) read(r *os.File) {
+	defer r.Close()
+	defer close(c.msgs)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<30)
+	for sc.Scan() {
+		var msg procMsg
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			fmt.Printf("[Worker] mensaje inválido del proceso %d: %v\n", c.cmd.Process.Pid, err)
+			continue
+		}
+		c.msgs <- msg
+	}
+}
+
+// kill termina al hijo y espera a que salga.
+func (c *childProc) kill() {
+	c.cmd.Process.Kill()
+	c.req.Close()
+	<-c.done
+}
+
+// prestart lanza n hijos ociosos para que el primer job no pague el arranque.
+func (pp *procPool) prestart(n int) {
+	for i := 0; i < n; i++ {
+		c, err := pp.spawn()
+		if err != nil {
+			fmt.Printf("[Worker:%s] no se pudo lanzar un proceso: %v\n", pp.task, err)
+			return
+		}
+		pp.mu.Lock()
+		pp.all[c] = struct{}{}
+		pp.idle = append(pp.idle, c)
+		pp.mu.Unlock()
+	}
+}
+
+// get toma un hijo ocioso vivo o lanza uno nuevo, y lo marca con jobID.
+func (pp *procPool) get(jobID string) (*childProc, error) {
+	pp.mu.Lock()
+	for len(pp.idle) > 0 {
+		c := pp.idle[len(pp.idle)-1]
+		pp.idle = pp.idle[:len(pp.idle)-1]
+		select {
+		case <-c.done:
+			delete(pp.all, c)
+			continue
+		default:
+		}
+		c.jobID = jobID
+		pp.mu.Unlock()
+		return c, nil
+	}
+	if pp.closed {
+		pp.mu.Unlock()
+		return nil, ErrShutdown
+	}
+	pp.mu.Unlock()
+
+	c, err := pp.spawn()
+	if err != nil {
+		return nil, err
+	}
+	pp.mu.Lock()
+	c.jobID = jobID
+	pp.all[c] = struct{}{}
+	pp.mu.Unlock()
+	return c, nil
+}
+
+// put devuelve un hijo a los ociosos, o lo termina si sobran o el pool
+// se cerró.
+func (pp *procPool) put(c *childProc) {
+	pp.mu.Lock()
+	c.jobID = ""
+	if !pp.closed && len(pp.idle) < pp.size() {
+		pp.idle = append(pp.idle, c)
+		pp.mu.Unlock()
+		return
+	}
+	delete(pp.all, c)
+	pp.mu.Unlock()
+	// cerrar el pipe de pedidos hace que el hijo salga solo
+	c.req.Close()
+}
+
+// discard mata a un hijo y lo quita del pool.
+func (pp *procPool) discard(c *childProc) {
+	c.kill()
+	pp.mu.Lock()
+	delete(pp.all, c)
+	pp.mu.Unlock()
+}
+
+// close termina a los hijos ociosos; los ocupados los mata el cancel del
+// apagado y ya no vuelven al pool.
+func (pp *procPool) close() {
+	pp.mu.Lock()
+	pp.closed = true
+	idle := pp.idle
+	pp.idle = nil
+	for _, c := range idle {
+		delete(pp.all, c)
+	}
+	pp.mu.Unlock()
+	for _, c := range idle {
+		c.req.Close()
+	}
+}
+
+// workers lista los hijos vivos, por pid.
+func (pp *procPool) workers() []WorkerInfo {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	out := make([]WorkerInfo, 0, len(pp.all))
+	for c := range pp.all {
+		w := WorkerInfo{PID: c.cmd.Process.Pid, Status: "idle"}
+		if c.jobID != "" {
+			w.Status, w.JobID = "busy", c.jobID
+		}
+		out = append(out, w)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PID < out[j].PID })
+	return out
+}
+
+// run es la TaskFunc de una tarea aislada: envía el job a un hijo y
+// retransmite sus mensajes al Progress del job hasta el resultado.
+func (pp *procPool) run(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+	c, err := pp.get(p.jobID)
+	if err != nil {
+		return nil, err
+	}
+	p.m.setLeaseOwner(p.jobID, fmt.Sprintf("proceso %d", c.cmd.Process.Pid))
+	req := procRequest{JobID: p.jobID, Params: params, Limits: p.limits, LeaseMs: p.m.leaseTTL.Milliseconds()}
+	p.Resume(&req.Checkpoint)
+	data, err := json.Marshal(req)
+	if err != nil {
+		pp.put(c)
+		return nil, err
+	}
+	if _, err := c.req.Write(append(data, '\n')); err != nil {
+		pp.discard(c)
+		return nil, fmt.Errorf("%w: %v", ErrWorkerExited, err)
+	}
+
+	for {
+		select {
+		case msg, ok := <-c.msgs:
+			if !ok {
+				pid := c.cmd.Process.Pid
+				pp.discard(c)
+				return nil, &panicError{fmt.Sprintf("%v (pid %d, tarea '%s'): %v", ErrWorkerExited, pid, pp.task, c.err)}
+			}
+			done, res, err := msg.apply(p)
+			if !done {
+				continue
+			}
+			if msg.Exit {
+				<-c.done
+				pp.mu.Lock()
+				delete(pp.all, c)
+				pp.mu.Unlock()
+			} else {
+				pp.put(c)
+			}
+			return res, err
+
+		case <-ctx.Done():
+			fmt.Printf("[Worker:%s] matando proceso %d (job %s: %v)\n", pp.task, c.cmd.Process.Pid, p.jobID, context.Cause(ctx))
+			pp.discard(c)
+			return nil, context.Cause(ctx)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Lado del hijo: WorkerHost
+// -----------------------------------------------------------------------------
+
+// WorkerTask devuelve la tarea que atiende este proceso si fue lanzado
+// como worker, o "" en el servidor.
+func WorkerTask() string { return os.Getenv(WorkerEnv) }
+
+// WorkerHost ejecuta en un proceso worker los jobs de una sola tarea. Se
+// le registran las mismas tareas que al Manager y conserva la que le toca:
+//
+//	if task := jobs.WorkerTask(); task != "" {
+//		host := jobs.NewWorkerHost(task)
+//		registerTasks(host)
+//		os.Exit(host.Serve())
+//	}
+type WorkerHost struct {
+	task string
+	fn   TaskFunc
+
+	mu  sync.Mutex // serializa las escrituras al pipe de respuestas
+	out io.Writer
+}
+
+func NewWorkerHost(task string) *WorkerHost {
+	return &WorkerHost{task: task}
+}
+
+// Register guarda fn si name es la tarea del proceso; el resto de los
+// argumentos los usa el Manager del servidor.
+func (h *WorkerHost) Register(name string, fn TaskFunc, workers int, queueDepth int, timeout time.Duration, opts ...TaskOption) {
+	if name == h.task {
+		h.fn = fn
+	}
+}
+
+// Serve aplica los rlimits y atiende pedidos hasta que el servidor cierra
+// el pipe. Devuelve el código de salida del proceso.
+func (h *WorkerHost) Serve() int {
+	if h.fn == nil {
+		fmt.Fprintf(os.Stderr, "[Worker:%s] tarea no registrada\n", h.task)
+		return 2
+	}
+	var limits ProcessLimits
+	if env := os.Getenv(workerLimitsEnv); env != "" {
+		if err := json.Unmarshal([]byte(env), &limits); err != nil {
+			fmt.Fprintf(os.Stderr, "[Worker:%s] límites inválidos: %v\n", h.task, err)
+			return 2
+		}
+	}
+	if err := applyRlimits(limits); err != nil {
+		fmt.Fprintf(os.Stderr, "[Worker:%s] no se pudieron aplicar los rlimits: %v\n", h.task, err)
+		return 2
+	}
+
+	in := os.NewFile(workerRequestFD, "jobs")
+	h.out = os.NewFile(workerReplyFD, "results")
+	watchCPULimit(func() {
+		err := &LimitError{Code: LimitCPU, Limit: int64(limits.CPUSeconds)}
+		msg := errorMsg(err)
+		msg.Exit = true
+		h.send(msg)
+		os.Exit(3)
+	})
+
+	dec := json.NewDecoder(in)
+	for {
+		var req procRequest
+		if err := dec.Decode(&req); err != nil {
+			if err == io.EOF {
+				return 0
+			}
+			fmt.Fprintf(os.Stderr, "[Worker:%s] pedido inválido: %v\n", h.task, err)
+			return 2
+		}
+		if err := resetCPULimit(limits.CPUSeconds); err != nil {
+			fmt.Fprintf(os.Stderr, "[Worker:%s] RLIMIT_CPU: %v\n", h.task, err)
+		}
+		h.send(runRequest(context.Background(), h.fn, h.task, req, h.send))
+	}
+}
+
+// runRequest ejecuta un pedido con fn y arma el mensaje final. Lo usan los
+// procesos worker y los nodos remotos (ver node.go). Mientras la tarea
+// corre manda heartbeats del lease por relay; el último sale antes de que
+// runRequest devuelva, así que no se mezcla con el pedido siguiente.
+func runRequest(ctx context.Context, fn TaskFunc, task string, req procRequest, relay func(procMsg)) (msg procMsg) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = errorMsg(&panicError{fmt.Sprintf("panic en tarea '%s': %v", task, r)})
+		}
+	}()
+	if req.LeaseMs > 0 {
+		stop, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+			t := time.NewTicker(time.Duration(req.LeaseMs) * time.Millisecond / 3)
+			defer t.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-t.C:
+					relay(procMsg{Type: "heartbeat"})
+				}
+			}
+		}()
+		defer func() {
+			close(stop)
+			<-stopped
+		}()
+	}
+	p := &Progress{jobID: req.JobID, start: time.Now(), limits: req.Limits, relay: relay, resume: req.Checkpoint}
+	res, err := fn(ctx, req.Params, p)
+	if err != nil {
+		return errorMsg(err)
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return errorMsg(err)
+	}
+	return procMsg{Type: "result", Data: data}
+}
+
+func (h *WorkerHost) send(msg procMsg) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.out.Write(append(data, '\n'))
+}
+
+// -----------------------------------------------------------------------------
+// Estado de los workers
+// -----------------------------------------------------------------------------
+
+// WorkerStatus lista los workers de cada pool. Los de un pool aislado son
+// sus procesos hijos; los demás son goroutines del servidor y reportan su
+// pid. Al final van los slots de los nodos remotos.
+func (m *Manager) WorkerStatus() map[string][]WorkerInfo {
+	m.mu.RLock()
+	pools := make(map[string]*WorkerPool, len(m.pools))
+	for name, p := range m.pools {
+		pools[name] = p
+	}
+	m.mu.RUnlock()
+
+	pid := os.Getpid()
+	out := make(map[string][]WorkerInfo, len(pools))
+	for name, p := range pools {
+		if p.procs != nil {
+			out[name] = p.procs.workers()
+			continue
+		}
+		p.mu.Lock()
+		ids := make([]int, 0, len(p.workers))
+		for id := range p.workers {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		list := make([]WorkerInfo, 0, len(ids))
+		for _, id := range ids {
+			w := WorkerInfo{PID: pid, Status: "idle"}
+			if job, ok := p.running[id]; ok {
+				w.Status, w.JobID = "busy", job
+			}
+			list = append(list, w)
+		}
+		p.mu.Unlock()
+		out[name] = list
+	}
+	m.remoteWorkers(out)
+	return out
+}
@@ -0,0 +1,67 @@
+//go:build linux
+
+package jobs
+
+import (
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"syscall"
+)
+
+// applyRlimits fija en el proceso worker los límites de memoria y de
+// archivos abiertos. El runtime recibe además un límite blando un poco
+// menor, para que el GC libere memoria antes de que falle una reserva.
+func applyRlimits(l ProcessLimits) error {
+	if l.Memory > 0 {
+		if err := setSoftLimit(syscall.RLIMIT_DATA, l.Memory); err != nil {
+			return err
+		}
+		debug.SetMemoryLimit(int64(l.Memory / 10 * 9))
+	}
+	if l.OpenFiles > 0 {
+		if err := setSoftLimit(syscall.RLIMIT_NOFILE, l.OpenFiles); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetCPULimit renueva el RLIMIT_CPU antes de cada job: el límite cuenta
+// la CPU de toda la vida del proceso, así que se fija en lo ya consumido
+// más seconds. Solo se toca el límite blando, que se puede volver a subir.
+func resetCPULimit(seconds uint64) error {
+	if seconds == 0 {
+		return nil
+	}
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return err
+	}
+	used := uint64(ru.Utime.Sec) + uint64(ru.Stime.Sec) + 1
+	return setSoftLimit(syscall.RLIMIT_CPU, used+seconds)
+}
+
+// watchCPULimit llama a fn cuando el proceso excede su RLIMIT_CPU. El
+// runtime de Go ignora SIGXCPU si nadie lo escucha.
+func watchCPULimit(fn func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGXCPU)
+	go func() {
+		<-ch
+		fn()
+	}()
+}
+
+// setSoftLimit cambia el límite blando de resource sin pasar del duro.
+func setSoftLimit(resource int, v uint64) error {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(resource, &rl); err != nil {
+		return err
+	}
+	rl.Cur = v
+	if rl.Cur > rl.Max {
+		rl.Cur = rl.Max
+	}
+	return syscall.Setrlimit(resource, &rl)
+}
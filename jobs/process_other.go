@@ -0,0 +1,12 @@
+//go:build !linux
+
+package jobs
+
+// Sin rlimits fuera de Linux: los procesos worker corren con los límites
+// del servidor y solo se controlan por timeout.
+
+func applyRlimits(l ProcessLimits) error { return nil }
+
+func resetCPULimit(seconds uint64) error { return nil }
+
+func watchCPULimit(fn func()) {}
@@ -0,0 +1,191 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestMain atiende los pedidos cuando el binario de test se relanza como
+// proceso worker de un pool aislado.
+func TestMain(m *testing.M) {
+	if task := WorkerTask(); task != "" {
+		host := NewWorkerHost(task)
+		registerIsolated(host)
+		os.Exit(host.Serve())
+	}
+	os.Exit(m.Run())
+}
+
+// registerIsolated registra las tareas aisladas de los tests, en el
+// Manager o en el WorkerHost del hijo.
+func registerIsolated(r Registrar) {
+	r.Register("echo", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		p.Update(1, 2, "mitad")
+		return map[string]any{"pid": os.Getpid(), "n": params["n"]}, nil
+	}, 1, 10, 5*time.Second, WithIsolation(ProcessLimits{OpenFiles: 64}))
+
+	// ignora el contexto: solo se detiene matando al proceso
+	r.Register("stuck", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		time.Sleep(time.Hour)
+		return nil, nil
+	}, 1, 10, 300*time.Millisecond, WithIsolation(ProcessLimits{}))
+
+	// un panic en una goroutine auxiliar tira abajo el proceso entero
+	r.Register("crash", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		go func() { panic("fuera del worker") }()
+		time.Sleep(time.Hour)
+		return nil, nil
+	}, 1, 10, 5*time.Second, WithIsolation(ProcessLimits{}))
+
+	// consume CPU sin límite de tiempo propio
+	r.Register("spin", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		for {
+		}
+	}, 1, 10, 10*time.Second, WithIsolation(ProcessLimits{CPUSeconds: 1}))
+
+	// duerme ms milisegundos sin informar progreso
+	r.Register("nap", napTask, 1, 10, 5*time.Second, WithIsolation(ProcessLimits{}))
+
+	r.Register("mem", memTask, 1, 10, 5*time.Second,
+		WithLimits(ResourceLimits{Memory: 4 << 20}),
+		WithIsolation(ProcessLimits{}))
+}
+
+func isolatedManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager("", time.Minute, time.Minute)
+	registerIsolated(m)
+	return m
+}
+
+// echoPID ejecuta un echo y devuelve el pid del proceso que lo atendió
+func echoPID(t *testing.T, m *Manager) int {
+	t.Helper()
+	id, _, err := m.Submit("echo", url.Values{"n": {"7"}}, PrioNormal)
+	if err != nil {
+		t.Fatalf("Submit(echo): %v", err)
+	}
+	j := waitStatus(t, m, id, StatusDone)
+	res, ok := j.Result.(map[string]any)
+	if !ok || res["n"] != "7" {
+		t.Fatalf("resultado de echo = %#v", j.Result)
+	}
+	return int(res["pid"].(float64))
+}
+
+// TestProcess_RunsInChild prueba que el job corre en otro proceso y que
+// /status ve su pid
+func TestProcess_RunsInChild(t *testing.T) {
+	m := isolatedManager(t)
+	defer m.Close()
+
+	pid := echoPID(t, m)
+	if pid == os.Getpid() {
+		t.Fatal("el job aislado corrió en el proceso del servidor")
+	}
+	if again := echoPID(t, m); again != pid {
+		t.Errorf("segundo job en el pid %d; se esperaba reutilizar el %d", again, pid)
+	}
+
+	workers := m.WorkerStatus()["echo"]
+	if len(workers) != 1 || workers[0].PID != pid || workers[0].Status != "idle" {
+		t.Errorf("WorkerStatus()[echo] = %+v; se esperaba el pid %d ocioso", workers, pid)
+	}
+	for _, w := range m.WorkerStatus()["stuck"] {
+		if w.PID == os.Getpid() {
+			t.Errorf("el pool aislado reporta el pid del servidor: %+v", w)
+		}
+	}
+}
+
+// TestProcess_TimeoutKills prueba que el timeout mata al proceso aunque la
+// tarea ignore el contexto
+func TestProcess_TimeoutKills(t *testing.T) {
+	m := isolatedManager(t)
+	defer m.Close()
+
+	id, _, _ := m.Submit("stuck", nil, PrioNormal)
+	waitUntil(t, func() bool {
+		w := m.WorkerStatus()["stuck"]
+		return len(w) == 1 && w[0].Status == "busy"
+	})
+	killed := m.WorkerStatus()["stuck"][0].PID
+
+	waitStatus(t, m, id, StatusTimeout)
+	waitUntil(t, func() bool {
+		for _, w := range m.WorkerStatus()["stuck"] {
+			if w.PID == killed {
+				return false
+			}
+		}
+		return true
+	})
+	if p, err := os.FindProcess(killed); err == nil && p.Signal(syscall.Signal(0)) == nil {
+		t.Errorf("el proceso %d sigue vivo tras el timeout", killed)
+	}
+}
+
+// TestProcess_CrashIsolated prueba que un panic fuera de la goroutine de
+// la tarea solo hace fallar al job
+func TestProcess_CrashIsolated(t *testing.T) {
+	m := isolatedManager(t)
+	defer m.Close()
+
+	id, _, _ := m.Submit("crash", nil, PrioNormal)
+	j := waitStatus(t, m, id, StatusError)
+	if len(j.Errors) == 0 || j.Errors[0].Class != ClassPanic {
+		t.Errorf("errores = %+v; se esperaba clase panic", j.Errors)
+	}
+	// el servidor sigue atendiendo
+	echoPID(t, m)
+}
+
+// TestProcess_LimitFromChild prueba que un error de límite del hijo llega
+// con su código
+func TestProcess_LimitFromChild(t *testing.T) {
+	m := isolatedManager(t)
+	defer m.Close()
+
+	id, _, _ := m.Submit("mem", url.Values{"mb": {"8"}}, PrioNormal)
+	j := waitStatus(t, m, id, StatusError)
+	if j.ErrorCode != LimitMemory {
+		t.Errorf("error_code = %q (%s); se esperaba %s", j.ErrorCode, j.Error, LimitMemory)
+	}
+
+	err := procMsg{Type: "error", Error: "x", Class: ClassIO}.err()
+	if classifyError(err) != ClassIO || errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("error remoto de IO clasificado como %s", classifyError(err))
+	}
+}
+
+// TestProcess_CPULimit prueba que el RLIMIT_CPU del hijo corta el job con
+// cpu_limit
+func TestProcess_CPULimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("rlimits solo en Linux")
+	}
+	m := isolatedManager(t)
+	defer m.Close()
+
+	id, _, _ := m.Submit("spin", nil, PrioNormal)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		j, _ := m.GetStatus(id)
+		if j.Status == StatusError {
+			if j.ErrorCode != LimitCPU {
+				t.Errorf("error_code = %q (%s); se esperaba %s", j.ErrorCode, j.Error, LimitCPU)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s status = %s; se esperaba error por RLIMIT_CPU", id, j.Status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,197 @@
+package jobs
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Intervalo mínimo entre escrituras de progreso al Job cuando ni el
+// porcentaje ni la etapa cambiaron (solo se refresca el ETA).
+const progressMinInterval = 250 * time.Millisecond
+
+// Progress es el reporter que recibe cada TaskFunc. Es la única forma en
+// que una tarea modifica su Job: las escrituras pasan por el lock del
+// Manager, así que no hay carreras con GetStatus.
+//
+// Un *Progress nil es válido y descarta todos los reportes.
+type Progress struct {
+	m     *Manager
+	jobID string
+	start time.Time
+
+	mu       sync.Mutex
+	percent  int
+	stage    string
+	lastSent time.Time
+
+	// uso declarado por la tarea, contra los límites del job (ver limits.go)
+	limits       ResourceLimits
+	memory, disk atomic.Int64
+
+	// en un proceso worker no hay Manager: los reportes viajan por el pipe
+	// y el checkpoint llega con el pedido (ver process.go)
+	relay  func(procMsg)
+	resume json.RawMessage
+}
+
+// heartbeat renueva el lease del job con un heartbeat de un proceso
+// aislado o de un nodo (ver lease.go).
+func (p *Progress) heartbeat() {
+	if p != nil && p.m != nil {
+		p.m.renewLease(p.jobID)
+	}
+}
+
+func newProgress(m *Manager, job *Job) *Progress {
+	limits, _ := m.limitsFor(job.Task, job.Limits)
+	return &Progress{m: m, jobID: job.ID, start: time.Now(), limits: limits}
+}
+
+// Set reporta el porcentaje completado (0..100).
+func (p *Progress) Set(percent int) {
+	if p == nil {
+		return
+	}
+	p.report(float64(percent)/100, "", false)
+}
+
+// Stage cambia el texto libre que describe la etapa actual.
+func (p *Progress) Stage(stage string) {
+	if p == nil {
+		return
+	}
+	p.report(-1, stage, true)
+}
+
+// Update reporta done de total unidades (bytes, filas, iteraciones...) y,
+// si no es vacío, la etapa actual. Tiene la firma de tasks.ProgressFunc.
+func (p *Progress) Update(done, total int64, stage string) {
+	if p == nil || total <= 0 {
+		return
+	}
+	p.report(float64(done)/float64(total), stage, stage != "")
+}
+
+// Checkpoint guarda v (serializable a JSON) como estado intermedio del job.
+// Con la política RecoverResume, si el servidor cae durante la ejecución,
+// el job vuelve a la cola y la tarea lo recupera con Resume.
+func (p *Progress) Checkpoint(v any) error {
+	if p == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if p.relay != nil {
+		p.relay(procMsg{Type: "checkpoint", Data: data})
+		return nil
+	}
+	p.m.mu.Lock()
+	j, ok := p.m.jobs[p.jobID]
+	if ok && j.Status == StatusRunning {
+		j.Checkpoint = data
+		j.UpdatedAt = time.Now()
+	}
+	p.m.mu.Unlock()
+	if ok {
+		p.m.persist(p.jobID)
+	}
+	return nil
+}
+
+// Resume carga en v el último checkpoint del job. Devuelve false si no hay
+// ninguno (primera ejecución): la tarea empieza desde el principio.
+func (p *Progress) Resume(v any) bool {
+	if p == nil {
+		return false
+	}
+	data := p.resume
+	if p.m != nil {
+		p.m.mu.RLock()
+		if j, ok := p.m.jobs[p.jobID]; ok {
+			data = j.Checkpoint
+		}
+		p.m.mu.RUnlock()
+	}
+	return len(data) > 0 && json.Unmarshal(data, v) == nil
+}
+
+// ReserveMemory declara n bytes que la tarea está por reservar (matrices,
+// archivos leídos completos). Si el total supera el límite de memoria del
+// job devuelve un error de límite: la tarea debe abortar con él. Tiene la
+// firma de tasks.QuotaFunc.
+func (p *Progress) ReserveMemory(n int64) error {
+	if p == nil {
+		return nil
+	}
+	return p.limits.check(ResourceUsage{Memory: p.memory.Add(n)}, false)
+}
+
+// WriteDisk declara n bytes que la tarea está por escribir a disco, con
+// el mismo contrato que ReserveMemory.
+func (p *Progress) WriteDisk(n int64) error {
+	if p == nil {
+		return nil
+	}
+	return p.limits.check(ResourceUsage{Disk: p.disk.Add(n)}, false)
+}
+
+// report calcula porcentaje y ETA a partir de la fracción completada y la
+// tasa observada desde el inicio. fraction < 0 conserva el porcentaje actual.
+func (p *Progress) report(fraction float64, stage string, setStage bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	percent := p.percent
+	if fraction >= 0 {
+		if fraction > 1 {
+			fraction = 1
+		}
+		// 100 queda reservado para cuando el Manager cierra el job
+		percent = int(fraction * 100)
+		if percent > 99 {
+			percent = 99
+		}
+	}
+	if !setStage {
+		stage = p.stage
+	}
+
+	now := time.Now()
+	if percent == p.percent && stage == p.stage && now.Sub(p.lastSent) < progressMinInterval {
+		return
+	}
+
+	eta := int64(-1) // -1: sin cambios
+	if fraction > 0 {
+		elapsed := now.Sub(p.start)
+		remaining := time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+		eta = remaining.Milliseconds()
+	}
+
+	p.percent, p.stage, p.lastSent = percent, stage, now
+	if p.relay != nil {
+		p.relay(procMsg{Type: "progress", Fraction: fraction, Stage: stage, SetStage: setStage})
+		return
+	}
+	p.m.updateProgress(p.jobID, percent, stage, eta)
+}
+
+// updateProgress escribe el progreso en el Job si sigue en ejecución.
+func (m *Manager) updateProgress(jobID string, percent int, stage string, etaMs int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[jobID]
+	if !ok || j.Status != StatusRunning {
+		return
+	}
+	j.Progress = percent
+	j.Stage = stage
+	if etaMs >= 0 {
+		j.ETAMs = etaMs
+	}
+	j.UpdatedAt = time.Now()
+}
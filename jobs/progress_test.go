@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestProgress_ETA prueba que el progreso y el ETA lleguen al Job mientras corre
+func TestProgress_ETA(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	release := make(chan struct{})
+	manager.Register("steps", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		time.Sleep(40 * time.Millisecond)
+		p.Update(1, 4, "etapa 1")
+		<-release
+		return "ok", nil
+	}, 1, 1, 5*time.Second)
+	defer manager.Close()
+
+	jobID, _, _ := manager.Submit("steps", url.Values{}, PrioNormal)
+	time.Sleep(100 * time.Millisecond)
+
+	job, _ := manager.GetStatus(jobID)
+	if job.Progress != 25 || job.Stage != "etapa 1" {
+		t.Errorf("Progress = %d, Stage = %q; se esperaba 25 y 'etapa 1'", job.Progress, job.Stage)
+	}
+	// 1/4 en ~40ms => quedan ~120ms
+	if job.ETAMs < 60 || job.ETAMs > 400 {
+		t.Errorf("ETAMs = %d; se esperaba alrededor de 120", job.ETAMs)
+	}
+
+	close(release)
+	job = waitStatus(t, manager, jobID, StatusDone)
+	if job.Progress != 100 || job.ETAMs != 0 {
+		t.Errorf("Job terminado Progress = %d, ETAMs = %d; se esperaba 100 y 0", job.Progress, job.ETAMs)
+	}
+}
+
+// TestProgress_Nil prueba que un reporter nil descarte los reportes sin fallar
+func TestProgress_Nil(t *testing.T) {
+	var p *Progress
+	p.Set(50)
+	p.Stage("x")
+	p.Update(1, 2, "y")
+}
@@ -0,0 +1,327 @@
+package jobs
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Listado de jobs: filtros, orden y paginación por cursor. Tanto los
+// Stores como el Manager mantienen índices por estado y por tarea junto a
+// su mapa, de modo que un filtro solo recorre los jobs que pueden cumplirlo.
+
+var ErrInvalidCursor = errors.New("cursor inválido")
+
+// Criterios de orden de JobQuery.Sort. Los empates se desempatan por id.
+const (
+	SortCreated  = "created_at"
+	SortUpdated  = "updated_at"
+	SortPriority = "priority"
+)
+
+// MaxListLimit acota el tamaño de una página.
+const MaxListLimit = 1000
+
+// JobQuery filtra, ordena y pagina List. Los campos vacíos no filtran.
+type JobQuery struct {
+	Task     string
+	Status   JobStatus
+	Priority *JobPriority
+
+	// Rangos de tiempo: After incluido, Before excluido.
+	CreatedAfter, CreatedBefore time.Time
+	UpdatedAfter, UpdatedBefore time.Time
+
+	Sort   string // SortCreated (por defecto), SortUpdated o SortPriority
+	Desc   bool
+	Limit  int    // 0 = sin límite
+	Cursor string // NextCursor de la página anterior, con el mismo orden
+
+	// OmitResult deja la página sin Result, para quien no lo va a mostrar
+	// (p.ej. /jobs con fields que no piden result).
+	OmitResult bool
+}
+
+// JobPage es una página del listado. NextCursor vacío: no hay más.
+type JobPage struct {
+	Jobs       []Job  `json:"jobs"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Validate revisa los valores de la consulta.
+func (q JobQuery) Validate() error {
+	switch q.Sort {
+	case "", SortCreated, SortUpdated, SortPriority:
+	default:
+		return fmt.Errorf("orden desconocido %q (use %s, %s o %s)", q.Sort, SortCreated, SortUpdated, SortPriority)
+	}
+	switch q.Status {
+	case "", StatusQueued, StatusRunning, StatusDone, StatusError, StatusCanceled,
+		StatusTimeout, StatusRetrying, StatusScheduled:
+	default:
+		return fmt.Errorf("estado desconocido %q", q.Status)
+	}
+	if q.Limit < 0 || q.Limit > MaxListLimit {
+		return fmt.Errorf("limit debe estar entre 0 (sin límite) y %d", MaxListLimit)
+	}
+	if q.Cursor != "" {
+		if _, _, err := q.decodeCursor(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q JobQuery) match(j *Job) bool {
+	switch {
+	case q.Task != "" && j.Task != q.Task,
+		q.Status != "" && j.Status != q.Status,
+		q.Priority != nil && j.Priority != *q.Priority,
+		!q.CreatedAfter.IsZero() && j.CreatedAt.Before(q.CreatedAfter),
+		!q.CreatedBefore.IsZero() && !j.CreatedAt.Before(q.CreatedBefore),
+		!q.UpdatedAfter.IsZero() && j.UpdatedAt.Before(q.UpdatedAfter),
+		!q.UpdatedBefore.IsZero() && !j.UpdatedAt.Before(q.UpdatedBefore):
+		return false
+	}
+	return true
+}
+
+func (q JobQuery) sortName() string {
+	if q.Sort == "" {
+		return SortCreated
+	}
+	return q.Sort
+}
+
+// sortValue es el valor del job en el criterio de orden.
+func (q JobQuery) sortValue(j *Job) int64 {
+	switch q.Sort {
+	case SortUpdated:
+		return j.UpdatedAt.UnixNano()
+	case SortPriority:
+		return int64(j.Priority)
+	default:
+		return j.CreatedAt.UnixNano()
+	}
+}
+
+// before indica si (v1, id1) va antes que (v2, id2) en el orden pedido.
+func (q JobQuery) before(v1 int64, id1 string, v2 int64, id2 string) bool {
+	if q.Desc {
+		v1, id1, v2, id2 = v2, id2, v1, id1
+	}
+	return v1 < v2 || (v1 == v2 && id1 < id2)
+}
+
+// El cursor es la posición del último job entregado en el orden pedido:
+// la página siguiente empieza justo después, aunque entre tanto se hayan
+// agregado o eliminado jobs.
+func (q JobQuery) encodeCursor(j *Job) string {
+	order := "asc"
+	if q.Desc {
+		order = "desc"
+	}
+	raw := fmt.Sprintf("%s|%s|%d|%s", q.sortName(), order, q.sortValue(j), j.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func (q JobQuery) decodeCursor() (int64, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 4)
+	if len(parts) != 4 {
+		return 0, "", ErrInvalidCursor
+	}
+	order := "asc"
+	if q.Desc {
+		order = "desc"
+	}
+	if parts[0] != q.sortName() || parts[1] != order {
+		return 0, "", fmt.Errorf("%w: se generó con otro orden", ErrInvalidCursor)
+	}
+	v, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	return v, parts[3], nil
+}
+
+// jobIndex son los índices secundarios por estado y por tarea de un mapa
+// de jobs. Recuerda con qué estado indexó cada job: add lo mueve de
+// índice aunque el estado ya haya cambiado. No tiene lock propio: lo usa
+// quien tiene el lock del mapa.
+type jobIndex struct {
+	byStatus map[JobStatus]map[string]*Job
+	byTask   map[string]map[string]*Job
+	status   map[string]JobStatus
+}
+
+func newJobIndex() jobIndex {
+	return jobIndex{
+		byStatus: make(map[JobStatus]map[string]*Job),
+		byTask:   make(map[string]map[string]*Job),
+		status:   make(map[string]JobStatus),
+	}
+}
+
+// add indexa j o, si ya estaba, lo mueve al índice de su estado actual.
+func (x *jobIndex) add(j *Job) {
+	if prev, ok := x.status[j.ID]; ok {
+		if prev == j.Status && x.byStatus[prev][j.ID] == j {
+			return
+		}
+		x.remove(j)
+	}
+	if x.byStatus[j.Status] == nil {
+		x.byStatus[j.Status] = make(map[string]*Job)
+	}
+	x.byStatus[j.Status][j.ID] = j
+	if x.byTask[j.Task] == nil {
+		x.byTask[j.Task] = make(map[string]*Job)
+	}
+	x.byTask[j.Task][j.ID] = j
+	x.status[j.ID] = j.Status
+}
+
+func (x *jobIndex) remove(j *Job) {
+	st, ok := x.status[j.ID]
+	if !ok {
+		return
+	}
+	delete(x.status, j.ID)
+	delete(x.byStatus[st], j.ID)
+	if len(x.byStatus[st]) == 0 {
+		delete(x.byStatus, st)
+	}
+	delete(x.byTask[j.Task], j.ID)
+	if len(x.byTask[j.Task]) == 0 {
+		delete(x.byTask, j.Task)
+	}
+}
+
+// candidates elige el conjunto más chico que cubre los filtros de tarea y
+// estado; all si la consulta no filtra por ninguno.
+func (x *jobIndex) candidates(q JobQuery, all map[string]*Job) map[string]*Job {
+	set := all
+	if q.Status != "" {
+		set = x.byStatus[q.Status]
+	}
+	if q.Task != "" {
+		if byTask := x.byTask[q.Task]; len(byTask) < len(set) || q.Status == "" {
+			set = byTask
+		}
+	}
+	return set
+}
+
+func (t *table) List(q JobQuery) (JobPage, error) {
+	if err := q.Validate(); err != nil {
+		return JobPage{}, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	set := t.idx.candidates(q, t.jobs)
+	cands := make([]*Job, 0, len(set))
+	for _, j := range set {
+		cands = append(cands, j)
+	}
+	return q.page(cands), nil
+}
+
+// page aplica a cands los filtros, el cursor, el orden y el límite de la
+// consulta (ya validada) y devuelve copias de los jobs de la página. El
+// llamador debe impedir que cands cambie mientras tanto.
+func (q JobQuery) page(cands []*Job) JobPage {
+	var afterV int64
+	var afterID string
+	if q.Cursor != "" {
+		afterV, afterID, _ = q.decodeCursor()
+	}
+
+	type hit struct {
+		v int64
+		j *Job
+	}
+	var hits []hit
+	for _, j := range cands {
+		if !q.match(j) {
+			continue
+		}
+		v := q.sortValue(j)
+		if q.Cursor != "" && !q.before(afterV, afterID, v, j.ID) {
+			continue
+		}
+		hits = append(hits, hit{v, j})
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		return q.before(hits[a].v, hits[a].j.ID, hits[b].v, hits[b].j.ID)
+	})
+
+	var page JobPage
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+		page.NextCursor = q.encodeCursor(hits[len(hits)-1].j)
+	}
+	page.Jobs = make([]Job, len(hits))
+	for i, h := range hits {
+		if q.OmitResult {
+			cp := *h.j
+			cp.Result = nil
+			page.Jobs[i] = *cloneJob(&cp)
+			continue
+		}
+		page.Jobs[i] = *cloneJob(h.j)
+	}
+	return page
+}
+
+// -----------------------------------------------------------------------------
+// Uso desde el Manager
+// -----------------------------------------------------------------------------
+
+// ListJobs devuelve una página de jobs del mapa del Manager, con el mismo
+// estado que GetStatus: los seguidores se filtran y se muestran con el
+// estado y el avance de su líder.
+func (m *Manager) ListJobs(q JobQuery) (JobPage, error) {
+	if err := q.Validate(); err != nil {
+		return JobPage{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	set := m.idx.candidates(q, m.jobs)
+	cands := make([]*Job, 0, len(set))
+	seen := make(map[string]bool)
+	add := func(j *Job) {
+		if seen[j.ID] {
+			return
+		}
+		seen[j.ID] = true
+		if j.CoalescedWith != "" && !j.Status.Terminal() {
+			cp := *j
+			m.mirrorLeaderLocked(&cp)
+			j = &cp
+		}
+		cands = append(cands, j)
+	}
+	for _, j := range set {
+		add(j)
+	}
+	// un seguidor se lista con el estado de su líder, así que el filtro
+	// por estado también alcanza a los seguidores de los líderes elegidos
+	if q.Status != "" {
+		for _, j := range set {
+			for _, id := range m.followers[j.ID] {
+				if f, ok := m.jobs[id]; ok && (q.Task == "" || f.Task == q.Task) {
+					add(f)
+				}
+			}
+		}
+	}
+	return q.page(cands), nil
+}
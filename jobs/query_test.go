@@ -0,0 +1,241 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// fillStore crea n jobs alternando tarea, estado y prioridad, uno por segundo
+func fillStore(t *testing.T, s Store, n int, base time.Time) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		at := base.Add(time.Duration(i) * time.Second)
+		j := Job{
+			ID:        fmt.Sprintf("j%03d", i),
+			Task:      []string{"pi", "sortfile"}[i%2],
+			Status:    []JobStatus{StatusDone, StatusError, StatusQueued}[i%3],
+			Priority:  JobPriority(i % 3),
+			CreatedAt: at,
+			UpdatedAt: at,
+		}
+		if err := s.Put(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestStoreList_Pagination prueba que recorrer las páginas entregue cada job una vez y en orden
+func TestStoreList_Pagination(t *testing.T) {
+	s := NewMemoryStore()
+	fillStore(t, s, 25, time.Now())
+
+	for _, desc := range []bool{false, true} {
+		q := JobQuery{Desc: desc, Limit: 10}
+		var seen []string
+		for pages := 0; ; pages++ {
+			page, err := s.List(q)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			for _, j := range page.Jobs {
+				seen = append(seen, j.ID)
+			}
+			if page.NextCursor == "" {
+				if pages != 2 {
+					t.Errorf("desc=%v: %d páginas; se esperaban 3", desc, pages+1)
+				}
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+		if len(seen) != 25 {
+			t.Fatalf("desc=%v: %d jobs; se esperaban 25", desc, len(seen))
+		}
+		for i := 1; i < len(seen); i++ {
+			if (seen[i-1] < seen[i]) == desc {
+				t.Errorf("desc=%v: orden incorrecto en %s, %s", desc, seen[i-1], seen[i])
+			}
+		}
+	}
+}
+
+// TestStoreList_Filters prueba los filtros y que los índices sigan los cambios de estado
+func TestStoreList_Filters(t *testing.T) {
+	s := NewMemoryStore()
+	base := time.Now()
+	fillStore(t, s, 12, base)
+
+	high := PrioHigh
+	cases := []struct {
+		name string
+		q    JobQuery
+		want int
+	}{
+		{"task", JobQuery{Task: "pi"}, 6},
+		{"status", JobQuery{Status: StatusQueued}, 4},
+		{"task+status", JobQuery{Task: "pi", Status: StatusDone}, 2},
+		{"prio", JobQuery{Priority: &high}, 4},
+		{"created range", JobQuery{CreatedAfter: base.Add(3 * time.Second), CreatedBefore: base.Add(6 * time.Second)}, 3},
+		{"updated after", JobQuery{UpdatedAfter: base.Add(10 * time.Second)}, 2},
+	}
+	for _, c := range cases {
+		page, err := s.List(c.q)
+		if err != nil || len(page.Jobs) != c.want {
+			t.Errorf("%s: %d jobs, %v; se esperaban %d", c.name, len(page.Jobs), err, c.want)
+		}
+	}
+
+	// un job que cambia de estado pasa de un índice al otro
+	j, _ := storedJob(s, "j002")
+	j.Status = StatusDone
+	s.Put(j)
+	if page, _ := s.List(JobQuery{Status: StatusQueued}); len(page.Jobs) != 3 {
+		t.Errorf("queued tras Put: %d; se esperaban 3", len(page.Jobs))
+	}
+	s.Delete("j000")
+	if page, _ := s.List(JobQuery{Status: StatusDone}); len(page.Jobs) != 4 {
+		t.Errorf("done tras Put y Delete: %d; se esperaban 4", len(page.Jobs))
+	}
+
+	// orden por prioridad: empates por id
+	page, _ := s.List(JobQuery{Sort: SortPriority, Desc: true, Limit: 2})
+	if page.Jobs[0].Priority != PrioHigh || page.Jobs[0].ID < page.Jobs[1].ID {
+		t.Errorf("sort=priority desc = %v", page.Jobs)
+	}
+}
+
+// TestStoreList_InvalidQuery prueba los errores de validación
+func TestStoreList_InvalidQuery(t *testing.T) {
+	s := NewMemoryStore()
+	fillStore(t, s, 3, time.Now())
+	page, _ := s.List(JobQuery{Limit: 1})
+
+	if _, err := s.List(JobQuery{Limit: 1, Cursor: page.NextCursor, Sort: SortUpdated}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor de otro orden: err = %v; se esperaba ErrInvalidCursor", err)
+	}
+	if _, err := s.List(JobQuery{Cursor: "%%%"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor basura: err = %v", err)
+	}
+	if _, err := s.List(JobQuery{Sort: "nombre"}); err == nil {
+		t.Errorf("orden desconocido: se esperaba un error")
+	}
+	if _, err := s.List(JobQuery{Limit: MaxListLimit + 1}); err == nil {
+		t.Errorf("limit excesivo: se esperaba un error")
+	}
+}
+
+// TestManager_ListJobs prueba el listado a través del Manager
+func TestManager_ListJobs(t *testing.T) {
+	m := NewManager("", time.Minute, time.Minute)
+	m.Register("quick", quickTask, 1, 10, time.Second)
+	defer m.Close()
+
+	for i := 0; i < 3; i++ {
+		id, _, _ := m.Submit("quick", url.Values{"n": {fmt.Sprint(i)}}, PrioNormal)
+		waitStatus(t, m, id, StatusDone)
+	}
+	page, err := m.ListJobs(JobQuery{Task: "quick", Status: StatusDone, Limit: 2})
+	if err != nil || len(page.Jobs) != 2 || page.NextCursor == "" {
+		t.Fatalf("ListJobs = %+v, %v; se esperaban 2 jobs y un cursor", page, err)
+	}
+	rest, _ := m.ListJobs(JobQuery{Task: "quick", Status: StatusDone, Limit: 2, Cursor: page.NextCursor})
+	if len(rest.Jobs) != 1 || rest.NextCursor != "" {
+		t.Errorf("segunda página = %+v; se esperaba 1 job sin cursor", rest)
+	}
+}
+
+// TestManager_ListJobsFollowers prueba que el filtro por estado vea a los
+// seguidores con el estado de su líder, como GetStatus
+func TestManager_ListJobsFollowers(t *testing.T) {
+	gates := map[string]chan struct{}{"0": make(chan struct{}), "1": make(chan struct{})}
+	m := NewManager("", time.Minute, time.Minute)
+	m.Register("gated", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		select {
+		case <-gates[params["n"]]:
+			return params["n"], nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}, 1, 10, 5*time.Second, WithCoalescing(true))
+	defer m.Close()
+	defer close(gates["1"])
+
+	// el seguidor se une mientras el líder espera en la cola
+	busy, _, _ := m.Submit("gated", url.Values{"n": {"0"}}, PrioNormal)
+	waitStatus(t, m, busy, StatusRunning)
+	leader, _, _ := m.Submit("gated", url.Values{"n": {"1"}}, PrioNormal)
+	follower, _, _ := m.Submit("gated", url.Values{"n": {"1"}}, PrioNormal)
+	close(gates["0"])
+	waitStatus(t, m, leader, StatusRunning)
+
+	page, err := m.ListJobs(JobQuery{Task: "gated", Status: StatusRunning})
+	if err != nil || len(page.Jobs) != 2 {
+		t.Fatalf("ListJobs(running) = %+v, %v; se esperaban el líder y el seguidor", page, err)
+	}
+	for _, j := range page.Jobs {
+		if j.Status != StatusRunning {
+			t.Errorf("job %s listado como %s", j.ID, j.Status)
+		}
+	}
+	if page, _ := m.ListJobs(JobQuery{Task: "gated", Status: StatusQueued}); len(page.Jobs) != 0 {
+		t.Errorf("ListJobs(queued) = %+v; el seguidor %s ya no está en cola", page.Jobs, follower)
+	}
+}
+
+// TestStoreList_OmitResult prueba que OmitResult deje la página sin Result
+func TestStoreList_OmitResult(t *testing.T) {
+	s := NewMemoryStore()
+	if err := s.Put(Job{ID: "j1", Task: "pi", Status: StatusDone, Result: "3.14159", Params: map[string]string{"digits": "5"}}); err != nil {
+		t.Fatal(err)
+	}
+	page, _ := s.List(JobQuery{OmitResult: true})
+	if len(page.Jobs) != 1 || page.Jobs[0].Result != nil || page.Jobs[0].Params["digits"] != "5" {
+		t.Errorf("List(OmitResult) = %+v; se esperaba el job sin result", page.Jobs)
+	}
+	if page, _ := s.List(JobQuery{}); len(page.Jobs) != 1 || page.Jobs[0].Result != "3.14159" {
+		t.Errorf("List = %+v; se esperaba el job con result", page.Jobs)
+	}
+}
+
+// TestManager_ListJobsIndexed prueba que un filtro por tarea o estado no
+// recorra los jobs ajenos y que el índice siga a los cambios de estado
+func TestManager_ListJobsIndexed(t *testing.T) {
+	s := NewMemoryStore()
+	base := time.Now().Add(-time.Minute)
+	for i := 0; i < 1000; i++ {
+		at := base.Add(time.Duration(i) * time.Millisecond)
+		j := Job{ID: fmt.Sprintf("old%04d", i), Task: "old", Status: StatusDone, CreatedAt: at, UpdatedAt: at}
+		if err := s.Put(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewManager("", time.Hour, time.Hour, WithStore(s))
+	m.Register("quick", quickTask, 1, 10, time.Second)
+	defer m.Close()
+
+	id, _, _ := m.Submit("quick", url.Values{"n": {"1"}}, PrioNormal)
+	waitStatus(t, m, id, StatusDone)
+
+	m.mu.RLock()
+	byTask := len(m.idx.candidates(JobQuery{Task: "quick"}, m.jobs))
+	queued := len(m.idx.candidates(JobQuery{Status: StatusQueued}, m.jobs))
+	m.mu.RUnlock()
+	if byTask != 1 {
+		t.Errorf("candidatos de la tarea quick = %d; se esperaba 1 de 1001 jobs", byTask)
+	}
+	if queued != 0 {
+		t.Errorf("candidatos en cola = %d; el job terminado sigue indexado como queued", queued)
+	}
+
+	page, err := m.ListJobs(JobQuery{Task: "quick", Status: StatusDone})
+	if err != nil || len(page.Jobs) != 1 || page.Jobs[0].ID != id {
+		t.Fatalf("ListJobs = %+v, %v; se esperaba solo %s", page, err, id)
+	}
+	if page, _ := m.ListJobs(JobQuery{Status: StatusDone, Limit: MaxListLimit}); len(page.Jobs) != 1000 || page.NextCursor == "" {
+		t.Errorf("ListJobs(done) = %d jobs; se esperaba una página llena con cursor", len(page.Jobs))
+	}
+}
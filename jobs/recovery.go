@@ -0,0 +1,165 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Recuperación tras una caída: al registrar una tarea, los jobs que el
+// proceso anterior dejó "queued" vuelven a la cola y los que dejó
+// "running" siguen la RecoveryPolicy de la tarea.
+
+var ErrInterrupted = errors.New("interrumpido por un reinicio del servidor")
+
+// RecoveryPolicy decide qué hacer con un job que estaba en ejecución
+// cuando el proceso terminó sin un cierre ordenado.
+type RecoveryPolicy string
+
+const (
+	RecoverRetry  RecoveryPolicy = "retry"  // volver a la cola y ejecutarlo desde el principio
+	RecoverFail   RecoveryPolicy = "fail"   // marcarlo como error y copiarlo a la DLQ
+	RecoverResume RecoveryPolicy = "resume" // volver a la cola conservando su checkpoint
+)
+
+// RecoveryStats resume lo recuperado para una tarea al arrancar.
+type RecoveryStats struct {
+	Queued   int `json:"queued"`   // en cola: reencolados
+	Retried  int `json:"retried"`  // interrumpidos: reencolados desde el principio
+	Resumed  int `json:"resumed"`  // interrumpidos: reencolados con su checkpoint
+	Failed   int `json:"failed"`   // interrumpidos: marcados como error
+	Deferred int `json:"deferred"` // de los reencolados, sin lugar en la cola: esperan en el planificador
+	Rearmed  int `json:"rearmed"`  // scheduled/retrying devueltos al planificador
+}
+
+func (s RecoveryStats) empty() bool {
+	return s == RecoveryStats{}
+}
+
+// recoverLocked clasifica los jobs persistidos de la tarea. Devuelve los
+// que hay que encolar, en orden de prioridad y de creación, y los que
+// quedaron en error. Requiere m.mu tomado.
+func (m *Manager) recoverLocked(task string, policy RecoveryPolicy) (queue []*Job, failed []Job, st RecoveryStats) {
+	now := time.Now()
+	for _, j := range m.jobs {
+		// los seguidores esperan a su líder (ver relinkFollowersLocked)
+		if j.Task != task || j.CoalescedWith != "" {
+			continue
+		}
+		switch {
+		case j.Status == StatusScheduled && j.RunAt != nil:
+			m.sched.add(j.ID, *j.RunAt)
+			st.Rearmed++
+		case j.Status == StatusRetrying && j.NextAttemptAt != nil:
+			m.sched.add(j.ID, *j.NextAttemptAt)
+			st.Rearmed++
+		case j.Status == StatusQueued:
+			queue = append(queue, j)
+			st.Queued++
+		case j.Status == StatusRunning:
+			// el worker que tenía el lease murió con el servidor
+			j.Lease = nil
+			switch policy {
+			case RecoverFail:
+				j.Status = StatusError
+				m.idx.add(j)
+				j.Error = ErrInterrupted.Error()
+				j.Progress = 100
+				j.ETAMs = 0
+				j.UpdatedAt = now
+				failed = append(failed, *j)
+				st.Failed++
+				continue
+			case RecoverResume:
+				st.Resumed++
+			default:
+				j.Checkpoint = nil
+				st.Retried++
+			}
+			j.Status = StatusQueued
+			m.idx.add(j)
+			j.Progress = 0
+			j.Stage = ""
+			j.ETAMs = 0
+			j.UpdatedAt = now
+			queue = append(queue, j)
+		}
+	}
+	sort.Slice(queue, func(a, b int) bool {
+		if queue[a].Priority != queue[b].Priority {
+			return queue[a].Priority > queue[b].Priority
+		}
+		if !queue[a].CreatedAt.Equal(queue[b].CreatedAt) {
+			return queue[a].CreatedAt.Before(queue[b].CreatedAt)
+		}
+		return queue[a].ID < queue[b].ID
+	})
+	return queue, failed, st
+}
+
+// pushRecovered encola los jobs recuperados. Los que no entran quedan
+// "scheduled" y el planificador los encola, en el mismo orden, a medida
+// que haya lugar.
+func (m *Manager) pushRecovered(pool *WorkerPool, queue []*Job, st *RecoveryStats) {
+	now := time.Now()
+	for i, j := range queue {
+		if err := pool.Queue.Push(j); err == nil {
+			continue
+		}
+		at := now.Add(time.Duration(i) * time.Microsecond)
+		m.mu.Lock()
+		j.Status = StatusScheduled
+		m.idx.add(j)
+		j.RunAt = &at
+		m.mu.Unlock()
+		m.sched.add(j.ID, at)
+		st.Deferred++
+	}
+}
+
+// recoverTask ejecuta la recuperación de una tarea recién registrada.
+// Requiere que la tarea ya esté en m.tasks y su pool sin arrancar.
+func (m *Manager) recoverTask(name string, tc *taskConf) {
+	m.mu.Lock()
+	resolved := m.relinkFollowersLocked(name)
+	queue, failed, st := m.recoverLocked(name, tc.recovery)
+	m.mu.Unlock()
+
+	m.pushRecovered(tc.pool, queue, &st)
+
+	ids := make([]string, 0, len(queue)+len(failed))
+	for _, j := range queue {
+		ids = append(ids, j.ID)
+	}
+	for _, j := range failed {
+		ids = append(ids, j.ID)
+	}
+	m.persist(ids...)
+	for _, j := range failed {
+		m.deadLetter(j, classifyError(ErrInterrupted), ReasonInterrupted)
+		m.jobFinished(j)
+	}
+	for _, leader := range resolved {
+		m.resolveFollowers(leader)
+	}
+
+	m.mu.Lock()
+	m.recovery[name] = st
+	m.mu.Unlock()
+	if !st.empty() {
+		fmt.Printf("[Recovery] %s: %d en cola, %d reintentados, %d retomados, %d fallidos, %d diferidos, %d reprogramados\n",
+			name, st.Queued, st.Retried, st.Resumed, st.Failed, st.Deferred, st.Rearmed)
+	}
+}
+
+// RecoveryReport devuelve, por tarea, lo recuperado al arrancar.
+func (m *Manager) RecoveryReport() map[string]RecoveryStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]RecoveryStats, len(m.recovery))
+	for name, st := range m.recovery {
+		out[name] = st
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeJobsFile deja en disco el estado que un proceso caído habría persistido
+func writeJobsFile(t *testing.T, file string, list ...*Job) {
+	t.Helper()
+	state := map[string]*Job{}
+	for _, j := range list {
+		state[j.ID] = j
+	}
+	data, _ := json.Marshal(state)
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestManager_RecoverQueuedInOrder prueba que los jobs en cola vuelvan a la cola en su orden de creación
+func TestManager_RecoverQueuedInOrder(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	base := time.Now().Add(-time.Minute)
+	var pending []*Job
+	for i, id := range []string{"q-c", "q-a", "q-d", "q-b"} {
+		// los ids no siguen el orden de creación: manda CreatedAt
+		at := base.Add(time.Duration([]int{2, 0, 3, 1}[i]) * time.Second)
+		pending = append(pending, &Job{ID: id, Task: "record", Status: StatusQueued, Priority: PrioNormal, CreatedAt: at})
+	}
+	writeJobsFile(t, file, pending...)
+
+	var mu sync.Mutex
+	var order []string
+	m := NewManager(file, time.Minute, time.Minute)
+	defer m.Close()
+	m.Register("record", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		mu.Lock()
+		order = append(order, p.jobID)
+		mu.Unlock()
+		return "ok", nil
+	}, 1, 10, time.Second)
+
+	for _, id := range []string{"q-a", "q-b", "q-c", "q-d"} {
+		waitStatus(t, m, id, StatusDone)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"q-a", "q-b", "q-c", "q-d"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("orden de ejecución = %v; se esperaba %v", order, want)
+		}
+	}
+	if st := m.RecoveryReport()["record"]; st.Queued != 4 {
+		t.Errorf("reporte = %+v; se esperaban 4 en cola", st)
+	}
+}
+
+// TestManager_RecoverInterrupted prueba las tres políticas para jobs que quedaron en ejecución
+func TestManager_RecoverInterrupted(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	now := time.Now()
+	checkpoint := json.RawMessage(`{"next":7}`)
+	writeJobsFile(t, file,
+		&Job{ID: "r-retry", Task: "retry", Status: StatusRunning, Progress: 40, Checkpoint: checkpoint, CreatedAt: now},
+		&Job{ID: "r-resume", Task: "resume", Status: StatusRunning, Progress: 70, Checkpoint: checkpoint, CreatedAt: now},
+		&Job{ID: "r-fail", Task: "fail", Status: StatusRunning, Progress: 10, CreatedAt: now},
+	)
+
+	// cada tarea devuelve desde dónde arrancó
+	fromCheckpoint := func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		var cp struct{ Next int }
+		p.Resume(&cp)
+		return cp.Next, nil
+	}
+	m := NewManager(file, time.Minute, time.Minute)
+	defer m.Close()
+	m.Register("retry", fromCheckpoint, 1, 4, time.Second)
+	m.Register("resume", fromCheckpoint, 1, 4, time.Second, WithRecovery(RecoverResume))
+	m.Register("fail", fromCheckpoint, 1, 4, time.Second, WithRecovery(RecoverFail))
+
+	if j := waitStatus(t, m, "r-retry", StatusDone); j.Result != 0 {
+		t.Errorf("retry: resultado = %v; se esperaba empezar de cero", j.Result)
+	}
+	if j := waitStatus(t, m, "r-resume", StatusDone); j.Result != 7 {
+		t.Errorf("resume: resultado = %v; se esperaba retomar desde el checkpoint (7)", j.Result)
+	}
+	j := waitStatus(t, m, "r-fail", StatusError)
+	if j.Error != ErrInterrupted.Error() {
+		t.Errorf("fail: error = %q; se esperaba %q", j.Error, ErrInterrupted)
+	}
+	if dl := m.DeadLetters(DeadLetterFilter{ID: "r-fail"}); len(dl) != 1 || dl[0].Reason != ReasonInterrupted {
+		t.Errorf("fail: DLQ = %+v; se esperaba una entrada %q", dl, ReasonInterrupted)
+	}
+
+	report := m.RecoveryReport()
+	if report["retry"].Retried != 1 || report["resume"].Resumed != 1 || report["fail"].Failed != 1 {
+		t.Errorf("reporte = %+v", report)
+	}
+}
+
+// TestProgress_Checkpoint prueba que el checkpoint quede guardado en el job
+func TestProgress_Checkpoint(t *testing.T) {
+	m := NewManager("", time.Minute, time.Minute)
+	defer m.Close()
+	m.Register("steps", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		var cp struct{ Next int }
+		if p.Resume(&cp) {
+			return nil, nil // primera ejecución: no debería haber checkpoint
+		}
+		return "ok", p.Checkpoint(map[string]int{"next": 3})
+	}, 1, 4, time.Second)
+
+	id, _, _ := m.Submit("steps", nil, PrioNormal)
+	j := waitStatus(t, m, id, StatusDone)
+	if j.Result != "ok" || string(j.Checkpoint) != `{"next":3}` {
+		t.Errorf("job = %+v; se esperaba resultado ok y checkpoint guardado", j)
+	}
+}
+
+// TestManager_RecoverAfterClose prueba que un job interrumpido por un
+// apagado ordenado no quede cancelado: al reiniciar lo resuelve la
+// política de recuperación
+func TestManager_RecoverAfterClose(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	m := NewManager(file, time.Minute, time.Minute)
+	m.Register("slow", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, 1, 10, time.Minute)
+	id, _, _ := m.Submit("slow", nil, PrioNormal)
+	waitStatus(t, m, id, StatusRunning)
+	m.Close()
+
+	reloaded := NewManager(file, time.Minute, time.Minute)
+	defer reloaded.Close()
+	reloaded.Register("slow", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		return "ok", nil
+	}, 1, 10, time.Minute, WithRecovery(RecoverRetry))
+	j := waitStatus(t, reloaded, id, StatusDone)
+	if j.Attempt != 2 {
+		t.Errorf("intento %d; se esperaba el 2", j.Attempt)
+	}
+	if st := reloaded.RecoveryReport()["slow"]; st.Retried != 1 {
+		t.Errorf("RecoveryReport = %+v; se esperaba 1 reintentado", st)
+	}
+}
@@ -0,0 +1,420 @@
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Nodos remotos: otros procesos (normalmente el mismo binario en modo nodo,
+// ver node.go) se conectan por TCP al Manager, anuncian qué tareas
+// ejecutan y con cuántos slots, y piden jobs. El protocolo es JSON por
+// línea en ambos sentidos:
+//
+//	nodo -> Manager   hello{node, pid, tasks, token}  lease{task}  heartbeat{job_id}
+//	                  progress / checkpoint / result / error {job_id, ...}
+//	Manager -> nodo   welcome{lease_ms}  job{task, request}  cancel{job_id}
+//
+// Cada lease pide un job de una tarea: el Manager lo saca de la cola del
+// pool como lo haría un worker local y lo ejecuta con runJobWith, así que
+// timeouts, reintentos, caché y límites funcionan igual. Mientras ejecuta,
+// el nodo renueva el lease del job con heartbeats (ver lease.go); si se
+// corta la conexión, el lease vence de inmediato.
+//
+// Un nodo recibe los parámetros de los jobs y sus resultados se guardan
+// como los de un worker local. Con WithNodeToken el saludo debe traer el
+// token compartido; sin token, el listener no debe quedar expuesto fuera
+// de una red de confianza.
+
+var (
+	ErrBadHello         = errors.New("saludo de nodo inválido")
+	ErrNodeUnauthorized = errors.New("token de nodo inválido")
+)
+
+// WithNodeToken exige a los nodos remotos el token compartido token en su
+// saludo (vacío = sin autenticación).
+func WithNodeToken(token string) ManagerOption {
+	return func(m *Manager) { m.nodes.token = token }
+}
+
+// WithRemoteOnly deja la tarea sin workers locales: sus jobs solo los
+// ejecutan los nodos remotos conectados. Ignora WithAutoscale.
+func WithRemoteOnly() TaskOption {
+	return func(tc *taskConf) { tc.remoteOnly = true }
+}
+
+// ParseNodeTasks interpreta la lista de tareas de un nodo, "pi:4,matrixmul"
+// (sin slots = 1).
+func ParseNodeTasks(s string) (map[string]int, error) {
+	out := make(map[string]int)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, slots, found := strings.Cut(item, ":")
+		n := 1
+		if found {
+			var err error
+			if n, err = strconv.Atoi(slots); err != nil || n <= 0 {
+				return nil, fmt.Errorf("slots inválidos en %q", item)
+			}
+		}
+		out[name] = n
+	}
+	if len(out) == 0 {
+		return nil, errors.New("el nodo no anuncia ninguna tarea")
+	}
+	return out, nil
+}
+
+// nodeMsg es un mensaje del protocolo de nodos. Los de ejecución reutilizan
+// los campos de procMsg (ver process.go), etiquetados con el job.
+type nodeMsg struct {
+	procMsg
+	JobID   string         `json:"job_id,omitempty"`
+	Node    string         `json:"node,omitempty"`
+	PID     int            `json:"pid,omitempty"`
+	Tasks   map[string]int `json:"tasks,omitempty"`
+	Token   string         `json:"token,omitempty"`
+	LeaseMs int64          `json:"lease_ms,omitempty"`
+	Task    string         `json:"task,omitempty"`
+	Request *procRequest   `json:"request,omitempty"`
+}
+
+// NodeInfo describe un nodo conectado (ruta /nodes de administración).
+type NodeInfo struct {
+	Name        string            `json:"name"`
+	Addr        string            `json:"addr"`
+	PID         int               `json:"pid"`
+	Tasks       map[string]int    `json:"tasks"` // slots por tarea
+	Running     map[string]string `json:"running"`
+	ConnectedAt time.Time         `json:"connected_at"`
+}
+
+// nodeTable son los nodos conectados al Manager.
+type nodeTable struct {
+	mu       sync.Mutex
+	sessions map[*nodeSession]struct{}
+	wg       sync.WaitGroup // leases en curso, para Close
+	closed   bool           // closeNodes: no se aceptan leases nuevos
+	token    string         // WithNodeToken
+}
+
+func newNodeTable() *nodeTable {
+	return &nodeTable{sessions: make(map[*nodeSession]struct{})}
+}
+
+// nodeSession es la conexión de un nodo.
+type nodeSession struct {
+	m     *Manager
+	conn  net.Conn
+	name  string
+	pid   int
+	tasks map[string]int
+	since time.Time
+
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	wmu sync.Mutex // serializa las escrituras a conn
+
+	mu      sync.Mutex
+	pending map[string]int        // leases en curso por tarea (esperando job o ejecutando)
+	queued  map[string]int        // pedidos que esperan a que cierre un lease
+	leases  map[string]*nodeLease // job en ejecución -> lease
+}
+
+// nodeLease es un job asignado a un nodo.
+type nodeLease struct {
+	task string
+	msgs chan procMsg
+	done chan struct{} // run terminó: ya no se aceptan mensajes
+}
+
+// ServeNodes acepta conexiones de nodos en ln hasta que se cierra.
+func (m *Manager) ServeNodes(ln net.Listener) error {
+	fmt.Printf("[Nodes] escuchando nodos en %s (lease %v)\n", ln.Addr(), m.leaseTTL)
+	if m.nodes.token == "" {
+		fmt.Printf("[Nodes] sin token: cualquiera que alcance %s puede tomar jobs y entregar resultados\n", ln.Addr())
+	}
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go m.serveNode(conn)
+	}
+}
+
+// serveNode atiende a un nodo: saludo, pedidos de lease y mensajes de
+// los jobs, hasta que se corta la conexión.
+func (m *Manager) serveNode(conn net.Conn) {
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 64*1024), 1<<30)
+
+	s := &nodeSession{
+		m: m, conn: conn, since: time.Now(),
+		closed:  make(chan struct{}),
+		pending: make(map[string]int),
+		queued:  make(map[string]int),
+		leases:  make(map[string]*nodeLease),
+	}
+	if err := s.hello(sc); err != nil {
+		fmt.Printf("[Nodes] %s rechazado: %v\n", conn.RemoteAddr(), err)
+		s.send(nodeMsg{procMsg: procMsg{Type: "error", Error: err.Error()}})
+		conn.Close()
+		return
+	}
+	m.nodes.mu.Lock()
+	m.nodes.sessions[s] = struct{}{}
+	m.nodes.mu.Unlock()
+	fmt.Printf("[Nodes] nodo %s conectado desde %s: %v\n", s.name, conn.RemoteAddr(), s.tasks)
+
+	for sc.Scan() {
+		var msg nodeMsg
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			fmt.Printf("[Nodes] mensaje inválido de %s: %v\n", s.name, err)
+			continue
+		}
+		switch msg.Type {
+		case "lease":
+			s.lease(msg.Task)
+		case "heartbeat", "progress", "checkpoint", "result", "error":
+			s.deliver(msg.JobID, msg.procMsg)
+		}
+	}
+
+	s.close()
+	m.nodes.mu.Lock()
+	delete(m.nodes.sessions, s)
+	m.nodes.mu.Unlock()
+	fmt.Printf("[Nodes] nodo %s desconectado\n", s.name)
+}
+
+// hello lee el saludo y responde con el vencimiento de los leases. Las
+// tareas que el Manager no tiene registradas se descartan.
+func (s *nodeSession) hello(sc *bufio.Scanner) error {
+	s.conn.SetReadDeadline(time.Now().Add(s.m.leaseTTL))
+	defer s.conn.SetReadDeadline(time.Time{})
+	if !sc.Scan() {
+		return ErrBadHello
+	}
+	var msg nodeMsg
+	if err := json.Unmarshal(sc.Bytes(), &msg); err != nil || msg.Type != "hello" || msg.Node == "" {
+		return ErrBadHello
+	}
+	if token := s.m.nodes.token; token != "" && subtle.ConstantTimeCompare([]byte(msg.Token), []byte(token)) != 1 {
+		return ErrNodeUnauthorized
+	}
+	s.name, s.pid, s.tasks = msg.Node, msg.PID, make(map[string]int)
+	s.m.mu.RLock()
+	for task, slots := range msg.Tasks {
+		if _, ok := s.m.tasks[task]; ok && slots > 0 {
+			s.tasks[task] = slots
+		}
+	}
+	s.m.mu.RUnlock()
+	if len(s.tasks) == 0 {
+		return fmt.Errorf("%w: ninguna tarea registrada en %v", ErrBadHello, msg.Tasks)
+	}
+	return s.send(nodeMsg{procMsg: procMsg{Type: "welcome"}, LeaseMs: s.m.leaseTTL.Milliseconds()})
+}
+
+func (s *nodeSession) send(msg nodeMsg) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	_, err = s.conn.Write(append(data, '\n'))
+	return err
+}
+
+// close corta la conexión; los leases abiertos vencen de inmediato.
+func (s *nodeSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.conn.Close()
+	})
+}
+
+// lease atiende un pedido de job de task. El nodo pide un lease por slot
+// libre; uno que llega antes de que cierre el anterior del mismo slot se
+// atiende al cerrarlo.
+func (s *nodeSession) lease(task string) {
+	s.mu.Lock()
+	slots, ok := s.tasks[task]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	if s.pending[task] >= slots {
+		s.queued[task]++
+		s.mu.Unlock()
+		return
+	}
+	s.pending[task]++
+	s.mu.Unlock()
+
+	s.m.nodes.mu.Lock()
+	if s.m.nodes.closed {
+		s.m.nodes.mu.Unlock()
+		return
+	}
+	s.m.nodes.wg.Add(1)
+	s.m.nodes.mu.Unlock()
+	go func() {
+		defer s.m.nodes.wg.Done()
+		for {
+			s.leaseOnce(task)
+			s.mu.Lock()
+			if s.queued[task] > 0 {
+				s.queued[task]--
+				s.mu.Unlock()
+				continue
+			}
+			s.pending[task]--
+			s.mu.Unlock()
+			return
+		}
+	}()
+}
+
+// leaseOnce espera un job de task y lo ejecuta en el nodo.
+func (s *nodeSession) leaseOnce(task string) {
+	s.m.mu.RLock()
+	pool := s.m.pools[task]
+	s.m.mu.RUnlock()
+	for {
+		job, ok := pool.Queue.Pop(s.closed)
+		if !ok {
+			return
+		}
+		// un job cancelado mientras estaba en cola no se ejecuta
+		if !s.m.startJob(job.ID, fmt.Sprintf("nodo %s (pid %d)", s.name, s.pid)) {
+			continue
+		}
+		fmt.Printf("[Nodes] job %s asignado al nodo %s\n", job.ID, s.name)
+		s.m.runJobWith(job, func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+			return s.run(ctx, task, params, p)
+		})
+		return
+	}
+}
+
+// deliver entrega un mensaje del nodo al lease de su job. Los mensajes de
+// un lease ya cerrado (vencido o cancelado) se descartan.
+func (s *nodeSession) deliver(jobID string, msg procMsg) {
+	s.mu.Lock()
+	l, ok := s.leases[jobID]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case l.msgs <- msg:
+	case <-l.done:
+	}
+}
+
+// run es la TaskFunc de un job asignado al nodo: lo envía y retransmite
+// sus mensajes hasta el resultado, la desconexión o la cancelación (que
+// incluye el vencimiento del lease).
+func (s *nodeSession) run(ctx context.Context, task string, params map[string]string, p *Progress) (any, error) {
+	l := &nodeLease{task: task, msgs: make(chan procMsg, 16), done: make(chan struct{})}
+	s.mu.Lock()
+	s.leases[p.jobID] = l
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.leases, p.jobID)
+		s.mu.Unlock()
+		close(l.done)
+	}()
+
+	req := procRequest{JobID: p.jobID, Params: params, Limits: p.limits, LeaseMs: s.m.leaseTTL.Milliseconds()}
+	p.Resume(&req.Checkpoint)
+	if err := s.send(nodeMsg{procMsg: procMsg{Type: "job"}, Task: task, Request: &req}); err != nil {
+		return nil, fmt.Errorf("%w (%s): %v", ErrLeaseExpired, s.name, err)
+	}
+
+	for {
+		select {
+		case msg := <-l.msgs:
+			if done, res, err := msg.apply(p); done {
+				return res, err
+			}
+		case <-s.closed:
+			return nil, fmt.Errorf("%w (%s desconectado)", ErrLeaseExpired, s.name)
+		case <-ctx.Done():
+			s.send(nodeMsg{procMsg: procMsg{Type: "cancel"}, JobID: p.jobID})
+			return nil, context.Cause(ctx)
+		}
+	}
+}
+
+// Nodes lista los nodos conectados, por nombre.
+func (m *Manager) Nodes() []NodeInfo {
+	m.nodes.mu.Lock()
+	sessions := make([]*nodeSession, 0, len(m.nodes.sessions))
+	for s := range m.nodes.sessions {
+		sessions = append(sessions, s)
+	}
+	m.nodes.mu.Unlock()
+
+	out := make([]NodeInfo, 0, len(sessions))
+	for _, s := range sessions {
+		info := NodeInfo{Name: s.name, Addr: s.conn.RemoteAddr().String(), PID: s.pid,
+			Tasks: s.tasks, Running: make(map[string]string), ConnectedAt: s.since}
+		s.mu.Lock()
+		for id, l := range s.leases {
+			info.Running[id] = l.task
+		}
+		s.mu.Unlock()
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// remoteWorkers agrega a ws los slots de los nodos conectados, como
+// workers con el pid y el nombre de su nodo.
+func (m *Manager) remoteWorkers(ws map[string][]WorkerInfo) {
+	for _, n := range m.Nodes() {
+		busy := make(map[string][]string)
+		for id, task := range n.Running {
+			busy[task] = append(busy[task], id)
+		}
+		for task, slots := range n.Tasks {
+			sort.Strings(busy[task])
+			for i := 0; i < slots; i++ {
+				w := WorkerInfo{PID: n.PID, Node: n.Name, Status: "idle"}
+				if i < len(busy[task]) {
+					w.Status, w.JobID = "busy", busy[task][i]
+				}
+				ws[task] = append(ws[task], w)
+			}
+		}
+	}
+}
+
+// closeNodes corta la conexión de todos los nodos.
+func (m *Manager) closeNodes() {
+	m.nodes.mu.Lock()
+	defer m.nodes.mu.Unlock()
+	m.nodes.closed = true
+	for s := range m.nodes.sessions {
+		s.close()
+	}
+}
@@ -0,0 +1,209 @@
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// nodeManager arranca un Manager con la tarea remota "square" y un
+// listener de nodos en localhost
+func nodeManager(t *testing.T, opts ...ManagerOption) (*Manager, string) {
+	t.Helper()
+	opts = append([]ManagerOption{WithLease(300*time.Millisecond, LeaseRequeue)}, opts...)
+	m := NewManager("", time.Minute, time.Minute, opts...)
+	m.Register("square", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		t.Error("un job de una tarea remota corrió en el Manager")
+		return nil, nil
+	}, 1, 16, 5*time.Second, WithRemoteOnly())
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go m.ServeNodes(ln)
+	t.Cleanup(func() {
+		ln.Close()
+		m.Close()
+	})
+	return m, ln.Addr().String()
+}
+
+// startNode conecta un nodo que ejecuta square con fn
+func startNode(t *testing.T, addr, name string, slots int, fn TaskFunc) context.CancelFunc {
+	t.Helper()
+	n := NewNode(name, map[string]int{"square": slots})
+	n.RetryEvery = 50 * time.Millisecond
+	n.Register("square", fn, 1, 1, time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	go n.Run(ctx, addr)
+	t.Cleanup(cancel)
+	return cancel
+}
+
+// squareOn devuelve el cuadrado de n y el nodo que lo calculó
+func squareOn(name string) TaskFunc {
+	return func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		n, _ := strconv.Atoi(params["n"])
+		p.Update(1, 2, "calculando")
+		time.Sleep(20 * time.Millisecond)
+		return map[string]any{"node": name, "square": n * n}, nil
+	}
+}
+
+func nodeCount(m *Manager) int { return len(m.Nodes()) }
+
+// TestParseNodeTasks prueba la lista de tareas de un nodo
+func TestParseNodeTasks(t *testing.T) {
+	got, err := ParseNodeTasks("pi:4, matrixmul")
+	if err != nil || got["pi"] != 4 || got["matrixmul"] != 1 || len(got) != 2 {
+		t.Errorf("ParseNodeTasks = %v, %v", got, err)
+	}
+	for _, bad := range []string{"", "pi:0", "pi:x"} {
+		if _, err := ParseNodeTasks(bad); err == nil {
+			t.Errorf("ParseNodeTasks(%q): se esperaba un error", bad)
+		}
+	}
+}
+
+// TestNodes_Token prueba que con WithNodeToken solo se acepten los nodos
+// que traen el token
+func TestNodes_Token(t *testing.T) {
+	m, addr := nodeManager(t, WithNodeToken("s3creto"))
+
+	for _, token := range []string{"", "otro"} {
+		n := NewNode("intruso", map[string]int{"square": 1})
+		n.Token = token
+		n.Register("square", squareOn("intruso"), 1, 1, time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := n.Run(ctx, addr)
+		cancel()
+		if !errors.Is(err, ErrNodeUnauthorized) {
+			t.Errorf("Run con token %q = %v; se esperaba ErrNodeUnauthorized", token, err)
+		}
+	}
+	if n := nodeCount(m); n != 0 {
+		t.Fatalf("nodos conectados = %d; se esperaba ninguno", n)
+	}
+
+	n := NewNode("a", map[string]int{"square": 1})
+	n.Token = "s3creto"
+	n.Register("square", squareOn("a"), 1, 1, time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go n.Run(ctx, addr)
+	id, _, _ := m.Submit("square", url.Values{"n": {"3"}}, PrioNormal)
+	if j := waitStatus(t, m, id, StatusDone); j.Result.(map[string]any)["node"] != "a" {
+		t.Errorf("resultado = %v; se esperaba del nodo a", j.Result)
+	}
+}
+
+// TestNodes_SeveralNodes prueba el reparto de jobs entre varios nodos
+func TestNodes_SeveralNodes(t *testing.T) {
+	m, addr := nodeManager(t)
+	startNode(t, addr, "a", 2, squareOn("a"))
+	startNode(t, addr, "b", 2, squareOn("b"))
+	waitUntil(t, func() bool { return nodeCount(m) == 2 })
+
+	ids := make([]string, 8)
+	for i := range ids {
+		id, _, err := m.Submit("square", url.Values{"n": {strconv.Itoa(i)}}, PrioNormal)
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		ids[i] = id
+	}
+	used := map[string]bool{}
+	for i, id := range ids {
+		j := waitStatus(t, m, id, StatusDone)
+		res := j.Result.(map[string]any)
+		if res["square"] != float64(i*i) {
+			t.Errorf("job %d: resultado %v", i, res)
+		}
+		used[res["node"].(string)] = true
+	}
+	if !used["a"] || !used["b"] {
+		t.Errorf("nodos usados = %v; se esperaba que trabajaran los dos", used)
+	}
+
+	workers := m.WorkerStatus()["square"]
+	if len(workers) != 4 || workers[0].Node == "" {
+		t.Errorf("WorkerStatus()[square] = %+v; se esperaban los 4 slots remotos", workers)
+	}
+}
+
+// TestNodes_LeaseExpires prueba que un nodo que deja de mandar heartbeats
+// pierde el job y otro lo termina
+func TestNodes_LeaseExpires(t *testing.T) {
+	m, addr := nodeManager(t)
+
+	// nodo a mano: toma un job, informa progreso y se queda callado
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	enc := json.NewEncoder(conn)
+	sc := bufio.NewScanner(conn)
+	enc.Encode(nodeMsg{procMsg: procMsg{Type: "hello"}, Node: "mudo", Tasks: map[string]int{"square": 1}})
+	var msg nodeMsg
+	if !sc.Scan() || json.Unmarshal(sc.Bytes(), &msg) != nil || msg.Type != "welcome" || msg.LeaseMs != 300 {
+		t.Fatalf("respuesta al saludo: %s", sc.Text())
+	}
+	enc.Encode(nodeMsg{procMsg: procMsg{Type: "lease"}, Task: "square"})
+
+	id, _, _ := m.Submit("square", url.Values{"n": {"9"}}, PrioNormal)
+	if !sc.Scan() || json.Unmarshal(sc.Bytes(), &msg) != nil || msg.Type != "job" || msg.Request.JobID != id {
+		t.Fatalf("se esperaba el job %s: %s", id, sc.Text())
+	}
+	enc.Encode(nodeMsg{procMsg: procMsg{Type: "progress", Fraction: 0.5, Stage: "mitad", SetStage: true}, JobID: id})
+	waitUntil(t, func() bool {
+		j, _ := m.GetStatus(id)
+		return j.Progress == 50 && j.Stage == "mitad"
+	})
+	if running := m.Nodes()[0].Running; running[id] != "square" {
+		t.Errorf("Nodes()[0].Running = %v; se esperaba el job %s", running, id)
+	}
+
+	// sin heartbeats el lease vence y el job vuelve a la cola
+	waitStatus(t, m, id, StatusQueued)
+	if !sc.Scan() || json.Unmarshal(sc.Bytes(), &msg) != nil || msg.Type != "cancel" || msg.JobID != id {
+		t.Errorf("se esperaba el cancel del job %s: %s", id, sc.Text())
+	}
+
+	startNode(t, addr, "b", 1, squareOn("b"))
+	j := waitStatus(t, m, id, StatusDone)
+	if res := j.Result.(map[string]any); res["node"] != "b" || res["square"] != float64(81) || j.Attempt != 2 {
+		t.Errorf("resultado %v en el intento %d; se esperaba 81 del nodo b en el intento 2", res, j.Attempt)
+	}
+}
+
+// TestNodes_Disconnect prueba que el job de un nodo que se desconecta
+// vuelve a la cola de inmediato
+func TestNodes_Disconnect(t *testing.T) {
+	m, addr := nodeManager(t)
+	started := make(chan struct{}, 1)
+	stopA := startNode(t, addr, "a", 1, func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	waitUntil(t, func() bool { return nodeCount(m) == 1 })
+
+	id, _, _ := m.Submit("square", url.Values{"n": {"3"}}, PrioNormal)
+	<-started
+	stopA()
+	waitUntil(t, func() bool { return nodeCount(m) == 0 })
+	j := waitStatus(t, m, id, StatusQueued)
+	if j.Error != "" {
+		t.Errorf("un lease perdido no es un error del job: %q", j.Error)
+	}
+
+	startNode(t, addr, "b", 1, squareOn("b"))
+	waitStatus(t, m, id, StatusDone)
+}
@@ -0,0 +1,211 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// ErrorClass agrupa los errores de un intento para decidir si se reintenta.
+type ErrorClass string
+
+const (
+	ClassTimeout ErrorClass = "timeout" // el intento superó el timeout de la tarea
+	ClassIO      ErrorClass = "io"      // errores de archivos/sistema (lock, disco, EOF)
+	ClassPanic   ErrorClass = "panic"   // la tarea entró en pánico
+	ClassTask    ErrorClass = "task"    // cualquier otro error devuelto por la tarea
+	ClassLimit   ErrorClass = "limit"   // excedió un límite de recursos: nunca se reintenta
+)
+
+// AttemptError registra el error de un intento fallido.
+type AttemptError struct {
+	Attempt int        `json:"attempt"`
+	Class   ErrorClass `json:"class"`
+	Error   string     `json:"error"`
+	At      time.Time  `json:"at"`
+}
+
+// RetryPolicy define cuántas veces y cuándo se reintenta un job fallido.
+// El valor cero (MaxAttempts <= 1) no reintenta.
+type RetryPolicy struct {
+	MaxAttempts int           // intentos totales, incluido el primero
+	BaseDelay   time.Duration // espera antes del segundo intento; se duplica en cada intento
+	MaxDelay    time.Duration // tope de la espera (0 = sin tope)
+	Jitter      float64       // 0..1: fracción aleatoria que se resta a la espera
+	RetryOn     []ErrorClass  // clases reintentables (vacío = todas menos panic)
+}
+
+// panicError marca los errores que provienen de un recover en runJob.
+type panicError struct{ msg string }
+
+func (e *panicError) Error() string { return e.msg }
+
+// classifyError asigna una ErrorClass a un error de tarea.
+func classifyError(err error) ErrorClass {
+	var pe *panicError
+	var re *remoteError
+	var pathErr *fs.PathError
+	var sysErr *os.SyscallError
+	switch {
+	case errors.As(err, &re):
+		return re.class
+	case errors.As(err, &pe):
+		return ClassPanic
+	case errors.Is(err, ErrLimitExceeded):
+		return ClassLimit
+	case errors.Is(err, ErrJobTimeout), errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrLeaseExpired):
+		return ClassTimeout
+	case errors.As(err, &pathErr), errors.As(err, &sysErr),
+		errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, fs.ErrPermission):
+		return ClassIO
+	}
+	return ClassTask
+}
+
+// allows indica si, tras fallar el intento `attempt` con un error de la
+// clase dada, corresponde otro intento.
+func (p RetryPolicy) allows(attempt int, class ErrorClass) bool {
+	if attempt >= p.MaxAttempts || class == ClassLimit {
+		return false
+	}
+	if len(p.RetryOn) == 0 {
+		return class != ClassPanic
+	}
+	for _, c := range p.RetryOn {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff calcula la espera antes del intento attempt+1: exponencial con jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = time.Second
+	}
+	d := time.Duration(float64(base) * math.Pow(2, float64(attempt-1)))
+	if p.MaxDelay > 0 && (d > p.MaxDelay || d <= 0) {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		j := math.Min(p.Jitter, 1)
+		d -= time.Duration(float64(d) * j * rand.Float64())
+	}
+	return d
+}
+
+// fail cierra un intento fallido: si la política de la tarea lo permite el
+// job pasa a "retrying" y se reprograma; si no, queda en el estado final st.
+func (m *Manager) fail(jobID string, st JobStatus, err error) {
+	m.mu.Lock()
+	j, ok := m.jobs[jobID]
+	if !ok || j.Status != StatusRunning {
+		m.mu.Unlock()
+		return
+	}
+	var policy RetryPolicy
+	if tc, ok := m.tasks[j.Task]; ok {
+		policy = tc.retry
+	}
+
+	now := time.Now()
+	class := classifyError(err)
+	j.Lease = nil
+	j.Errors = append(j.Errors, AttemptError{Attempt: j.Attempt, Class: class, Error: err.Error(), At: now})
+	j.Error = err.Error()
+	j.ErrorCode = ErrorCode(err)
+	j.UpdatedAt = now
+
+	attempt := j.Attempt
+	if policy.allows(attempt, class) {
+		delay := policy.backoff(attempt)
+		next := now.Add(delay)
+		j.Status = StatusRetrying
+		m.idx.add(j)
+		j.NextAttemptAt = &next
+		j.Progress = 0
+		j.Stage = ""
+		j.ETAMs = 0
+		m.mu.Unlock()
+
+		fmt.Printf("[Manager] job %s falló (intento %d, %s): reintento en %v\n", jobID, attempt, class, delay)
+		m.scheduleRetry(jobID, delay)
+		m.persist(jobID)
+		return
+	}
+
+	j.Status = st
+	m.idx.add(j)
+	j.Progress = 100
+	j.ETAMs = 0
+	final := *j
+	m.mu.Unlock()
+
+	// el job sigue en el mapa hasta el TTL; la DLQ guarda su copia permanente
+	reason := ReasonNotRetryable
+	switch {
+	case class == ClassPanic:
+		reason = ReasonPanic
+	case class == ClassLimit:
+		reason = ReasonLimit
+	case attempt >= policy.MaxAttempts:
+		reason = ReasonExhausted
+	}
+	m.deadLetter(final, class, reason)
+	m.persist(jobID)
+	m.jobFinished(final)
+}
+
+// scheduleRetry vuelve a encolar el job cuando vence su espera.
+func (m *Manager) scheduleRetry(jobID string, delay time.Duration) {
+	m.sched.add(jobID, time.Now().Add(delay))
+}
+
+// requeue pasa un job de "retrying" o "scheduled" a "queued" y lo devuelve
+// a su pool. Lo invoca el planificador al vencer la espera; si la cola
+// está llena, se vuelve a intentar en un segundo.
+func (m *Manager) requeue(jobID string) {
+	if m.ShuttingDown() {
+		return
+	}
+	m.mu.Lock()
+	j, ok := m.jobs[jobID]
+	if !ok || (j.Status != StatusRetrying && j.Status != StatusScheduled) {
+		m.mu.Unlock()
+		return
+	}
+	pool := m.pools[j.Task]
+	prev := j.Status
+	j.Status = StatusQueued
+	m.idx.add(j)
+	j.NextAttemptAt = nil
+	j.UpdatedAt = time.Now()
+	m.mu.Unlock()
+
+	if pool == nil {
+		return
+	}
+	if err := pool.Queue.Push(j); err != nil {
+		next := time.Now().Add(time.Second)
+		m.mu.Lock()
+		if j.Status == StatusQueued {
+			j.Status = prev
+			m.idx.add(j)
+			if prev == StatusRetrying {
+				j.NextAttemptAt = &next
+			}
+		}
+		m.mu.Unlock()
+		m.sched.add(jobID, next)
+		return
+	}
+	m.persist(jobID)
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestManager_RetryIOError prueba que un error de IO se reintente hasta tener éxito
+func TestManager_RetryIOError(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	var calls int32
+	manager.Register("flaky", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			_, err := os.Open("/no/existe/archivo.txt")
+			return nil, err
+		}
+		return "ok", nil
+	}, 1, 4, 1*time.Second, WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   10 * time.Millisecond,
+		RetryOn:     []ErrorClass{ClassIO},
+	}))
+	defer manager.Close()
+
+	jobID, _, _ := manager.Submit("flaky", url.Values{}, PrioNormal)
+	job := waitStatus(t, manager, jobID, StatusDone)
+
+	if job.Attempt != 3 {
+		t.Errorf("Attempt = %d; se esperaba 3", job.Attempt)
+	}
+	if len(job.Errors) != 2 || job.Errors[0].Class != ClassIO {
+		t.Errorf("Errors = %+v; se esperaban 2 errores de clase io", job.Errors)
+	}
+}
+
+// TestManager_RetryNotRetryable prueba que un error fuera de RetryOn falle en el primer intento
+func TestManager_RetryNotRetryable(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("bad", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		return nil, errors.New("parámetros inválidos")
+	}, 1, 4, 1*time.Second, WithRetry(RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   10 * time.Millisecond,
+		RetryOn:     []ErrorClass{ClassIO, ClassTimeout},
+	}))
+	defer manager.Close()
+
+	jobID, _, _ := manager.Submit("bad", url.Values{}, PrioNormal)
+	job := waitStatus(t, manager, jobID, StatusError)
+	if job.Attempt != 1 || len(job.Errors) != 1 || job.Errors[0].Class != ClassTask {
+		t.Errorf("Attempt = %d, Errors = %+v; se esperaba un único intento de clase task", job.Attempt, job.Errors)
+	}
+}
+
+// TestManager_RetryingStatus prueba que un job en espera muestre "retrying" y su próximo intento
+func TestManager_RetryingStatus(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("slowretry", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, 1, 4, 20*time.Millisecond, WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute}))
+	defer manager.Close()
+
+	jobID, _, _ := manager.Submit("slowretry", url.Values{}, PrioNormal)
+	job := waitStatus(t, manager, jobID, StatusRetrying)
+	if job.NextAttemptAt == nil || job.NextAttemptAt.Before(time.Now()) {
+		t.Errorf("NextAttemptAt = %v; se esperaba una fecha futura", job.NextAttemptAt)
+	}
+	if job.Errors[0].Class != ClassTimeout {
+		t.Errorf("Clase del error = %s; se esperaba %s", job.Errors[0].Class, ClassTimeout)
+	}
+
+	if status, err := manager.Cancel(jobID); err != nil || status != StatusCanceled {
+		t.Errorf("Cancel(retrying) = %s, %v; se esperaba canceled", status, err)
+	}
+}
+
+// TestRetryPolicy_Backoff prueba el crecimiento exponencial, el tope y el jitter
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	expect := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, want := range expect {
+		if got := p.backoff(i + 1); got != want {
+			t.Errorf("backoff(%d) = %v; se esperaba %v", i+1, got, want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		if d := p.backoff(2); d < 100*time.Millisecond || d > 200*time.Millisecond {
+			t.Fatalf("backoff con jitter = %v; se esperaba entre 100ms y 200ms", d)
+		}
+	}
+}
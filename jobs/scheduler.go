@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// scheduler mantiene los jobs con ejecución diferida (status "scheduled" o
+// "retrying") en un heap ordenado por hora de vencimiento. Una única
+// goroutine duerme hasta el próximo vencimiento y entrega los ids vencidos
+// a fire, que los pasa a la cola de su pool.
+//
+// El heap puede contener ids obsoletos (jobs cancelados o ya despachados):
+// fire revisa el estado del job y los descarta.
+type scheduler struct {
+	mu    sync.Mutex
+	items schedHeap
+	wake  chan struct{}
+	stop  chan struct{}
+	fire  func(jobID string)
+}
+
+type schedItem struct {
+	at    time.Time
+	jobID string
+}
+
+type schedHeap []schedItem
+
+func (h schedHeap) Len() int           { return len(h) }
+func (h schedHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h schedHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *schedHeap) Push(x any)        { *h = append(*h, x.(schedItem)) }
+func (h *schedHeap) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
+
+func newScheduler(fire func(jobID string)) *scheduler {
+	return &scheduler{
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		fire: fire,
+	}
+}
+
+// add programa jobID para el instante at (en el pasado = lo antes posible).
+func (s *scheduler) add(jobID string, at time.Time) {
+	s.mu.Lock()
+	heap.Push(&s.items, schedItem{at: at, jobID: jobID})
+	first := s.items[0].jobID == jobID
+	s.mu.Unlock()
+
+	// Solo hace falta despertar el loop si cambió el próximo vencimiento
+	if first {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Len devuelve la cantidad de entradas pendientes (incluye obsoletas).
+func (s *scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// due saca del heap las entradas vencidas y devuelve la espera hasta la
+// siguiente (-1 si el heap quedó vacío).
+func (s *scheduler) due(now time.Time) ([]string, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for len(s.items) > 0 && !s.items[0].at.After(now) {
+		ids = append(ids, heap.Pop(&s.items).(schedItem).jobID)
+	}
+	if len(s.items) == 0 {
+		return ids, -1
+	}
+	return ids, s.items[0].at.Sub(now)
+}
+
+func (s *scheduler) run() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		ids, wait := s.due(time.Now())
+		for _, id := range ids {
+			s.fire(id)
+		}
+
+		var tc <-chan time.Time
+		if wait >= 0 {
+			timer.Reset(wait)
+			tc = timer.C
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-tc:
+		}
+	}
+}
+
+func (s *scheduler) Stop() {
+	close(s.stop)
+}
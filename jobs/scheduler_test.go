@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestScheduler_Order prueba que los ids se entreguen por hora de vencimiento
+func TestScheduler_Order(t *testing.T) {
+	var mu sync.Mutex
+	var fired []string
+	done := make(chan struct{})
+	s := newScheduler(func(id string) {
+		mu.Lock()
+		fired = append(fired, id)
+		if len(fired) == 3 {
+			close(done)
+		}
+		mu.Unlock()
+	})
+	go s.run()
+	defer s.Stop()
+
+	now := time.Now()
+	s.add("c", now.Add(60*time.Millisecond))
+	s.add("a", now.Add(-time.Second)) // vencido: se entrega de inmediato
+	s.add("b", now.Add(30*time.Millisecond))
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("el planificador no entregó todos los ids: %v", fired)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fired[0] != "a" || fired[1] != "b" || fired[2] != "c" {
+		t.Errorf("orden = %v; se esperaba [a b c]", fired)
+	}
+}
+
+func quickTask(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+	return "ok", nil
+}
+
+// TestManager_SubmitDelayed prueba que un job diferido espere su hora y luego se ejecute
+func TestManager_SubmitDelayed(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("quick", quickTask, 1, 4, 1*time.Second)
+	defer manager.Close()
+
+	runAt := time.Now().Add(150 * time.Millisecond)
+	jobID, status, err := manager.SubmitJob(SubmitRequest{Task: "quick", Priority: PrioNormal, RunAt: runAt})
+	if err != nil || status != StatusScheduled {
+		t.Fatalf("SubmitJob = %s, %v; se esperaba scheduled", status, err)
+	}
+	if job, _ := manager.GetStatus(jobID); job.Status != StatusScheduled || job.RunAt == nil {
+		t.Errorf("job = %+v; se esperaba scheduled con run_at", job)
+	}
+
+	waitStatus(t, manager, jobID, StatusDone)
+	if time.Now().Before(runAt) {
+		t.Errorf("el job se ejecutó antes de run_at")
+	}
+
+	// run_at en el pasado: se encola de inmediato
+	_, status, _ = manager.SubmitJob(SubmitRequest{Task: "quick", RunAt: time.Now().Add(-time.Hour)})
+	if status != StatusQueued {
+		t.Errorf("SubmitJob(run_at pasado) status = %s; se esperaba queued", status)
+	}
+
+	if _, _, err := manager.SubmitJob(SubmitRequest{Task: "nope", RunAt: runAt}); err != ErrTaskNotFound {
+		t.Errorf("SubmitJob(tarea inexistente) = %v; se esperaba ErrTaskNotFound", err)
+	}
+}
+
+// TestManager_CancelScheduled prueba que un job diferido cancelado no se ejecute
+func TestManager_CancelScheduled(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("quick", quickTask, 1, 4, 1*time.Second)
+	defer manager.Close()
+
+	jobID, _, _ := manager.SubmitJob(SubmitRequest{Task: "quick", RunAt: time.Now().Add(50 * time.Millisecond)})
+	if status, err := manager.Cancel(jobID); err != nil || status != StatusCanceled {
+		t.Fatalf("Cancel(scheduled) = %s, %v; se esperaba canceled", status, err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if job, _ := manager.GetStatus(jobID); job.Status != StatusCanceled {
+		t.Errorf("status = %s; se esperaba canceled", job.Status)
+	}
+}
+
+// TestManager_ScheduledSurvivesRestart prueba que los jobs diferidos se
+// reprogramen desde el archivo de persistencia
+func TestManager_ScheduledSurvivesRestart(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+
+	manager := NewManager(file, 1*time.Minute, 1*time.Minute)
+	manager.Register("quick", quickTask, 1, 4, 1*time.Second)
+	jobID, _, _ := manager.SubmitJob(SubmitRequest{Task: "quick", RunAt: time.Now().Add(100 * time.Millisecond)})
+	manager.Close()
+
+	reloaded := NewManager(file, 1*time.Minute, 1*time.Minute)
+	defer reloaded.Close()
+	if job, err := reloaded.GetStatus(jobID); err != nil || job.Status != StatusScheduled {
+		t.Fatalf("job recargado = %+v, %v; se esperaba scheduled", job, err)
+	}
+	reloaded.Register("quick", quickTask, 1, 4, 1*time.Second)
+	waitStatus(t, reloaded, jobID, StatusDone)
+}
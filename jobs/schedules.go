@@ -0,0 +1,362 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("schedule no encontrado")
+	ErrInvalidOverlap   = errors.New("política de solapamiento inválida (use skip, queue o cancel_previous)")
+)
+
+// OverlapPolicy decide qué hacer cuando vence un schedule y el job que
+// lanzó la vez anterior todavía no terminó.
+type OverlapPolicy string
+
+const (
+	OverlapSkip           OverlapPolicy = "skip"            // no lanzar esta ejecución
+	OverlapQueue          OverlapPolicy = "queue"           // lanzar igual; espera en la cola
+	OverlapCancelPrevious OverlapPolicy = "cancel_previous" // cancelar el anterior y lanzar
+)
+
+// Cantidad de ejecuciones que se conservan en el historial de cada schedule.
+const scheduleHistoryMax = 50
+
+// ScheduleRun es una entrada del historial de un schedule.
+type ScheduleRun struct {
+	At      time.Time `json:"at"`
+	JobID   string    `json:"job_id,omitempty"`
+	Skipped bool      `json:"skipped,omitempty"` // omitido por la política skip
+	Error   string    `json:"error,omitempty"`   // el envío falló (p.ej. backpressure)
+}
+
+// Schedule lanza un job de Task con Params cada vez que se cumple Cron.
+type Schedule struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name,omitempty"`
+	Cron      string            `json:"cron"`
+	Task      string            `json:"task"`
+	Params    map[string]string `json:"params"`
+	Priority  JobPriority       `json:"priority"`
+	Overlap   OverlapPolicy     `json:"overlap"`
+	Paused    bool              `json:"paused"`
+	NextRun   time.Time         `json:"next_run"`
+	LastRun   *time.Time        `json:"last_run,omitempty"`
+	History   []ScheduleRun     `json:"history"`
+	CreatedAt time.Time         `json:"created_at"`
+
+	expr *CronExpr
+}
+
+// ScheduleSpec son los datos para crear un schedule.
+type ScheduleSpec struct {
+	Name     string
+	Cron     string
+	Task     string
+	Params   map[string]string
+	Priority JobPriority
+	Overlap  OverlapPolicy // vacío = skip
+}
+
+// ScheduleUpdate modifica un schedule; los campos nil no cambian y Params
+// se combina con los parámetros actuales (un valor vacío borra la clave).
+type ScheduleUpdate struct {
+	Cron    *string
+	Overlap *OverlapPolicy
+	Paused  *bool
+	Params  map[string]string
+}
+
+func validOverlap(p OverlapPolicy) bool {
+	switch p {
+	case OverlapSkip, OverlapQueue, OverlapCancelPrevious:
+		return true
+	}
+	return false
+}
+
+// schedulesFileFor deriva el archivo de schedules del archivo de jobs:
+// jobs_data.json -> jobs_data_schedules.json.
+func schedulesFileFor(file string) string {
+	if file == "" {
+		return ""
+	}
+	return strings.TrimSuffix(file, ".json") + "_schedules.json"
+}
+
+// cronTable guarda los schedules y su archivo de persistencia.
+type cronTable struct {
+	mu    sync.Mutex
+	file  string
+	items map[string]*Schedule
+}
+
+func newCronTable(file string) *cronTable {
+	t := &cronTable{file: file, items: make(map[string]*Schedule)}
+	if file == "" {
+		return t
+	}
+	if data, err := os.ReadFile(file); err == nil && len(data) > 0 {
+		if err := json.Unmarshal(data, &t.items); err != nil {
+			fmt.Printf("[Cron] No se pudo leer %s: %v\n", file, err)
+		}
+	}
+	for id, s := range t.items {
+		expr, err := ParseCron(s.Cron)
+		if err != nil {
+			fmt.Printf("[Cron] schedule %s descartado: %v\n", id, err)
+			delete(t.items, id)
+			continue
+		}
+		s.expr = expr
+	}
+	return t
+}
+
+// saveLocked reescribe el archivo de schedules. Requiere t.mu tomado.
+func (t *cronTable) saveLocked() {
+	if t.file == "" {
+		return
+	}
+	data, err := json.MarshalIndent(t.items, "", "  ")
+	if err == nil {
+		err = os.WriteFile(t.file, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("[Cron] Error persistiendo %s: %v\n", t.file, err)
+	}
+}
+
+func (s *Schedule) snapshot() Schedule {
+	cp := *s
+	cp.Params = make(map[string]string, len(s.Params))
+	for k, v := range s.Params {
+		cp.Params[k] = v
+	}
+	cp.History = append([]ScheduleRun(nil), s.History...)
+	return cp
+}
+
+// -----------------------------------------------------------------------------
+// API del Manager
+// -----------------------------------------------------------------------------
+
+// armSchedules entrega al planificador los schedules de una tarea recién
+// registrada. Un schedule vencido mientras el servidor estaba caído se
+// ejecuta una sola vez al arrancar.
+func (m *Manager) armSchedules(task string) {
+	m.cron.mu.Lock()
+	defer m.cron.mu.Unlock()
+	for id, s := range m.cron.items {
+		if s.Task == task && !s.Paused {
+			m.cronSched.add(id, s.NextRun)
+		}
+	}
+}
+
+// CreateSchedule valida y registra un schedule nuevo.
+func (m *Manager) CreateSchedule(spec ScheduleSpec) (*Schedule, error) {
+	expr, err := ParseCron(spec.Cron)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Overlap == "" {
+		spec.Overlap = OverlapSkip
+	}
+	if !validOverlap(spec.Overlap) {
+		return nil, ErrInvalidOverlap
+	}
+	m.mu.RLock()
+	_, ok := m.tasks[spec.Task]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	now := time.Now()
+	s := &Schedule{
+		ID:        "sch-" + genID(),
+		Name:      spec.Name,
+		Cron:      expr.String(),
+		Task:      spec.Task,
+		Params:    spec.Params,
+		Priority:  spec.Priority,
+		Overlap:   spec.Overlap,
+		NextRun:   expr.Next(now),
+		History:   []ScheduleRun{},
+		CreatedAt: now,
+		expr:      expr,
+	}
+	if s.Params == nil {
+		s.Params = map[string]string{}
+	}
+
+	m.cron.mu.Lock()
+	m.cron.items[s.ID] = s
+	m.cron.saveLocked()
+	cp := s.snapshot()
+	m.cron.mu.Unlock()
+
+	m.cronSched.add(s.ID, s.NextRun)
+	fmt.Printf("[Cron] schedule %s creado: %s %q (próxima: %s)\n", s.ID, s.Task, s.Cron, s.NextRun.Format(time.RFC3339))
+	return &cp, nil
+}
+
+// Schedules lista los schedules ordenados por próxima ejecución.
+func (m *Manager) Schedules() []Schedule {
+	m.cron.mu.Lock()
+	defer m.cron.mu.Unlock()
+	out := make([]Schedule, 0, len(m.cron.items))
+	for _, s := range m.cron.items {
+		out = append(out, s.snapshot())
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].NextRun.Before(out[b].NextRun) })
+	return out
+}
+
+func (m *Manager) GetSchedule(id string) (*Schedule, error) {
+	m.cron.mu.Lock()
+	defer m.cron.mu.Unlock()
+	s, ok := m.cron.items[id]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+	cp := s.snapshot()
+	return &cp, nil
+}
+
+// UpdateSchedule aplica los cambios y recalcula la próxima ejecución.
+func (m *Manager) UpdateSchedule(id string, u ScheduleUpdate) (*Schedule, error) {
+	var expr *CronExpr
+	if u.Cron != nil {
+		var err error
+		if expr, err = ParseCron(*u.Cron); err != nil {
+			return nil, err
+		}
+	}
+	if u.Overlap != nil && !validOverlap(*u.Overlap) {
+		return nil, ErrInvalidOverlap
+	}
+
+	m.cron.mu.Lock()
+	s, ok := m.cron.items[id]
+	if !ok {
+		m.cron.mu.Unlock()
+		return nil, ErrScheduleNotFound
+	}
+	if expr != nil {
+		s.expr = expr
+		s.Cron = expr.String()
+	}
+	if u.Overlap != nil {
+		s.Overlap = *u.Overlap
+	}
+	if u.Paused != nil {
+		s.Paused = *u.Paused
+	}
+	for k, v := range u.Params {
+		if v == "" {
+			delete(s.Params, k)
+		} else {
+			s.Params[k] = v
+		}
+	}
+	s.NextRun = s.expr.Next(time.Now())
+	m.cron.saveLocked()
+	cp := s.snapshot()
+	m.cron.mu.Unlock()
+
+	// La entrada anterior del planificador queda obsoleta y se descarta al vencer
+	if !cp.Paused {
+		m.cronSched.add(id, cp.NextRun)
+	}
+	return &cp, nil
+}
+
+func (m *Manager) DeleteSchedule(id string) error {
+	m.cron.mu.Lock()
+	defer m.cron.mu.Unlock()
+	if _, ok := m.cron.items[id]; !ok {
+		return ErrScheduleNotFound
+	}
+	delete(m.cron.items, id)
+	m.cron.saveLocked()
+	return nil
+}
+
+// fireSchedule lo invoca el planificador cuando vence un schedule: aplica
+// la política de solapamiento, lanza el job y programa la próxima ejecución.
+func (m *Manager) fireSchedule(id string) {
+	if m.ShuttingDown() {
+		return
+	}
+	now := time.Now()
+
+	m.cron.mu.Lock()
+	s, ok := m.cron.items[id]
+	// Entradas obsoletas: schedule borrado, pausado o reprogramado
+	if !ok || s.Paused || s.NextRun.After(now) {
+		m.cron.mu.Unlock()
+		return
+	}
+	task, prio, overlap := s.Task, s.Priority, s.Overlap
+	params := url.Values{}
+	for k, v := range s.Params {
+		params.Set(k, v)
+	}
+	prev := ""
+	for i := len(s.History) - 1; i >= 0; i-- {
+		if s.History[i].JobID != "" {
+			prev = s.History[i].JobID
+			break
+		}
+	}
+	s.NextRun = s.expr.Next(now)
+	next := s.NextRun
+	m.cron.mu.Unlock()
+
+	run := ScheduleRun{At: now}
+	prevActive := false
+	if prev != "" {
+		if j, err := m.GetStatus(prev); err == nil && !j.Status.Terminal() {
+			prevActive = true
+		}
+	}
+
+	switch {
+	case prevActive && overlap == OverlapSkip:
+		run.Skipped = true
+		fmt.Printf("[Cron] schedule %s: job %s sigue activo, se omite esta ejecución\n", id, prev)
+	default:
+		if prevActive && overlap == OverlapCancelPrevious {
+			m.Cancel(prev)
+		}
+		jobID, _, err := m.SubmitJob(SubmitRequest{Task: task, Params: params, Priority: prio})
+		if err != nil {
+			run.Error = err.Error()
+			fmt.Printf("[Cron] schedule %s: error lanzando %s: %v\n", id, task, err)
+		}
+		run.JobID = jobID
+	}
+
+	m.cron.mu.Lock()
+	if s, ok := m.cron.items[id]; ok {
+		s.LastRun = &now
+		s.History = append(s.History, run)
+		if len(s.History) > scheduleHistoryMax {
+			s.History = s.History[len(s.History)-scheduleHistoryMax:]
+		}
+		m.cron.saveLocked()
+	}
+	m.cron.mu.Unlock()
+
+	m.cronSched.add(id, next)
+}
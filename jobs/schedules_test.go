@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// forceDue deja el schedule vencido y lo dispara como lo haría el planificador.
+func forceDue(m *Manager, id string) {
+	m.cron.mu.Lock()
+	m.cron.items[id].NextRun = time.Now().Add(-time.Second)
+	m.cron.mu.Unlock()
+	m.fireSchedule(id)
+}
+
+func lastRun(t *testing.T, m *Manager, id string) ScheduleRun {
+	t.Helper()
+	s, err := m.GetSchedule(id)
+	if err != nil || len(s.History) == 0 {
+		t.Fatalf("GetSchedule(%s) = %+v, %v; se esperaba historial", id, s, err)
+	}
+	return s.History[len(s.History)-1]
+}
+
+// TestManager_ScheduleOverlap prueba las tres políticas de solapamiento
+func TestManager_ScheduleOverlap(t *testing.T) {
+	manager := NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("block", blockingTask, 2, 8, 10*time.Second)
+	defer manager.Close()
+
+	for _, policy := range []OverlapPolicy{OverlapSkip, OverlapQueue, OverlapCancelPrevious} {
+		s, err := manager.CreateSchedule(ScheduleSpec{Cron: "0 0 1 1 *", Task: "block", Overlap: policy})
+		if err != nil {
+			t.Fatalf("CreateSchedule(%s): %v", policy, err)
+		}
+		forceDue(manager, s.ID)
+		first := lastRun(t, manager, s.ID).JobID
+		waitStatus(t, manager, first, StatusRunning)
+
+		forceDue(manager, s.ID)
+		second := lastRun(t, manager, s.ID)
+		switch policy {
+		case OverlapSkip:
+			if !second.Skipped || second.JobID != "" {
+				t.Errorf("skip: ejecución = %+v; se esperaba omitida", second)
+			}
+		case OverlapQueue:
+			if second.JobID == "" || second.JobID == first {
+				t.Errorf("queue: ejecución = %+v; se esperaba un job nuevo", second)
+			}
+			manager.Cancel(second.JobID)
+		case OverlapCancelPrevious:
+			if second.JobID == "" {
+				t.Errorf("cancel_previous: ejecución = %+v; se esperaba un job nuevo", second)
+			}
+			waitStatus(t, manager, first, StatusCanceled)
+			manager.Cancel(second.JobID)
+		}
+		manager.Cancel(first)
+
+		if got, _ := manager.GetSchedule(s.ID); !got.NextRun.After(time.Now()) {
+			t.Errorf("NextRun = %v; se esperaba una fecha futura", got.NextRun)
+		}
+	}
+}
+
+// TestManager_ScheduleCRUD prueba validaciones, actualización y persistencia
+func TestManager_ScheduleCRUD(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	manager := NewManager(file, 1*time.Minute, 1*time.Minute)
+	manager.Register("quick", quickTask, 1, 4, 1*time.Second)
+
+	if _, err := manager.CreateSchedule(ScheduleSpec{Cron: "* * * * *", Task: "nope"}); err != ErrTaskNotFound {
+		t.Errorf("CreateSchedule(tarea inexistente) = %v; se esperaba ErrTaskNotFound", err)
+	}
+	if _, err := manager.CreateSchedule(ScheduleSpec{Cron: "* * * * *", Task: "quick", Overlap: "nunca"}); err != ErrInvalidOverlap {
+		t.Errorf("CreateSchedule(overlap inválido) = %v; se esperaba ErrInvalidOverlap", err)
+	}
+
+	s, err := manager.CreateSchedule(ScheduleSpec{
+		Name: "reporte", Cron: "0 2 * * *", Task: "quick", Params: map[string]string{"file": "a.txt"},
+	})
+	if err != nil {
+		t.Fatalf("CreateSchedule: %v", err)
+	}
+	if s.Overlap != OverlapSkip || s.NextRun.Hour() != 2 {
+		t.Errorf("schedule = %+v; se esperaba overlap skip y próxima ejecución a las 02:00", s)
+	}
+
+	cron, paused := "30 3 * * *", true
+	s, err = manager.UpdateSchedule(s.ID, ScheduleUpdate{Cron: &cron, Paused: &paused, Params: map[string]string{"file": "b.txt"}})
+	if err != nil || s.Cron != cron || !s.Paused || s.Params["file"] != "b.txt" {
+		t.Errorf("UpdateSchedule = %+v, %v; se esperaban los cambios aplicados", s, err)
+	}
+	manager.Close()
+
+	reloaded := NewManager(file, 1*time.Minute, 1*time.Minute)
+	defer reloaded.Close()
+	got, err := reloaded.GetSchedule(s.ID)
+	if err != nil || got.Cron != cron || got.Name != "reporte" {
+		t.Fatalf("schedule recargado = %+v, %v", got, err)
+	}
+	if err := reloaded.DeleteSchedule(s.ID); err != nil {
+		t.Errorf("DeleteSchedule: %v", err)
+	}
+	if _, err := reloaded.GetSchedule(s.ID); err != ErrScheduleNotFound {
+		t.Errorf("GetSchedule tras borrar = %v; se esperaba ErrScheduleNotFound", err)
+	}
+}
@@ -0,0 +1,423 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Almacenamiento de jobs. El Store es la persistencia del Manager, no su
+// estado vigente: el Manager trabaja sobre su mapa en memoria (las colas y
+// los workers comparten esos *Job bajo m.mu), vuelca cada cambio al Store
+// (persist, escritura completa del job) y al arrancar carga el estado desde
+// él con List. Las lecturas del Manager (GetStatus, ListJobs) salen del
+// mapa, así que el Store no ofrece más que eso. Hay tres implementaciones:
+//
+//	MemoryStore: solo memoria, se pierde al reiniciar (tests, -store=memory).
+//	FileStore:   el mapa completo en un archivo JSON, reescrito en cada cambio.
+//	WALStore:    el mismo archivo como snapshot más un log de eventos (wal.go).
+//
+// Los tres comparten el formato del archivo (jobs_data.json), así que
+// cambiar de uno a otro no pierde jobs.
+
+// el formato anterior no guardaba los parámetros: un job sin terminar no
+// puede volver a ejecutarse
+var ErrLegacyUnfinished = errors.New("importado sin parámetros desde jobstore.json: no se puede reanudar")
+
+// StoreOp es el tipo de cambio que se hace durable (ver table.commit y el
+// log de wal.go).
+type StoreOp string
+
+const (
+	OpPut    StoreOp = "put"    // alta o modificación: Job trae el estado completo
+	OpDelete StoreOp = "delete" // el job se eliminó
+)
+
+// Store guarda el estado de los jobs. Put y List copian los jobs (mapas,
+// slices y punteros) salvo Result, que se comparte: quien lo produce lo
+// reemplaza entero y nunca lo modifica en su lugar (ver cloneJob).
+type Store interface {
+	// Put guarda el estado completo del job, sea alta o modificación.
+	Put(j Job) error
+	// Delete elimina el job o devuelve ErrJobNotFound.
+	Delete(id string) error
+	// List devuelve una página de los jobs que cumplen q (ver query.go).
+	List(q JobQuery) (JobPage, error)
+	// Close vuelca lo pendiente; las escrituras posteriores se descartan.
+	Close() error
+}
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*FileStore)(nil)
+	_ Store = (*WALStore)(nil)
+)
+
+// cloneJob copia un job sin compartir mapas, slices ni punteros. Result se
+// reemplaza entero cuando cambia, así que se comparte.
+func cloneJob(j *Job) *Job {
+	cp := *j
+	if j.Params != nil {
+		cp.Params = make(map[string]string, len(j.Params))
+		for k, v := range j.Params {
+			cp.Params[k] = v
+		}
+	}
+	cp.Errors = append([]AttemptError(nil), j.Errors...)
+	cp.Checkpoint = append(json.RawMessage(nil), j.Checkpoint...)
+	if j.RunAt != nil {
+		t := *j.RunAt
+		cp.RunAt = &t
+	}
+	if j.NextAttemptAt != nil {
+		t := *j.NextAttemptAt
+		cp.NextAttemptAt = &t
+	}
+	return &cp
+}
+
+// -----------------------------------------------------------------------------
+// table: el mapa en memoria que comparten las tres implementaciones
+// -----------------------------------------------------------------------------
+
+type table struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+
+	idx jobIndex // índices secundarios para List (ver query.go)
+
+	// commit hace durable un cambio ya aplicado al mapa, con mu tomado. Si
+	// falla, el cambio se deshace. nil = solo memoria.
+	commit func(op StoreOp, id string) error
+}
+
+func (t *table) init(jobs map[string]*Job) {
+	if jobs == nil {
+		jobs = make(map[string]*Job)
+	}
+	t.jobs = jobs
+	t.idx = newJobIndex()
+	for _, j := range jobs {
+		t.idx.add(j)
+	}
+}
+
+// applyLocked guarda j (o lo elimina si es nil).
+func (t *table) applyLocked(id string, j *Job) error {
+	prev, had := t.jobs[id]
+	if had {
+		t.idx.remove(prev)
+	}
+	op := OpPut
+	if j != nil {
+		t.jobs[id] = j
+		t.idx.add(j)
+	} else {
+		op = OpDelete
+		delete(t.jobs, id)
+	}
+	if t.commit != nil {
+		if err := t.commit(op, id); err != nil {
+			if j != nil {
+				t.idx.remove(j)
+			}
+			if had {
+				t.jobs[id] = prev
+				t.idx.add(prev)
+			} else {
+				delete(t.jobs, id)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *table) Put(j Job) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.applyLocked(j.ID, cloneJob(&j))
+}
+
+func (t *table) Delete(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.jobs[id]; !ok {
+		return ErrJobNotFound
+	}
+	return t.applyLocked(id, nil)
+}
+
+// -----------------------------------------------------------------------------
+// MemoryStore
+// -----------------------------------------------------------------------------
+
+// MemoryStore guarda los jobs solo en memoria.
+type MemoryStore struct {
+	table
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{}
+	s.init(nil)
+	return s
+}
+
+func (s *MemoryStore) Close() error { return nil }
+
+// -----------------------------------------------------------------------------
+// FileStore
+// -----------------------------------------------------------------------------
+
+// FileStore guarda el mapa completo en un archivo JSON, reescrito (archivo
+// temporal + rename) en cada cambio. Simple de inspeccionar, pero cada
+// escritura cuesta tanto como el total de jobs: para muchos jobs, WALStore.
+type FileStore struct {
+	table
+	path string
+}
+
+// NewFileStore carga file. Si quedó un log de WALStore junto a él, lo
+// reaplica y lo incorpora al archivo.
+func NewFileStore(file string) *FileStore {
+	s := &FileStore{path: file}
+	s.init(loadJobsFile(file))
+	s.commit = func(StoreOp, string) error { return s.writeLocked() }
+
+	log := walFileFor(file)
+	if info, err := os.Stat(log); err == nil && info.Size() > 0 {
+		if err := s.writeLocked(); err != nil {
+			fmt.Printf("[Store] No se pudo incorporar %s a %s: %v\n", log, file, err)
+		} else {
+			os.Remove(log)
+			fmt.Printf("[Store] log %s incorporado a %s\n", log, file)
+		}
+	}
+	return s
+}
+
+func (s *FileStore) writeLocked() error {
+	data, err := json.MarshalIndent(s.jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// Close deja de escribir el archivo: los cambios posteriores solo quedan en memoria.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commit = nil
+	return nil
+}
+
+// loadJobsFile lee el archivo de jobs y reaplica el log de WALStore si lo
+// hay: el estado completo sea cual sea el Store que lo escribió.
+func loadJobsFile(file string) map[string]*Job {
+	jobs := make(map[string]*Job)
+	if data, err := os.ReadFile(file); err == nil && len(data) > 0 {
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			fmt.Printf("[Store] No se pudo leer %s: %v\n", file, err)
+		}
+	}
+	replayWAL(walFileFor(file), jobs)
+	return jobs
+}
+
+// writeFileAtomic reemplaza path de forma atómica: archivo temporal, fsync
+// y rename.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Migración del store anterior (jobstore.json)
+// -----------------------------------------------------------------------------
+
+// legacyJob es el formato del antiguo FakeJobStore.
+type legacyJob struct {
+	ID       string `json:"job_id"`
+	Task     string `json:"task"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Result   any    `json:"result,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// legacyStoreFileFor ubica jobstore.json junto al archivo de jobs.
+func legacyStoreFileFor(file string) string {
+	return filepath.Join(filepath.Dir(file), "jobstore.json")
+}
+
+// migrateLegacy importa los jobs de un jobstore.json que no estén ya en el
+// Store y lo renombra a jobstore.json.migrated para no repetirlo. Se llama
+// desde NewManager, antes de arrancar nada. Los jobs sin terminar quedan en
+// error: sin sus parámetros, la recuperación los ejecutaría vacíos.
+func (m *Manager) migrateLegacy(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("[Store] No se pudo leer %s: %v\n", path, err)
+		return
+	}
+	var legacy map[string]legacyJob
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		fmt.Printf("[Store] %s no tiene el formato esperado, no se migra: %v\n", path, err)
+		return
+	}
+
+	migrated := 0
+	for key, lj := range legacy {
+		id := lj.ID
+		if id == "" {
+			id = key
+		}
+		if _, ok := m.jobs[id]; ok {
+			continue
+		}
+		j := &Job{
+			ID:        id,
+			Task:      lj.Task,
+			Params:    map[string]string{},
+			Status:    JobStatus(lj.Status),
+			Priority:  PrioNormal,
+			Progress:  lj.Progress,
+			Result:    lj.Result,
+			Error:     lj.Error,
+			CreatedAt: info.ModTime(),
+			UpdatedAt: info.ModTime(),
+		}
+		if !j.Status.Terminal() {
+			j.Status = StatusError
+			j.Error = ErrLegacyUnfinished.Error()
+			j.Progress = 100
+		}
+		if err := m.store.Put(*j); err != nil {
+			fmt.Printf("[Store] No se pudo migrar el job %s: %v\n", id, err)
+			return
+		}
+		m.jobs[id] = j
+		m.idx.add(j)
+		migrated++
+	}
+	if err := os.Rename(path, path+".migrated"); err != nil {
+		fmt.Printf("[Store] No se pudo renombrar %s: %v\n", path, err)
+	}
+	fmt.Printf("[Store] %d jobs migrados desde %s\n", migrated, path)
+}
+
+// -----------------------------------------------------------------------------
+// Uso desde el Manager
+// -----------------------------------------------------------------------------
+
+// WithStore reemplaza el Store por defecto (WALStore sobre el archivo de
+// jobs, o MemoryStore sin archivo).
+func WithStore(s Store) ManagerOption {
+	return func(m *Manager) { m.store = s }
+}
+
+// openStore crea el Store por defecto si no se configuró uno y carga su
+// estado en el mapa del Manager.
+func (m *Manager) openStore() {
+	if m.store == nil {
+		if m.file != "" {
+			m.store = NewWALStore(m.file, m.fsync, m.snapshotEvery)
+		} else {
+			m.store = NewMemoryStore()
+		}
+	}
+	all, _ := m.store.List(JobQuery{})
+	for _, j := range all.Jobs {
+		cp := cloneJob(&j)
+		m.jobs[cp.ID] = cp
+		m.idx.add(cp)
+	}
+	// en un MemoryStore lo migrado se perdería al reiniciar
+	if _, mem := m.store.(*MemoryStore); m.file != "" && !mem {
+		m.migrateLegacy(legacyStoreFileFor(m.file))
+	}
+}
+
+// persist vuelca al Store el estado actual de los jobs indicados (o su
+// eliminación, si ya no están). Los errores quedan registrados para el
+// chequeo de readiness (/readyz). No debe llamarse con m.mu tomado.
+func (m *Manager) persist(ids ...string) {
+	if len(ids) == 0 {
+		return
+	}
+	// storeMu mantiene el orden: el Store recibe los cambios en el mismo
+	// orden en que se leyeron del mapa.
+	m.storeMu.Lock()
+	defer m.storeMu.Unlock()
+
+	states := make([]*Job, len(ids))
+	m.mu.RLock()
+	for i, id := range ids {
+		if j, ok := m.jobs[id]; ok {
+			states[i] = cloneJob(j)
+		}
+	}
+	m.mu.RUnlock()
+
+	var firstErr error
+	for i, id := range ids {
+		var err error
+		if j := states[i]; j == nil {
+			if err = m.store.Delete(id); errors.Is(err, ErrJobNotFound) {
+				err = nil
+			}
+		} else {
+			err = m.store.Put(*j)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	m.recordPersist(firstErr)
+}
+
+// snapshot compacta el Store si lo admite (WALStore) y hubo cambios.
+func (m *Manager) snapshot() {
+	s, ok := m.store.(interface{ Snapshot() error })
+	if !ok {
+		return
+	}
+	if err := s.Snapshot(); err != nil {
+		m.recordPersist(err)
+	}
+}
+
+// closeStore cierra el Store: las escrituras posteriores se descartan.
+func (m *Manager) closeStore() {
+	m.storeMu.Lock()
+	defer m.storeMu.Unlock()
+	if err := m.store.Close(); err != nil {
+		m.recordPersist(err)
+	}
+}
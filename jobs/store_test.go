@@ -0,0 +1,213 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// storedJob busca un job en lo que devuelve List
+func storedJob(s Store, id string) (Job, bool) {
+	all, _ := s.List(JobQuery{})
+	for _, j := range all.Jobs {
+		if j.ID == id {
+			return j, true
+		}
+	}
+	return Job{}, false
+}
+
+// testStore ejercita el contrato de Store común a todas las implementaciones
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	now := time.Now()
+	a := Job{ID: "a", Task: "pi", Status: StatusQueued, Params: map[string]string{"digits": "10"}, CreatedAt: now}
+	b := Job{ID: "b", Task: "sortfile", Status: StatusDone, CreatedAt: now.Add(time.Second)}
+	for _, j := range []Job{b, a} {
+		if err := s.Put(j); err != nil {
+			t.Fatalf("Put(%s): %v", j.ID, err)
+		}
+	}
+
+	// Put y List copian: ni el original ni lo devuelto cambian lo guardado
+	a.Params["digits"] = "99"
+	got, ok := storedJob(s, "a")
+	if !ok || got.Params["digits"] != "10" {
+		t.Fatalf("a = %+v, %v; Put no copió los parámetros", got, ok)
+	}
+	got.Params["digits"] = "98"
+	if again, _ := storedJob(s, "a"); again.Params["digits"] != "10" {
+		t.Errorf("modificar la copia cambió lo guardado")
+	}
+
+	// Put sobre un id existente reemplaza el estado completo
+	got.Status = StatusRunning
+	got.Params["digits"] = "10"
+	if err := s.Put(got); err != nil {
+		t.Errorf("Put(a) como modificación: %v", err)
+	}
+	if j, _ := storedJob(s, "a"); j.Status != StatusRunning {
+		t.Errorf("tras Put: status = %s; se esperaba running", j.Status)
+	}
+
+	if all, _ := s.List(JobQuery{}); len(all.Jobs) != 2 || all.Jobs[0].ID != "a" || all.Jobs[1].ID != "b" {
+		t.Errorf("List() = %v; se esperaba [a b] por fecha de creación", all)
+	}
+	if done, _ := s.List(JobQuery{Status: StatusDone}); len(done.Jobs) != 1 || done.Jobs[0].ID != "b" {
+		t.Errorf("List(done) = %v", done)
+	}
+	if pi, _ := s.List(JobQuery{Task: "pi", Status: StatusDone}); len(pi.Jobs) != 0 {
+		t.Errorf("List(pi, done) = %v; se esperaba vacío", pi)
+	}
+
+	if err := s.Delete("b"); err != nil {
+		t.Errorf("Delete(b): %v", err)
+	}
+	if _, ok := storedJob(s, "b"); ok {
+		t.Errorf("b sigue listado tras Delete")
+	}
+	if err := s.Delete("b"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Delete repetido: err = %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+// TestFileStore prueba el contrato y que el estado sobreviva a reabrir el archivo
+func TestFileStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	s := NewFileStore(file)
+	testStore(t, s)
+	s.Close()
+
+	reopened := NewFileStore(file)
+	if j, ok := storedJob(reopened, "a"); !ok || j.Status != StatusRunning {
+		t.Errorf("tras reabrir: a = %+v, %v", j, ok)
+	}
+	if _, ok := storedJob(reopened, "b"); ok {
+		t.Errorf("tras reabrir: el job eliminado volvió")
+	}
+}
+
+// TestWALStore prueba el contrato y la recuperación desde el log sin cierre ordenado
+func TestWALStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	s := NewWALStore(file, FsyncAlways, 0)
+	testStore(t, s)
+	s.mu.Lock()
+	s.closeLocked() // caída: sin snapshot final
+	s.mu.Unlock()
+
+	reopened := NewWALStore(file, FsyncAlways, 0)
+	defer reopened.Close()
+	if j, ok := storedJob(reopened, "a"); !ok || j.Status != StatusRunning {
+		t.Errorf("tras reabrir: a = %+v, %v", j, ok)
+	}
+	if all, _ := reopened.List(JobQuery{}); len(all.Jobs) != 1 {
+		t.Errorf("tras reabrir: List() = %v; se esperaba solo a", all)
+	}
+}
+
+// TestFileStore_MigratesWAL prueba que pasar de WALStore a FileStore incorpore el log
+func TestFileStore_MigratesWAL(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	w := NewWALStore(file, FsyncAlways, 0)
+	w.Put(Job{ID: "x", Task: "pi", Status: StatusDone})
+	w.mu.Lock()
+	w.closeLocked()
+	w.mu.Unlock()
+
+	s := NewFileStore(file)
+	if _, ok := storedJob(s, "x"); !ok {
+		t.Fatalf("x no está tras incorporar el log")
+	}
+	if _, err := os.Stat(walFileFor(file)); !os.IsNotExist(err) {
+		t.Errorf("el log debería haberse eliminado tras incorporarlo")
+	}
+	if all, _ := NewFileStore(file).List(JobQuery{}); len(all.Jobs) != 1 {
+		t.Errorf("el job del log no quedó en el archivo")
+	}
+}
+
+// TestManager_MigratesLegacyStore prueba la importación de un jobstore.json anterior
+func TestManager_MigratesLegacyStore(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "jobstore.json")
+	os.WriteFile(legacy, []byte(`{
+  "old-1": {"job_id": "old-1", "task": "isprime", "status": "done", "progress": 100, "result": {"n": 97, "is_prime": true}},
+  "old-2": {"job_id": "old-2", "task": "isprime", "status": "error", "progress": 100, "error": "falta parámetro n"}
+}`), 0644)
+
+	file := filepath.Join(dir, "jobs.json")
+	m := NewManager(file, time.Minute, time.Minute)
+	j, err := m.GetStatus("old-1")
+	if err != nil || j.Status != StatusDone || j.Result == nil {
+		t.Fatalf("GetStatus(old-1) = %+v, %v", j, err)
+	}
+	if _, err := os.Stat(legacy + ".migrated"); err != nil {
+		t.Errorf("jobstore.json no se renombró: %v", err)
+	}
+	m.Close()
+
+	reloaded := NewManager(file, time.Minute, time.Minute)
+	defer reloaded.Close()
+	if j, err := reloaded.GetStatus("old-2"); err != nil || j.Error != "falta parámetro n" {
+		t.Errorf("tras reiniciar: GetStatus(old-2) = %+v, %v", j, err)
+	}
+}
+
+// TestManager_MigratesLegacyUnfinished prueba que un job sin terminar del
+// formato anterior no se ejecute sin parámetros al registrar su tarea
+func TestManager_MigratesLegacyUnfinished(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "jobstore.json"), []byte(`{
+  "old-q": {"job_id": "old-q", "task": "quick", "status": "queued", "progress": 0},
+  "old-r": {"job_id": "old-r", "task": "quick", "status": "running", "progress": 40}
+}`), 0644)
+
+	var runs atomic.Int32
+	m := NewManager(filepath.Join(dir, "jobs.json"), time.Minute, time.Minute)
+	defer m.Close()
+	m.Register("quick", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		runs.Add(1)
+		return nil, nil
+	}, 1, 4, time.Second, WithRecovery(RecoverRetry))
+
+	for _, id := range []string{"old-q", "old-r"} {
+		j, err := m.GetStatus(id)
+		if err != nil || j.Status != StatusError || j.Error != ErrLegacyUnfinished.Error() {
+			t.Errorf("GetStatus(%s) = %+v, %v; se esperaba error por importación sin parámetros", id, j, err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := runs.Load(); n != 0 {
+		t.Errorf("la tarea corrió %d veces con jobs importados sin parámetros", n)
+	}
+	if st := m.RecoveryReport()["quick"]; st.Queued != 0 || st.Retried != 0 {
+		t.Errorf("RecoveryReport = %+v; no se esperaba nada recuperado", st)
+	}
+}
+
+// TestManager_WithStore prueba que el Manager persista en el Store configurado
+func TestManager_WithStore(t *testing.T) {
+	s := NewMemoryStore()
+	m := NewManager("", time.Minute, time.Minute, WithStore(s))
+	m.Register("quick", quickTask, 1, 4, time.Second)
+	defer m.Close()
+
+	id, _, err := m.Submit("quick", url.Values{}, PrioNormal)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitStatus(t, m, id, StatusDone)
+	if j, ok := storedJob(s, id); !ok || j.Status != StatusDone || j.Result != "ok" {
+		t.Errorf("Store: %s = %+v, %v; se esperaba done con el resultado", id, j, ok)
+	}
+}
@@ -0,0 +1,367 @@
+package jobs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Persistencia del WALStore: un snapshot (el formato de siempre, el
+// mapa completo en jobs_data.json) más un log de eventos de solo agregado
+// (jobs_data.wal) con cada cambio posterior. Al arrancar se carga el
+// snapshot y se reaplica el log; cada tanto el estado se vuelca a un
+// snapshot nuevo (escrito aparte y renombrado) y el log se vacía.
+//
+// Cada registro del log es:
+//
+//	longitud (uint32 LE) | crc32 del payload (uint32 LE) | payload JSON
+//
+// Un registro incompleto o con crc inválido marca el final del log (una
+// escritura cortada por una caída): se descarta junto con lo que siga.
+
+// FsyncPolicy decide cuándo se fuerza el log a disco.
+type FsyncPolicy string
+
+const (
+	FsyncAlways   FsyncPolicy = "always"   // tras cada escritura: no se pierde nada
+	FsyncInterval FsyncPolicy = "interval" // una vez por walSyncInterval
+	FsyncNever    FsyncPolicy = "never"    // lo decide el sistema operativo
+)
+
+const (
+	walSyncInterval      = time.Second
+	defaultSnapshotEvery = 1000     // registros del log que disparan un snapshot
+	walMaxRecord         = 64 << 20 // un largo mayor solo puede ser basura
+)
+
+type walRecord struct {
+	Op  StoreOp         `json:"op"`
+	ID  string          `json:"id"`
+	Job json.RawMessage `json:"job,omitempty"`
+}
+
+// WALStore es el Store durable por defecto: el estado vive en memoria, cada
+// cambio se agrega al log antes de confirmarse y cada snapshotEvery
+// registros (o con Snapshot) el estado se vuelca a un snapshot nuevo.
+// El lock de la tabla serializa también las escrituras del log.
+type WALStore struct {
+	table
+	path          string // log
+	snapshot      string // jobs_data.json
+	f             *os.File
+	fsync         FsyncPolicy
+	snapshotEvery int
+	records       int  // registros desde el último snapshot
+	dirty         bool // escrito sin fsync (política interval)
+	closed        bool
+	stop          chan struct{}
+}
+
+// walFileFor deriva el log del archivo de jobs: jobs_data.json -> jobs_data.wal.
+func walFileFor(file string) string {
+	return strings.TrimSuffix(file, ".json") + ".wal"
+}
+
+// NewWALStore carga el snapshot file y reaplica su log. Una política de
+// fsync vacía usa FsyncInterval; snapshotEvery <= 0 usa el valor por defecto.
+func NewWALStore(file string, fsync FsyncPolicy, snapshotEvery int) *WALStore {
+	if fsync == "" {
+		fsync = FsyncInterval
+	}
+	if snapshotEvery <= 0 {
+		snapshotEvery = defaultSnapshotEvery
+	}
+	s := &WALStore{
+		path:          walFileFor(file),
+		snapshot:      file,
+		fsync:         fsync,
+		snapshotEvery: snapshotEvery,
+		stop:          make(chan struct{}),
+	}
+	jobs := make(map[string]*Job)
+	if data, err := os.ReadFile(file); err == nil && len(data) > 0 {
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			fmt.Printf("[WAL] No se pudo leer el snapshot %s: %v\n", file, err)
+		}
+	}
+	s.records = replayWAL(s.path, jobs)
+	s.init(jobs)
+	s.commit = s.commitLocked
+	if fsync == FsyncInterval {
+		go s.syncLoop()
+	}
+	return s
+}
+
+// replayWAL reaplica el log sobre jobs y devuelve cuántos registros leyó.
+// Trunca una cola de log inválida.
+func replayWAL(path string, jobs map[string]*Job) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	replayed := 0
+	for {
+		payload, n, err := readWALRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = applyWALRecord(jobs, payload)
+		}
+		if err != nil {
+			// cola cortada o corrupta: se conserva lo anterior
+			info, _ := f.Stat()
+			fmt.Printf("[WAL] registro inválido en %s (offset %d): %v; se descartan %d bytes\n",
+				path, offset, err, info.Size()-offset)
+			if err := os.Truncate(path, offset); err != nil {
+				fmt.Printf("[WAL] No se pudo truncar %s: %v\n", path, err)
+			}
+			break
+		}
+		offset += n
+		replayed++
+	}
+	if replayed > 0 {
+		fmt.Printf("[WAL] %d registros reaplicados desde %s\n", replayed, path)
+	}
+	return replayed
+}
+
+func readWALRecord(r io.Reader) ([]byte, int64, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, 0, errors.New("encabezado incompleto")
+		}
+		return nil, 0, err // io.EOF: fin limpio
+	}
+	size := binary.LittleEndian.Uint32(hdr[0:4])
+	sum := binary.LittleEndian.Uint32(hdr[4:8])
+	if size > walMaxRecord {
+		return nil, 0, fmt.Errorf("largo inválido %d", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, errors.New("registro incompleto")
+	}
+	if crc32.ChecksumIEEE(payload) != sum {
+		return nil, 0, errors.New("crc inválido")
+	}
+	return payload, int64(len(hdr)) + int64(size), nil
+}
+
+func applyWALRecord(jobs map[string]*Job, payload []byte) error {
+	var rec walRecord
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return err
+	}
+	switch rec.Op {
+	case OpPut:
+		var j Job
+		if err := json.Unmarshal(rec.Job, &j); err != nil {
+			return err
+		}
+		jobs[rec.ID] = &j
+	case OpDelete:
+		delete(jobs, rec.ID)
+	default:
+		return fmt.Errorf("operación desconocida %q", rec.Op)
+	}
+	return nil
+}
+
+// commitLocked agrega el cambio al log y, cada snapshotEvery registros,
+// compacta. Un snapshot fallido no deshace el cambio (ya está en el log):
+// se reintenta en el próximo.
+func (s *WALStore) commitLocked(op StoreOp, id string) error {
+	rec := walRecord{Op: op, ID: id}
+	if op == OpPut {
+		data, err := json.Marshal(s.jobs[id])
+		if err != nil {
+			return err
+		}
+		rec.Job = data
+	}
+	if err := s.appendLocked(rec); err != nil {
+		return err
+	}
+	if s.records >= s.snapshotEvery {
+		if err := s.writeSnapshotLocked(); err != nil {
+			fmt.Printf("[WAL] No se pudo escribir el snapshot %s: %v\n", s.snapshot, err)
+		}
+	}
+	return nil
+}
+
+// appendLocked agrega un registro al log. Requiere s.mu tomado.
+func (s *WALStore) appendLocked(rec walRecord) error {
+	if s.closed {
+		return nil
+	}
+	if s.f == nil {
+		f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		s.f = f
+	}
+
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// encabezado y payload en una sola escritura
+	buf := make([]byte, 8, 8+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	buf = append(buf, payload...)
+	if _, err := s.f.Write(buf); err != nil {
+		return err
+	}
+	s.records++
+	switch s.fsync {
+	case FsyncAlways:
+		return s.f.Sync()
+	case FsyncInterval:
+		s.dirty = true
+	}
+	return nil
+}
+
+// writeSnapshotLocked reemplaza el snapshot de forma atómica y vacía el
+// log. Requiere s.mu tomado.
+func (s *WALStore) writeSnapshotLocked() error {
+	data, err := json.MarshalIndent(s.jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.snapshot, data); err != nil {
+		return err
+	}
+
+	// Si el proceso cae antes de vaciar el log, reaplicarlo sobre el
+	// snapshot nuevo da el mismo estado: cada registro es el estado
+	// completo de un job y el último gana.
+	if s.f != nil {
+		if err := s.f.Truncate(0); err != nil {
+			return err
+		}
+		s.dirty = true
+	} else if err := os.Truncate(s.path, 0); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.records = 0
+	return nil
+}
+
+// syncDir fuerza a disco la entrada del rename (ignorado donde no aplica).
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// syncLoop aplica la política interval.
+func (s *WALStore) syncLoop() {
+	t := time.NewTicker(walSyncInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			s.mu.Lock()
+			if s.dirty && s.f != nil {
+				s.f.Sync()
+				s.dirty = false
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Snapshot vuelca el estado completo y vacía el log, si hubo cambios.
+func (s *WALStore) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records == 0 || s.closed {
+		return nil
+	}
+	return s.writeSnapshotLocked()
+}
+
+// Close escribe un último snapshot y cierra el log; las escrituras
+// posteriores se descartan.
+func (s *WALStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	var err error
+	if s.records > 0 {
+		err = s.writeSnapshotLocked()
+	}
+	s.closeLocked()
+	return err
+}
+
+// closeLocked fuerza el log a disco y lo cierra. Requiere s.mu tomado.
+func (s *WALStore) closeLocked() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.stop)
+	if s.f != nil {
+		s.f.Sync()
+		s.f.Close()
+		s.f = nil
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Opciones del Manager
+// -----------------------------------------------------------------------------
+
+// ManagerOption configura aspectos opcionales del Manager:
+//
+//	jobs.NewManager("jobs_data.json", ttl, interval, jobs.WithFsync(jobs.FsyncAlways))
+type ManagerOption func(*Manager)
+
+// WithFsync fija la política de fsync del WALStore por defecto (interval).
+// Una política desconocida se ignora.
+func WithFsync(p FsyncPolicy) ManagerOption {
+	return func(m *Manager) {
+		switch p {
+		case FsyncAlways, FsyncInterval, FsyncNever:
+			m.fsync = p
+		default:
+			fmt.Printf("[WAL] política de fsync desconocida %q, se usa %s\n", p, FsyncInterval)
+		}
+	}
+}
+
+// WithSnapshotEvery fija cada cuántos registros del log el WALStore por
+// defecto escribe un snapshot. Además se escribe uno en cada ciclo de
+// limpieza y al cerrar.
+func WithSnapshotEvery(n int) ManagerOption {
+	return func(m *Manager) {
+		if n > 0 {
+			m.snapshotEvery = n
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestWAL_ReplayAfterCrash prueba que el estado se recupere del log sin un cierre ordenado
+func TestWAL_ReplayAfterCrash(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	m := NewManager(file, 1*time.Minute, 1*time.Minute, WithFsync(FsyncAlways))
+	m.Register("quick", quickTask, 1, 10, time.Second)
+	defer m.Close()
+
+	id, _, err := m.Submit("quick", url.Values{"n": {"7"}}, PrioNormal)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitStatus(t, m, id, StatusDone)
+	if _, err := os.Stat(file); err == nil {
+		t.Errorf("no debería haber snapshot antes de compactar")
+	}
+
+	// Otro proceso leyendo el mismo archivo ve lo que el primero dejó en el log
+	jobs := loadJobsFile(file)
+	if j, ok := jobs[id]; !ok || j.Status != StatusDone {
+		t.Fatalf("job reaplicado = %+v; se esperaba %s done", j, id)
+	}
+}
+
+// TestWAL_TornTail prueba que un registro cortado al final se descarte
+func TestWAL_TornTail(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	m := NewManager(file, 1*time.Minute, 1*time.Minute, WithFsync(FsyncAlways))
+	m.Register("quick", quickTask, 1, 10, time.Second)
+	id, _, _ := m.Submit("quick", url.Values{"n": {"7"}}, PrioNormal)
+	waitStatus(t, m, id, StatusDone)
+	w := m.store.(*WALStore)
+	w.mu.Lock()
+	w.closeLocked() // caída: sin snapshot final
+	w.mu.Unlock()
+	m.Close()
+
+	walPath := walFileFor(file)
+	before, _ := os.Stat(walPath)
+	f, _ := os.OpenFile(walPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write([]byte{40, 0, 0, 0, 1, 2, 3, 4, '{', '"'}) // encabezado de 40 bytes y 2 de payload
+	f.Close()
+
+	jobs := loadJobsFile(file)
+	if j, ok := jobs[id]; !ok || j.Status != StatusDone {
+		t.Errorf("job = %+v; se esperaba recuperarlo pese a la cola cortada", j)
+	}
+	if after, _ := os.Stat(walPath); after.Size() != before.Size() {
+		t.Errorf("tamaño del log = %d; se esperaba truncado a %d", after.Size(), before.Size())
+	}
+}
+
+// TestWAL_SnapshotCompaction prueba que el snapshot vacíe el log y conserve el estado
+func TestWAL_SnapshotCompaction(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	m := NewManager(file, 1*time.Minute, 1*time.Minute, WithSnapshotEvery(3))
+	m.Register("quick", quickTask, 1, 10, time.Second)
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		id, _, _ := m.Submit("quick", url.Values{"n": {"1"}}, PrioNormal)
+		waitStatus(t, m, id, StatusDone)
+		ids = append(ids, id)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("se esperaba un snapshot tras 3 registros: %v", err)
+	}
+	if _, err := os.Stat(file + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("quedó el archivo temporal del snapshot")
+	}
+
+	// Un job eliminado no vuelve al reiniciar
+	m.mu.Lock()
+	delete(m.jobs, ids[0])
+	m.mu.Unlock()
+	m.persist(ids[0])
+	m.Close()
+
+	if info, _ := os.Stat(walFileFor(file)); info.Size() != 0 {
+		t.Errorf("el log debería quedar vacío tras el snapshot de cierre (%d bytes)", info.Size())
+	}
+	reloaded := NewManager(file, 1*time.Minute, 1*time.Minute)
+	defer reloaded.Close()
+	for i, id := range ids {
+		_, err := reloaded.GetStatus(id)
+		if (i == 0) != (err != nil) {
+			t.Errorf("job %d tras reiniciar: err = %v", i, err)
+		}
+	}
+}
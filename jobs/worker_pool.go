@@ -2,6 +2,8 @@ package jobs
 
 import (
 	"fmt"
+	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -10,94 +12,259 @@ import (
 // que procesan trabajos (*Job) para una tarea específica
 // (por ejemplo, "isprime" o "sortfile").
 type WorkerPool struct {
-	Name     string      // nombre de la tarea (por ejemplo, "isprime")
-	Queue    chan *Job   // cola de trabajos pendientes
-	Workers  int         // número total de workers
-	Active   int64       // número actual de workers ocupados
-	Manager  *Manager    // referencia al manager principal
-	StopChan chan struct{} // canal para detener el pool
-	TotalJobs  int64
-    TotalTime  int64 // en nanosegundos
+	Name      string         // nombre de la tarea (por ejemplo, "isprime")
+	Queue     *PriorityQueue // cola de trabajos pendientes (por prioridad)
+	Active    int64          // número actual de workers ocupados
+	Manager   *Manager       // referencia al manager principal
+	StopChan  chan struct{}  // canal para detener el pool
+	TotalJobs int64
+	TotalTime int64 // en nanosegundos
+
+	mu      sync.Mutex
+	initial int                 // workers con los que arranca
+	workers map[int]*poolWorker // workers vivos
+	nextID  int
+	running map[int]string // worker -> job en ejecución
+	wg      sync.WaitGroup // workers vivos, para Wait
+
+	scaler *autoscaler // nil = tamaño fijo (ver autoscale.go)
+	budget *cpuBudget  // nil = tarea de clase io (ver budget.go)
+	procs  *procPool   // nil = los jobs corren en goroutines (ver process.go)
+}
+
+// poolWorker es un worker vivo: quit lo retira al terminar su job actual.
+type poolWorker struct {
+	quit      chan struct{}
+	idleSince time.Time
 }
 
 // NewWorkerPool crea una nueva instancia del pool
-func NewWorkerPool(name string, workers int, queue chan *Job, manager *Manager) *WorkerPool {
+func NewWorkerPool(name string, workers int, queue *PriorityQueue, manager *Manager) *WorkerPool {
 	return &WorkerPool{
 		Name:     name,
 		Queue:    queue,
-		Workers:  workers,
 		Active:   0,
 		Manager:  manager,
 		StopChan: make(chan struct{}),
+		initial:  workers,
+		workers:  make(map[int]*poolWorker),
+		running:  make(map[int]string),
 	}
 }
 
 // Start inicia todos los workers del pool.
 // Cada worker escucha trabajos en su cola asociada y los procesa usando Manager.runJob().
 func (p *WorkerPool) Start() {
-	for i := 0; i < p.Workers; i++ {
-		go p.worker(i)
+	p.mu.Lock()
+	for i := 0; i < p.initial; i++ {
+		p.addWorkerLocked()
 	}
-	fmt.Printf("[WorkerPool:%s] iniciado con %d workers\n", p.Name, p.Workers)
+	p.mu.Unlock()
+	if p.scaler != nil {
+		go p.scaler.run(p)
+	}
+	fmt.Printf("[WorkerPool:%s] iniciado con %d workers\n", p.Name, p.initial)
+}
+
+// Size es la cantidad de workers vivos.
+func (p *WorkerPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.workers)
 }
 
-// worker ejecuta trabajos tomados del canal Queue.
-// Si se cierra el canal StopChan, el worker termina su ejecución.
-func (p *WorkerPool) worker(id int) {
-	start := time.Now()
+// addWorkerLocked lanza un worker nuevo. Requiere p.mu tomado.
+func (p *WorkerPool) addWorkerLocked() {
+	select {
+	case <-p.StopChan:
+		return // pool detenido: Wait ya puede estar esperando
+	default:
+	}
+	id := p.nextID
+	p.nextID++
+	w := &poolWorker{quit: make(chan struct{}), idleSince: time.Now()}
+	p.workers[id] = w
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.worker(id, w)
+	}()
+}
+
+// retireIdle retira hasta n workers ociosos desde hace al menos idleFor.
+// Devuelve cuántos retiró.
+func (p *WorkerPool) retireIdle(n int, idleFor time.Duration) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	retired := 0
+	for id, w := range p.workers {
+		if retired >= n {
+			break
+		}
+		if _, busy := p.running[id]; busy || now.Sub(w.idleSince) < idleFor {
+			continue
+		}
+		close(w.quit)
+		delete(p.workers, id)
+		retired++
+	}
+	return retired
+}
+
+// resize lleva el pool a n workers. Al achicar se retiran primero los
+// ociosos; un worker ocupado termina su job actual antes de irse.
+func (p *WorkerPool) resize(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.workers) < n {
+		p.addWorkerLocked()
+	}
+	excess := len(p.workers) - n
+	for _, busy := range []bool{false, true} {
+		for id, w := range p.workers {
+			if excess <= 0 {
+				return
+			}
+			if _, ok := p.running[id]; ok != busy {
+				continue
+			}
+			close(w.quit)
+			delete(p.workers, id)
+			excess--
+		}
+	}
+}
+
+// worker ejecuta trabajos tomados de la cola de prioridad.
+// Si se cierra la cola (Stop) o su quit (retiro), el worker termina su ejecución.
+func (p *WorkerPool) worker(id int, w *poolWorker) {
 	for {
+		// Un worker retirado mientras ejecutaba no toma otro job
 		select {
-		case <-p.StopChan:
-			fmt.Printf("[WorkerPool:%s] worker %d detenido\n", p.Name, id)
+		case <-w.quit:
+			fmt.Printf("[WorkerPool:%s] worker %d retirado\n", p.Name, id)
 			return
-		case job, ok := <-p.Queue:
-			if !ok {
-				fmt.Printf("[WorkerPool:%s] cola cerrada, worker %d termina\n", p.Name, id)
-				return
+		default:
+		}
+
+		job, ok := p.Queue.Pop(w.quit)
+		if !ok {
+			select {
+			case <-w.quit:
+				fmt.Printf("[WorkerPool:%s] worker %d retirado\n", p.Name, id)
+			default:
+				fmt.Printf("[WorkerPool:%s] worker %d detenido\n", p.Name, id)
 			}
+			return
+		}
 
-			atomic.AddInt64(&p.Active, 1)
-			start := time.Now()
+		// Los jobs de clase cpu esperan un token del presupuesto global.
+		// En el apagado el job queda en cola en el store y se recupera al
+		// reiniciar; un retiro, en cambio, se aplica tras ejecutarlo.
+		if p.budget != nil && !p.budget.acquire(p.Name, p.StopChan) {
+			fmt.Printf("[WorkerPool:%s] worker %d detenido esperando CPU (job %s sigue en cola)\n", p.Name, id, job.ID)
+			return
+		}
 
-			p.Manager.setStatus(job.ID, StatusRunning)
-			fmt.Printf("[WorkerPool:%s] worker %d procesando job %s\n", p.Name, id, job.ID)
+		// Un job cancelado mientras estaba en cola no se ejecuta
+		owner := fmt.Sprintf("worker-%d (pid %d)", id, os.Getpid())
+		if !p.Manager.startJob(job.ID, owner) {
+			fmt.Printf("[WorkerPool:%s] worker %d salta job %s (ya no está en cola)\n", p.Name, id, job.ID)
+			p.releaseCPU()
+			continue
+		}
 
-			// Ejecutar el trabajo
-			p.Manager.runJob(job)
+		atomic.AddInt64(&p.Active, 1)
+		p.setRunning(id, job.ID)
+		start := time.Now()
 
-			elapsed := time.Since(start)
-			fmt.Printf("[WorkerPool:%s] worker %d completó job %s en %v\n",
-				p.Name, id, job.ID, elapsed)
+		fmt.Printf("[WorkerPool:%s] worker %d procesando job %s (prio %s)\n", p.Name, id, job.ID, job.Priority)
+
+		// Ejecutar el trabajo
+		p.Manager.runJob(job)
 
-			atomic.AddInt64(&p.Active, -1)
-		}
 		elapsed := time.Since(start)
+		fmt.Printf("[WorkerPool:%s] worker %d completó job %s en %v\n",
+			p.Name, id, job.ID, elapsed)
+
+		p.releaseCPU()
+		p.setRunning(id, "")
+		atomic.AddInt64(&p.Active, -1)
 		atomic.AddInt64(&p.TotalJobs, 1)
 		atomic.AddInt64(&p.TotalTime, elapsed.Nanoseconds())
 	}
-	
+}
+
+// releaseCPU devuelve el token del presupuesto de CPU, si la tarea usa uno.
+func (p *WorkerPool) releaseCPU() {
+	if p.budget != nil {
+		p.budget.release(p.Name)
+	}
+}
+
+// setRunning registra qué job ejecuta cada worker ("" = ocioso).
+func (p *WorkerPool) setRunning(worker int, jobID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if jobID == "" {
+		delete(p.running, worker)
+		if w, ok := p.workers[worker]; ok {
+			w.idleSince = time.Now()
+		}
+		return
+	}
+	p.running[worker] = jobID
+}
+
+// Running devuelve una copia del mapa worker -> job en ejecución.
+func (p *WorkerPool) Running() map[int]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[int]string, len(p.running))
+	for w, id := range p.running {
+		out[w] = id
+	}
+	return out
 }
 
 // Stop detiene todos los workers de forma ordenada.
 func (p *WorkerPool) Stop() {
 	close(p.StopChan)
-	close(p.Queue)
+	p.Queue.Close()
+	if p.procs != nil {
+		p.procs.close()
+	}
 	fmt.Printf("[WorkerPool:%s] pool detenido\n", p.Name)
 }
 
+// Wait espera a que terminen los workers tras Stop. Un worker que ejecuta
+// un job vuelve cuando la tarea retorna o vence cancelGrace.
+func (p *WorkerPool) Wait() {
+	p.wg.Wait()
+}
+
 // Stats devuelve estadísticas básicas del pool:
 // número de workers totales, activos y tamaño de la cola.
 func (p *WorkerPool) Stats() map[string]any {
 	avg := float64(0)
-	if p.TotalJobs > 0 {
-		avg = float64(p.TotalTime) / float64(p.TotalJobs) / 1e6
+	if total := atomic.LoadInt64(&p.TotalJobs); total > 0 {
+		avg = float64(atomic.LoadInt64(&p.TotalTime)) / float64(total) / 1e6
 	}
-	return map[string]any{
-		"workers":  p.Workers,
-		"active":   atomic.LoadInt64(&p.Active),
-		"queued":   len(p.Queue),
-		"capacity": cap(p.Queue),
-		"avg_ms":   avg,
+	stats := map[string]any{
+		"workers":            p.Size(),
+		"active":             atomic.LoadInt64(&p.Active),
+		"queued":             p.Queue.Len(),
+		"queued_by_priority": p.Queue.Depths(),
+		"capacity":           p.Queue.Cap(),
+		"paused":             p.Queue.Paused(),
+		"avg_ms":             avg,
 	}
-
+	if p.scaler != nil {
+		stats["autoscale"] = p.scaler.stats()
+	}
+	if p.budget != nil {
+		stats["cpu"] = p.budget.stats(p.Name)
+	}
+	return stats
 }
@@ -0,0 +1,575 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrWorkflowNotFound = errors.New("workflow no encontrado")
+	ErrInvalidWorkflow  = errors.New("workflow inválido")
+)
+
+// WorkflowStatus es el estado agregado de un workflow.
+type WorkflowStatus string
+
+const (
+	WorkflowRunning  WorkflowStatus = "running"
+	WorkflowDone     WorkflowStatus = "done"
+	WorkflowError    WorkflowStatus = "error"
+	WorkflowCanceled WorkflowStatus = "canceled"
+)
+
+// Estados propios de un paso que todavía no tiene job (o nunca lo tendrá).
+// Una vez lanzado, el paso refleja el estado de su job.
+const (
+	StepPending JobStatus = "pending" // esperando a sus dependencias
+	StepSkipped JobStatus = "skipped" // una dependencia no terminó bien
+)
+
+// Referencias a resultados de pasos anteriores dentro de los parámetros:
+// ${steps.<id>.result} o ${steps.<id>.result.<campo>[.<campo>...]}.
+var stepRefRe = regexp.MustCompile(`\$\{steps\.([A-Za-z0-9_-]+)\.result((?:\.[A-Za-z0-9_-]+)*)\}`)
+
+var stepIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// WorkflowStep es un paso de la definición: una tarea con sus parámetros
+// y los pasos de los que depende. Las referencias ${steps.X.result...}
+// agregan la dependencia con X automáticamente.
+type WorkflowStep struct {
+	ID        string            `json:"id"`
+	Task      string            `json:"task"`
+	Params    map[string]string `json:"params,omitempty"`
+	DependsOn []string          `json:"depends_on,omitempty"`
+	Prio      string            `json:"prio,omitempty"` // high | normal | low (vacío = normal)
+}
+
+// WorkflowSpec es la definición enviada por el cliente (un DAG de pasos).
+type WorkflowSpec struct {
+	Name  string         `json:"name,omitempty"`
+	Steps []WorkflowStep `json:"steps"`
+}
+
+// StepState es un paso con su estado de ejecución.
+type StepState struct {
+	WorkflowStep
+	Status JobStatus `json:"status"`
+	JobID  string    `json:"job_id,omitempty"`
+	Result any       `json:"result,omitempty"`
+	Error  string    `json:"error,omitempty"`
+
+	submitting bool // advanceWorkflow lo está enviando sin m.wf.mu
+}
+
+// Workflow es la vista agregada de una ejecución.
+type Workflow struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name,omitempty"`
+	Status    WorkflowStatus `json:"status"`
+	Progress  int            `json:"progress"` // % de pasos terminados
+	Error     string         `json:"error,omitempty"`
+	Steps     []*StepState   `json:"steps"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func (w *Workflow) step(id string) *StepState {
+	for _, s := range w.Steps {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (w *Workflow) snapshot() Workflow {
+	cp := *w
+	cp.Steps = make([]*StepState, len(w.Steps))
+	for i, s := range w.Steps {
+		sc := *s
+		cp.Steps[i] = &sc
+	}
+	return cp
+}
+
+// workflowsFileFor deriva el archivo de workflows del archivo de jobs:
+// jobs_data.json -> jobs_data_workflows.json.
+func workflowsFileFor(file string) string {
+	if file == "" {
+		return ""
+	}
+	return strings.TrimSuffix(file, ".json") + "_workflows.json"
+}
+
+// workflowTable guarda los workflows y su archivo de persistencia.
+type workflowTable struct {
+	mu    sync.Mutex
+	file  string
+	items map[string]*Workflow
+}
+
+func newWorkflowTable(file string) *workflowTable {
+	t := &workflowTable{file: file, items: make(map[string]*Workflow)}
+	if file == "" {
+		return t
+	}
+	if data, err := os.ReadFile(file); err == nil && len(data) > 0 {
+		if err := json.Unmarshal(data, &t.items); err != nil {
+			fmt.Printf("[Workflow] No se pudo leer %s: %v\n", file, err)
+		}
+	}
+	return t
+}
+
+// saveLocked reescribe el archivo de workflows. Requiere t.mu tomado.
+func (t *workflowTable) saveLocked() {
+	if t.file == "" {
+		return
+	}
+	data, err := json.MarshalIndent(t.items, "", "  ")
+	if err == nil {
+		err = os.WriteFile(t.file, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("[Workflow] Error persistiendo %s: %v\n", t.file, err)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Validación de la definición
+// -----------------------------------------------------------------------------
+
+// validateWorkflow revisa ids, tareas y dependencias (incluidas las
+// implícitas por referencias) y rechaza ciclos. Devuelve los pasos con
+// DependsOn completo.
+func (m *Manager) validateWorkflow(spec WorkflowSpec) ([]WorkflowStep, error) {
+	if len(spec.Steps) == 0 {
+		return nil, fmt.Errorf("%w: no tiene pasos", ErrInvalidWorkflow)
+	}
+
+	steps := make([]WorkflowStep, len(spec.Steps))
+	ids := map[string]bool{}
+	for i, st := range spec.Steps {
+		if !stepIDRe.MatchString(st.ID) {
+			return nil, fmt.Errorf("%w: id de paso inválido %q", ErrInvalidWorkflow, st.ID)
+		}
+		if ids[st.ID] {
+			return nil, fmt.Errorf("%w: id de paso repetido %q", ErrInvalidWorkflow, st.ID)
+		}
+		ids[st.ID] = true
+		m.mu.RLock()
+		_, ok := m.tasks[st.Task]
+		m.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("%w: paso %q: %v (%s)", ErrInvalidWorkflow, st.ID, ErrTaskNotFound, st.Task)
+		}
+		steps[i] = st
+	}
+
+	for i := range steps {
+		st := &steps[i]
+		deps := map[string]bool{}
+		for _, d := range st.DependsOn {
+			deps[d] = true
+		}
+		for _, v := range st.Params {
+			for _, ref := range stepRefRe.FindAllStringSubmatch(v, -1) {
+				deps[ref[1]] = true
+			}
+		}
+		st.DependsOn = st.DependsOn[:0:0]
+		for d := range deps {
+			if !ids[d] {
+				return nil, fmt.Errorf("%w: paso %q depende de %q, que no existe", ErrInvalidWorkflow, st.ID, d)
+			}
+			if d == st.ID {
+				return nil, fmt.Errorf("%w: paso %q depende de sí mismo", ErrInvalidWorkflow, st.ID)
+			}
+			st.DependsOn = append(st.DependsOn, d)
+		}
+		sort.Strings(st.DependsOn)
+	}
+
+	// Orden topológico (Kahn): si quedan pasos sin visitar, hay un ciclo
+	indeg := map[string]int{}
+	children := map[string][]string{}
+	for _, st := range steps {
+		indeg[st.ID] += 0
+		for _, d := range st.DependsOn {
+			indeg[st.ID]++
+			children[d] = append(children[d], st.ID)
+		}
+	}
+	var ready []string
+	for id, n := range indeg {
+		if n == 0 {
+			ready = append(ready, id)
+		}
+	}
+	visited := 0
+	for len(ready) > 0 {
+		id := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+		visited++
+		for _, c := range children[id] {
+			if indeg[c]--; indeg[c] == 0 {
+				ready = append(ready, c)
+			}
+		}
+	}
+	if visited != len(steps) {
+		return nil, fmt.Errorf("%w: las dependencias forman un ciclo", ErrInvalidWorkflow)
+	}
+	return steps, nil
+}
+
+// -----------------------------------------------------------------------------
+// Resolución de referencias
+// -----------------------------------------------------------------------------
+
+// resolveParams reemplaza las referencias ${steps.X.result...} por los
+// resultados de los pasos ya terminados.
+func resolveParams(w *Workflow, params map[string]string) (url.Values, error) {
+	out := url.Values{}
+	for k, v := range params {
+		var refErr error
+		resolved := stepRefRe.ReplaceAllStringFunc(v, func(ref string) string {
+			sub := stepRefRe.FindStringSubmatch(ref)
+			src := w.step(sub[1])
+			val, ok := lookupResult(src.Result, strings.Split(strings.TrimPrefix(sub[2], "."), "."))
+			if !ok && refErr == nil {
+				refErr = fmt.Errorf("referencia %s sin valor en el resultado de %q", ref, sub[1])
+			}
+			return val
+		})
+		if refErr != nil {
+			return nil, refErr
+		}
+		out.Set(k, resolved)
+	}
+	return out, nil
+}
+
+// lookupResult recorre path dentro del resultado y lo devuelve como texto.
+// Los resultados que no son mapas se normalizan pasando por JSON.
+func lookupResult(res any, path []string) (string, bool) {
+	if len(path) == 1 && path[0] == "" {
+		path = nil
+	}
+	cur := res
+	for _, key := range path {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			data, err := json.Marshal(cur)
+			if err != nil || json.Unmarshal(data, &obj) != nil || obj == nil {
+				return "", false
+			}
+		}
+		if cur, ok = obj[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := cur.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case map[string]any, []any:
+		data, _ := json.Marshal(v)
+		return string(data), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// resultError detecta el error que varias tareas devuelven dentro del
+// resultado ({"error": "..."}) en lugar de como error de Go.
+func resultError(res any) string {
+	if obj, ok := res.(map[string]any); ok {
+		if e, ok := obj["error"].(string); ok {
+			return e
+		}
+	}
+	return ""
+}
+
+// -----------------------------------------------------------------------------
+// API del Manager
+// -----------------------------------------------------------------------------
+
+// SubmitWorkflow valida la definición, la registra y lanza los pasos sin
+// dependencias. El resto se lanza a medida que terminan sus dependencias.
+func (m *Manager) SubmitWorkflow(spec WorkflowSpec) (*Workflow, error) {
+	steps, err := m.validateWorkflow(spec)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	w := &Workflow{
+		ID:        "wf-" + genID(),
+		Name:      spec.Name,
+		Status:    WorkflowRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, st := range steps {
+		w.Steps = append(w.Steps, &StepState{WorkflowStep: st, Status: StepPending})
+	}
+
+	m.wf.mu.Lock()
+	m.wf.items[w.ID] = w
+	m.wf.mu.Unlock()
+	fmt.Printf("[Workflow] %s creado con %d pasos\n", w.ID, len(w.Steps))
+
+	m.advanceWorkflow(w.ID)
+	return m.GetWorkflow(w.ID)
+}
+
+func (m *Manager) GetWorkflow(id string) (*Workflow, error) {
+	m.wf.mu.Lock()
+	defer m.wf.mu.Unlock()
+	w, ok := m.wf.items[id]
+	if !ok {
+		return nil, ErrWorkflowNotFound
+	}
+	cp := w.snapshot()
+	return &cp, nil
+}
+
+// CancelWorkflow cancela los pasos en curso y los pendientes.
+func (m *Manager) CancelWorkflow(id string) (*Workflow, error) {
+	m.wf.mu.Lock()
+	w, ok := m.wf.items[id]
+	if !ok {
+		m.wf.mu.Unlock()
+		return nil, ErrWorkflowNotFound
+	}
+	if w.Status != WorkflowRunning {
+		m.wf.mu.Unlock()
+		return nil, ErrNotCancelable
+	}
+	var active []string
+	for _, st := range w.Steps {
+		switch {
+		case st.Status == StepPending:
+			st.Status = StatusCanceled
+		case st.JobID != "" && !st.Status.Terminal():
+			st.Status = StatusCanceled
+			active = append(active, st.JobID)
+		}
+	}
+	w.Status = WorkflowCanceled
+	w.Error = ErrJobCanceled.Error()
+	w.Progress = 100
+	w.UpdatedAt = time.Now()
+	m.wf.saveLocked()
+	cp := w.snapshot()
+	m.wf.mu.Unlock()
+
+	// Con el workflow ya cerrado, el cierre de estos jobs no lo vuelve a avanzar
+	for _, jobID := range active {
+		m.Cancel(jobID)
+	}
+	fmt.Printf("[Workflow] %s cancelado\n", id)
+	return &cp, nil
+}
+
+// resumeWorkflows retoma, tras un reinicio, los workflows con pasos de una
+// tarea recién registrada.
+func (m *Manager) resumeWorkflows(task string) {
+	m.wf.mu.Lock()
+	var ids []string
+	for id, w := range m.wf.items {
+		if w.Status != WorkflowRunning {
+			continue
+		}
+		for _, st := range w.Steps {
+			if st.Task == task {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	m.wf.mu.Unlock()
+	for _, id := range ids {
+		m.advanceWorkflow(id)
+	}
+}
+
+// stepSubmit es un paso listo para enviarse: se arma con m.wf.mu y se
+// envía sin él.
+type stepSubmit struct {
+	step   *StepState
+	req    SubmitRequest
+	jobID  string
+	status JobStatus
+	err    error
+}
+
+// advanceWorkflow sincroniza los pasos con sus jobs, lanza los pasos cuyas
+// dependencias terminaron, salta los que dependen de un paso fallido y
+// calcula el estado agregado. Es idempotente: se invoca desde jobFinished
+// cada vez que termina un job del workflow. Los pasos listos se envían sin
+// m.wf.mu: SubmitJob puede tardar (estimación, log de persistencia) y, si
+// cierra un job de este mismo workflow, vuelve a entrar aquí.
+func (m *Manager) advanceWorkflow(id string) {
+	held := map[string]bool{} // pasos que no se vuelven a enviar en esta pasada
+	retryLater := false
+	for {
+		ready := m.syncWorkflow(id, held)
+		if len(ready) == 0 {
+			break
+		}
+		for _, r := range ready {
+			r.jobID, r.status, r.err = m.SubmitJob(r.req)
+		}
+
+		var orphans []string
+		m.wf.mu.Lock()
+		w, ok := m.wf.items[id]
+		for _, r := range ready {
+			st := r.step
+			st.submitting = false
+			if !ok || w.Status != WorkflowRunning || st.Status != StepPending {
+				// el workflow se canceló mientras se enviaba el paso
+				if r.err == nil {
+					orphans = append(orphans, r.jobID)
+				}
+				continue
+			}
+			switch {
+			case errors.Is(r.err, ErrBackpressure), errors.Is(r.err, ErrTaskDisabled):
+				// cola llena o tarea en mantenimiento: se reintenta
+				retryLater, held[st.ID] = true, true
+			case errors.Is(r.err, ErrTaskNotFound):
+				// tras un reinicio la tarea puede no estar registrada todavía
+				held[st.ID] = true
+			case r.err != nil:
+				st.Status, st.Error = StatusError, r.err.Error()
+			default:
+				st.JobID, st.Status = r.jobID, r.status
+			}
+		}
+		m.wf.mu.Unlock()
+		for _, jobID := range orphans {
+			m.Cancel(jobID)
+		}
+	}
+
+	if retryLater {
+		time.AfterFunc(time.Second, func() { m.advanceWorkflow(id) })
+	}
+}
+
+// syncWorkflow es la parte de advanceWorkflow que corre con m.wf.mu:
+// actualiza los pasos y el estado agregado, y devuelve los pasos listos
+// para enviar, marcados para que otra pasada simultánea no los repita.
+func (m *Manager) syncWorkflow(id string, held map[string]bool) []*stepSubmit {
+	m.wf.mu.Lock()
+	defer m.wf.mu.Unlock()
+	w, ok := m.wf.items[id]
+	if !ok || w.Status != WorkflowRunning {
+		return nil
+	}
+
+	var readySteps []*stepSubmit
+	for changed := true; changed; {
+		changed = false
+		for _, st := range w.Steps {
+			switch {
+			case st.JobID != "" && !st.Status.Terminal():
+				j, err := m.GetStatus(st.JobID)
+				if err != nil {
+					st.Status, st.Error = StatusError, ErrJobNotFound.Error()
+					changed = true
+					continue
+				}
+				if j.Status == st.Status {
+					continue
+				}
+				st.Status, changed = j.Status, true
+				switch {
+				case j.Status == StatusDone && resultError(j.Result) != "":
+					st.Status, st.Error = StatusError, resultError(j.Result)
+				case j.Status == StatusDone:
+					st.Result = j.Result
+				case j.Status.Terminal():
+					st.Error = j.Error
+				}
+
+			case st.Status == StepPending && !st.submitting && !held[st.ID]:
+				ready := true
+				for _, d := range st.DependsOn {
+					dep := w.step(d)
+					if dep.Status.Terminal() && dep.Status != StatusDone || dep.Status == StepSkipped {
+						st.Status, st.Error = StepSkipped, fmt.Sprintf("la dependencia %q terminó en %s", d, dep.Status)
+						changed = true
+						break
+					}
+					if dep.Status != StatusDone {
+						ready = false
+					}
+				}
+				if !ready || st.Status != StepPending {
+					continue
+				}
+
+				params, err := resolveParams(w, st.Params)
+				if err != nil {
+					st.Status, st.Error = StatusError, err.Error()
+					changed = true
+					continue
+				}
+				st.submitting = true
+				readySteps = append(readySteps, &stepSubmit{step: st, req: SubmitRequest{
+					Task: st.Task, Params: params, Priority: ParsePriority(st.Prio),
+					Workflow: w.ID, Step: st.ID,
+				}})
+			}
+		}
+	}
+
+	// Estado agregado
+	finished, failed, canceled := 0, "", false
+	for _, st := range w.Steps {
+		switch {
+		case st.Status == StatusDone:
+			finished++
+		case st.Status == StepSkipped || st.Status == StatusCanceled:
+			finished++
+			canceled = true
+		case st.Status.Terminal():
+			finished++
+			if failed == "" {
+				failed = fmt.Sprintf("paso %q: %s", st.ID, st.Error)
+			}
+		}
+	}
+	w.Progress = finished * 100 / len(w.Steps)
+	if finished == len(w.Steps) {
+		switch {
+		case failed != "":
+			w.Status, w.Error = WorkflowError, failed
+		case canceled:
+			w.Status = WorkflowCanceled
+		default:
+			w.Status = WorkflowDone
+		}
+		fmt.Printf("[Workflow] %s terminó: %s\n", w.ID, w.Status)
+	}
+	w.UpdatedAt = time.Now()
+	m.wf.saveLocked()
+	return readySteps
+}
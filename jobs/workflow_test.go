@@ -0,0 +1,214 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Tareas de prueba: "double" devuelve 2*n; "fail" siempre falla.
+func newWorkflowManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager("", 1*time.Minute, 1*time.Minute)
+	m.Register("double", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		n, err := strconv.Atoi(params["n"])
+		if err != nil {
+			return map[string]any{"error": "n inválido"}, nil
+		}
+		return map[string]any{"value": 2 * n, "nested": map[string]any{"label": "x" + params["n"]}}, nil
+	}, 2, 8, 1*time.Second)
+	m.Register("fail", func(ctx context.Context, params map[string]string, p *Progress) (any, error) {
+		return nil, errors.New("falla")
+	}, 1, 8, 1*time.Second)
+	m.Register("block", blockingTask, 2, 8, 10*time.Second)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func waitWorkflow(t *testing.T, m *Manager, id string, want WorkflowStatus) *Workflow {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		w, err := m.GetWorkflow(id)
+		if err != nil {
+			t.Fatalf("GetWorkflow(%s): %v", id, err)
+		}
+		if w.Status == want {
+			return w
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("workflow %s status = %s; se esperaba %s", id, w.Status, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestManager_WorkflowChain prueba que los resultados pasen de un paso al siguiente
+func TestManager_WorkflowChain(t *testing.T) {
+	m := newWorkflowManager(t)
+	wf, err := m.SubmitWorkflow(WorkflowSpec{Name: "cadena", Steps: []WorkflowStep{
+		{ID: "c", Task: "double", Params: map[string]string{"n": "${steps.b.result.value}", "tag": "${steps.a.result.nested.label}"}},
+		{ID: "a", Task: "double", Params: map[string]string{"n": "3"}},
+		{ID: "b", Task: "double", Params: map[string]string{"n": "${steps.a.result.value}"}},
+	}})
+	if err != nil {
+		t.Fatalf("SubmitWorkflow: %v", err)
+	}
+
+	w := waitWorkflow(t, m, wf.ID, WorkflowDone)
+	c := w.step("c")
+	if got := c.Result.(map[string]any)["value"]; got != 24 {
+		t.Errorf("resultado de c = %v; se esperaba 24", got)
+	}
+	job, _ := m.GetStatus(c.JobID)
+	if job.Params["tag"] != "x3" || job.Workflow != wf.ID || job.Step != "c" {
+		t.Errorf("job de c = %+v; se esperaba tag=x3 y el workflow asociado", job)
+	}
+	if w.Progress != 100 {
+		t.Errorf("Progress = %d; se esperaba 100", w.Progress)
+	}
+}
+
+// TestManager_WorkflowFailure prueba que un fallo salte los pasos dependientes
+// sin detener las ramas independientes
+func TestManager_WorkflowFailure(t *testing.T) {
+	m := newWorkflowManager(t)
+	wf, err := m.SubmitWorkflow(WorkflowSpec{Steps: []WorkflowStep{
+		{ID: "bad", Task: "fail"},
+		{ID: "after", Task: "double", Params: map[string]string{"n": "1"}, DependsOn: []string{"bad"}},
+		{ID: "last", Task: "double", Params: map[string]string{"n": "${steps.after.result.value}"}},
+		{ID: "other", Task: "double", Params: map[string]string{"n": "5"}},
+		{ID: "soft", Task: "double", Params: map[string]string{"n": "x"}}, // error dentro del resultado
+	}})
+	if err != nil {
+		t.Fatalf("SubmitWorkflow: %v", err)
+	}
+
+	w := waitWorkflow(t, m, wf.ID, WorkflowError)
+	want := map[string]JobStatus{"bad": StatusError, "after": StepSkipped, "last": StepSkipped, "other": StatusDone, "soft": StatusError}
+	for id, st := range want {
+		if got := w.step(id).Status; got != st {
+			t.Errorf("paso %s = %s; se esperaba %s", id, got, st)
+		}
+	}
+}
+
+// TestManager_WorkflowCancel prueba la cancelación del workflow completo
+func TestManager_WorkflowCancel(t *testing.T) {
+	m := newWorkflowManager(t)
+	wf, _ := m.SubmitWorkflow(WorkflowSpec{Steps: []WorkflowStep{
+		{ID: "wait", Task: "block"},
+		{ID: "next", Task: "double", Params: map[string]string{"n": "1"}, DependsOn: []string{"wait"}},
+	}})
+	waitStatus(t, m, wf.step("wait").JobID, StatusRunning)
+
+	w, err := m.CancelWorkflow(wf.ID)
+	if err != nil || w.Status != WorkflowCanceled {
+		t.Fatalf("CancelWorkflow = %+v, %v; se esperaba canceled", w, err)
+	}
+	waitStatus(t, m, wf.step("wait").JobID, StatusCanceled)
+	if w, _ := m.GetWorkflow(wf.ID); w.step("next").Status != StatusCanceled {
+		t.Errorf("paso next = %s; se esperaba canceled", w.step("next").Status)
+	}
+	if _, err := m.CancelWorkflow(wf.ID); err != ErrNotCancelable {
+		t.Errorf("CancelWorkflow repetido = %v; se esperaba ErrNotCancelable", err)
+	}
+}
+
+// TestManager_WorkflowValidation prueba el rechazo de definiciones inválidas
+func TestManager_WorkflowValidation(t *testing.T) {
+	m := newWorkflowManager(t)
+	cases := map[string][]WorkflowStep{
+		"vacío":               nil,
+		"id repetido":         {{ID: "a", Task: "double"}, {ID: "a", Task: "double"}},
+		"id inválido":         {{ID: "a.b", Task: "double"}},
+		"tarea inexistente":   {{ID: "a", Task: "nope"}},
+		"dependencia ausente": {{ID: "a", Task: "double", DependsOn: []string{"z"}}},
+		"referencia ausente":  {{ID: "a", Task: "double", Params: map[string]string{"n": "${steps.z.result}"}}},
+		"ciclo": {
+			{ID: "a", Task: "double", DependsOn: []string{"b"}},
+			{ID: "b", Task: "double", Params: map[string]string{"n": "${steps.a.result.value}"}},
+		},
+	}
+	for name, steps := range cases {
+		if _, err := m.SubmitWorkflow(WorkflowSpec{Steps: steps}); !errors.Is(err, ErrInvalidWorkflow) {
+			t.Errorf("%s: SubmitWorkflow = %v; se esperaba ErrInvalidWorkflow", name, err)
+		}
+	}
+}
+
+// TestManager_WorkflowSlowSubmit prueba que un envío lento de un paso no
+// detenga a los demás workflows
+func TestManager_WorkflowSlowSubmit(t *testing.T) {
+	m := newWorkflowManager(t)
+	gate := make(chan struct{})
+	m.Register("slow", quickTask, 1, 8, 1*time.Second,
+		WithLimits(ResourceLimits{Memory: 1 << 30}),
+		WithEstimator(func(p map[string]string) ResourceUsage {
+			<-gate // una estimación que lee archivos grandes
+			return ResourceUsage{}
+		}))
+
+	slow := make(chan string, 1)
+	go func() {
+		wf, _ := m.SubmitWorkflow(WorkflowSpec{Steps: []WorkflowStep{{ID: "s", Task: "slow"}}})
+		slow <- wf.ID
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan *Workflow, 1)
+	go func() {
+		wf, _ := m.SubmitWorkflow(WorkflowSpec{Steps: []WorkflowStep{{ID: "a", Task: "double", Params: map[string]string{"n": "1"}}}})
+		done <- wf
+	}()
+	select {
+	case wf := <-done:
+		waitWorkflow(t, m, wf.ID, WorkflowDone)
+	case <-time.After(time.Second):
+		close(gate)
+		t.Fatal("el workflow quedó esperando al envío de otro")
+	}
+	close(gate)
+	waitWorkflow(t, m, <-slow, WorkflowDone)
+}
+
+// TestManager_WorkflowCancelDuringSubmit prueba que el job de un paso que se
+// enviaba mientras se cancelaba el workflow se cancele también
+func TestManager_WorkflowCancelDuringSubmit(t *testing.T) {
+	m := newWorkflowManager(t)
+	gate := make(chan struct{})
+	m.Register("slowblock", blockingTask, 1, 8, 10*time.Second,
+		WithLimits(ResourceLimits{Memory: 1 << 30}),
+		WithEstimator(func(p map[string]string) ResourceUsage {
+			<-gate
+			return ResourceUsage{}
+		}))
+
+	go m.SubmitWorkflow(WorkflowSpec{Name: "lento", Steps: []WorkflowStep{{ID: "s", Task: "slowblock"}}})
+	var id string
+	waitUntil(t, func() bool {
+		m.wf.mu.Lock()
+		defer m.wf.mu.Unlock()
+		for _, w := range m.wf.items {
+			if w.Name == "lento" && w.Steps[0].submitting {
+				id = w.ID
+				return true
+			}
+		}
+		return false
+	})
+	if _, err := m.CancelWorkflow(id); err != nil {
+		t.Fatalf("CancelWorkflow: %v", err)
+	}
+	close(gate)
+
+	waitUntil(t, func() bool {
+		page, _ := m.ListJobs(JobQuery{Task: "slowblock"})
+		return len(page.Jobs) == 1 && page.Jobs[0].Status == StatusCanceled
+	})
+	if w, _ := m.GetWorkflow(id); w.Status != WorkflowCanceled || w.Steps[0].JobID != "" {
+		t.Errorf("workflow = %s, job del paso %q; se esperaba cancelado sin job", w.Status, w.Steps[0].JobID)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"P1/server"
@@ -8,18 +10,127 @@ import (
 	"P1/jobs"
 	"time"
 	"flag"
+	"net"
+	"os"
+	"strings"
+	"os/signal"
+	"syscall"
 )
 
 // Instancia global del Job Manager
 var jobManager *jobs.Manager
 
 func main() {
+	// Proceso hijo de un pool aislado: atiende los jobs que le envía el
+	// servidor por pipes, sin flags ni listener propio.
+	if task := jobs.WorkerTask(); task != "" {
+		host := jobs.NewWorkerHost(task)
+		registerTasks(host)
+		os.Exit(host.Serve())
+	}
 
-	jobManager := jobs.NewManager("jobs_data.json", 10*time.Minute, 30*time.Second)
+	portPtr := flag.Int("port", 8080, "Puerto TCP para escuchar")
+	adminPtr := flag.String("admin", "127.0.0.1:6060", "Dirección del listener de administración (vacío = deshabilitado)")
+	gracePtr := flag.Duration("shutdown-grace", 5*time.Second, "Tiempo entre el inicio del apagado y la detención de los pools")
+	cacheMBPtr := flag.Int("cache-mb", 64, "Tamaño en memoria de la caché de resultados en MiB (0 = deshabilitada)")
+	spillPtr := flag.String("cache-spill", "", "Directorio para los resultados desalojados de la caché (vacío = sin spill)")
+	fsyncPtr := flag.String("fsync", "interval", "Cuándo forzar a disco el log de jobs: always, interval o never")
+	storePtr := flag.String("store", "wal", "Almacenamiento de jobs: wal, file o memory")
+	cpuBudgetPtr := flag.Int("cpu-budget", 0, "Jobs de clase cpu simultáneos entre todos los pools (0 = GOMAXPROCS)")
+	nodeListenPtr := flag.String("node-listen", "", "Dirección donde se aceptan nodos remotos (vacío = deshabilitado)")
+	remoteOnlyPtr := flag.String("remote-only", "", "Tareas sin workers locales, solo para nodos remotos (ej. matrixmul,pi)")
+	nodePtr := flag.String("node", "", "Modo nodo: dirección del servidor del que tomar jobs")
+	nodeNamePtr := flag.String("node-name", "", "Nombre del nodo (por defecto host-pid)")
+	nodeTasksPtr := flag.String("node-tasks", "matrixmul:2,pi:2", "Tareas del nodo y sus slots")
+	nodeTokenPtr := flag.String("node-token", os.Getenv("JOBS_NODE_TOKEN"), "Token compartido entre el servidor y sus nodos (por defecto $JOBS_NODE_TOKEN)")
+	leasePtr := flag.Duration("lease", 30*time.Second, "Vencimiento del lease de un job sin heartbeats de su worker")
+	leaseActionPtr := flag.String("lease-action", "requeue", "Qué hacer con un job cuyo lease vence: requeue o fail")
+	flag.Parse()
+
+	// Modo nodo: ejecuta jobs de otro servidor en lugar de atender HTTP
+	if *nodePtr != "" {
+		os.Exit(runNode(*nodePtr, *nodeNamePtr, *nodeTasksPtr, *nodeTokenPtr))
+	}
+
+	const jobsFile = "jobs_data.json"
+	managerOpts := []jobs.ManagerOption{
+		jobs.WithFsync(jobs.FsyncPolicy(*fsyncPtr)),
+		jobs.WithCPUBudget(*cpuBudgetPtr),
+		jobs.WithLease(*leasePtr, jobs.LeaseAction(*leaseActionPtr)),
+		jobs.WithNodeToken(*nodeTokenPtr),
+	}
+	switch *storePtr {
+	case "wal":
+	case "file":
+		managerOpts = append(managerOpts, jobs.WithStore(jobs.NewFileStore(jobsFile)))
+	case "memory":
+		managerOpts = append(managerOpts, jobs.WithStore(jobs.NewMemoryStore()))
+	default:
+		fmt.Printf("Store desconocido %q (wal, file o memory)\n", *storePtr)
+		os.Exit(2)
+	}
+	jobManager := jobs.NewManager(jobsFile, 10*time.Minute, 30*time.Second, managerOpts...)
+
+	remote := remoteOnly{Registrar: jobManager, names: make(map[string]bool)}
+	for _, name := range strings.Split(*remoteOnlyPtr, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			remote.names[name] = true
+		}
+	}
+	registerTasks(remote)
+
+	// Nodos remotos (ver jobs/remote.go)
+	if *nodeListenPtr != "" {
+		ln, err := net.Listen("tcp", *nodeListenPtr)
+		if err != nil {
+			fmt.Printf("No se pudo escuchar nodos en %s: %v\n", *nodeListenPtr, err)
+			os.Exit(1)
+		}
+		go jobManager.ServeNodes(ln)
+	}
+
+	// Caché de resultados de las tareas registradas con WithCache(true)
+	if *cacheMBPtr > 0 {
+		jobManager.SetResultCache(jobs.NewResultCache(jobs.CacheConfig{
+			MaxBytes: int64(*cacheMBPtr) << 20,
+			SpillDir: *spillPtr,
+		}))
+	}
+	port := *portPtr
+	fmt.Printf("Iniciando servidor en puerto %d...\n", port)
 
+	// Diagnóstico: listener de administración fuera del puerto público
+	// y volcado de estado a disco con SIGUSR1.
+	if *adminPtr != "" {
+		go server.NewAdminServer(*adminPtr, jobManager).Start()
+	}
+	watchDebugSignal(jobManager)
+
+	// Apagado ordenado: /readyz falla de inmediato para que el orquestador
+	// deje de enviar tráfico, y tras el período de gracia se detienen los pools.
+	jobManager.SetCritical("isprime", "pi", "matrixmul")
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		fmt.Printf("Apagando servidor (gracia %v)...\n", *gracePtr)
+		jobManager.BeginShutdown()
+		time.Sleep(*gracePtr)
+		jobManager.Close()
+		os.Exit(0)
+	}()
+
+	srv := server.NewServer(port, jobManager)
+	srv.Start()
+}
+
+// registerTasks registra las tareas en el Manager del servidor o, en un
+// proceso worker, en su WorkerHost (ver jobs/process.go).
+func registerTasks(r jobs.Registrar) {
 	// --- Registrar tareas CPU-bound ---
-	jobManager.Register("isprime",
-		func(p map[string]string, j *jobs.Job) (any, error) {
+	r.Register("isprime",
+		func(ctx context.Context, p map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
 			// validar parámetro n
 			nStr, ok := p["n"]
 			if !ok || nStr == "" {
@@ -34,21 +145,24 @@ func main() {
 			}
 
 			// calcular primalidad
-			prime, err := tasks.IsPrime(n)
+			prime, err := tasks.IsPrimeContext(ctx, n)
 			if err != nil {
 				return map[string]any{"n": n, "error": err.Error()}, nil
 			}
 
-			j.Progress = 100
 			return map[string]any{"n": n, "is_prime": prime}, nil
 		},
 		4,              // workers
 		64,             // queueDepth
 		60*time.Second, // timeout
+		jobs.WithCache(true),
+		jobs.WithCoalescing(true),
+		jobs.WithClass(jobs.CPUBound, 2), // consultas cortas: más turnos de CPU
 	)
 
-	jobManager.Register("factor",
-		func(params map[string]string, job *jobs.Job) (any, error) {
+	r.Register("factor",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
 			nStr, ok := params["n"]
 			if !ok || nStr == "" {
 				return map[string]any{"error": "falta parámetro n"}, nil
@@ -58,17 +172,17 @@ func main() {
 				return map[string]any{"n": nStr, "error": "n inválido"}, nil
 			}
 
-			factors, err := tasks.Factor(n)
-			job.Progress = 100
+			factors, err := tasks.FactorContext(ctx, n)
 			if err != nil {
 				return map[string]any{"n": n, "error": err.Error()}, nil
 			}
 			return map[string]any{"n": n, "factors": factors}, nil
 		},
-		3, 16, 60*time.Second)
+		3, 16, 60*time.Second, jobs.WithCache(true), jobs.WithCoalescing(true), jobs.WithClass(jobs.CPUBound, 1))
 
-	jobManager.Register("pi",
-		func(params map[string]string, job *jobs.Job) (any, error) {
+	r.Register("pi",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
 			digitsStr, ok := params["digits"]
 			if !ok {
 				return map[string]any{"error": "falta parámetro digits"}, nil
@@ -77,17 +191,19 @@ func main() {
 			if err != nil {
 				return map[string]any{"error": "digits inválido"}, nil
 			}
-			pi, err := tasks.PiDigits(digits)
-			job.Progress = 100
+			pi, err := tasks.PiDigitsContext(ctx, digits)
 			if err != nil {
 				return map[string]any{"digits": digits, "error": err.Error()}, nil
 			}
 			return map[string]any{"digits": digits, "pi": pi}, nil
 		},
-		2, 8, 90*time.Second)
+		2, 8, 90*time.Second, jobs.WithCache(true), jobs.WithCoalescing(true), jobs.WithClass(jobs.CPUBound, 1),
+		// muchos dígitos: la división final y el formateo con big.Float no revisan el contexto
+		jobs.WithIsolation(jobs.ProcessLimits{CPUSeconds: 180, Memory: 1 << 30, OpenFiles: 64}))
 
-	jobManager.Register("matrixmul",
-		func(params map[string]string, job *jobs.Job) (any, error) {
+	r.Register("matrixmul",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
 			sizeStr := params["size"] // <-- CORREGIDO: "size"
 			seedStr := params["seed"]
 
@@ -98,24 +214,54 @@ func main() {
 				return nil, fmt.Errorf("parámetros 'size' o 'seed' inválidos")
 			}
 
-			hash, err := tasks.MatrixMul(size, seed)
-			job.Progress = 100
+			hash, err := tasks.MatrixMulContext(ctx, size, seed)
 			if err != nil {
 				return nil, err 
 			}
 			return map[string]any{"size": size, "hash": hash}, nil
 		},
-		2, 8, 120*time.Second)
+		2, 8, 120*time.Second, jobs.WithCache(true), jobs.WithCoalescing(true), jobs.WithClass(jobs.CPUBound, 1),
+		jobs.WithLimits(jobs.ResourceLimits{Memory: 512 << 20}),
+		jobs.WithEstimator(func(p map[string]string) jobs.ResourceUsage {
+			size, _ := strconv.Atoi(p["size"])
+			return jobs.ResourceUsage{Memory: tasks.MatrixMulMemory(size)}
+		}),
+		// matrices grandes: en un proceso aparte que el timeout puede matar
+		jobs.WithIsolation(jobs.ProcessLimits{CPUSeconds: 240, Memory: 2 << 30, OpenFiles: 64}))
+
+	r.Register("mandelbrot",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
+			width, errW := strconv.Atoi(params["width"])
+			height, errH := strconv.Atoi(params["height"])
+			maxIter, errI := strconv.Atoi(params["max_iter"])
+			if errW != nil || errH != nil || errI != nil {
+				return nil, fmt.Errorf("parámetros 'width', 'height' o 'max_iter' inválidos")
+			}
+			result, err := tasks.MandelbrotContext(ctx, width, height, maxIter)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"width": width, "height": height, "max_iter": maxIter, "result": result}, nil
+		},
+		2, 8, 60*time.Second, jobs.WithCache(true), jobs.WithCoalescing(true), jobs.WithClass(jobs.CPUBound, 1),
+		jobs.WithLimits(jobs.ResourceLimits{Memory: 256 << 20, Output: 16 << 20}),
+		jobs.WithEstimator(func(p map[string]string) jobs.ResourceUsage {
+			w, _ := strconv.Atoi(p["width"])
+			h, _ := strconv.Atoi(p["height"])
+			return jobs.ResourceUsage{Memory: tasks.MandelbrotMemory(w, h), Output: tasks.MandelbrotOutput(w, h)}
+		}),
+		jobs.WithIsolation(jobs.ProcessLimits{CPUSeconds: 120, Memory: 1 << 30, OpenFiles: 64}))
 
 	// --- Registrar tareas IO-bound ---
-	jobManager.Register("sortfile",
-		func(params map[string]string, job *jobs.Job) (any, error) {
+	r.Register("sortfile",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
 			name := params["name"]
 			algo := params["algo"]
 			start := time.Now()
-			out, elapsed, err := tasks.SortFile(name, algo)
+			out, elapsed, err := tasks.SortFileContext(ctx, name, algo)
 
-			job.Progress = 100
 			if err != nil {
 				fmt.Printf("[sortfile] Error procesando '%s' con algoritmo '%s': %v\n", name, algo, err)
 				return map[string]any{"error": err.Error()}, err
@@ -124,13 +270,58 @@ func main() {
 			fmt.Printf("[sortfile] Terminado en %.2fs\n", time.Since(start).Seconds())
 			return map[string]any{"output": out, "elapsed_ms": elapsed}, nil
 		},
-		1, 2, 120*time.Second)
+		1, 2, 120*time.Second,
+		// errores de IO (archivo bloqueado, disco) y timeouts suelen ser transitorios
+		jobs.WithRetry(jobs.RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   2 * time.Second,
+			MaxDelay:    30 * time.Second,
+			Jitter:      0.2,
+			RetryOn:     []jobs.ErrorClass{jobs.ClassIO, jobs.ClassTimeout},
+		}),
+		// las ráfagas llenan la cola (2 lugares) con CPU ociosa: escalar
+		jobs.WithAutoscale(jobs.ScalePolicy{Min: 1, Max: 4}),
+		// algo=quick carga el archivo completo en memoria
+		jobs.WithLimits(jobs.ResourceLimits{Memory: 512 << 20, Disk: 4 << 30}),
+		jobs.WithEstimator(func(p map[string]string) jobs.ResourceUsage {
+			mem, disk := tasks.SortFileUsage(p["name"], p["algo"])
+			return jobs.ResourceUsage{Memory: mem, Disk: disk}
+		}))
 
-	jobManager.Register("wordcount",
-		func(params map[string]string, job *jobs.Job) (any, error) {
+	// createfile como job: primer paso típico de los workflows
+	// (createfile -> sortfile -> compress -> hashfile)
+	r.Register("createfile",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
+			name := params["name"]
+			content := params["content"]
+			repeat, err := strconv.Atoi(params["repeat"])
+			if err != nil || repeat <= 0 {
+				repeat = 1
+			}
+			if name == "" || content == "" {
+				return nil, fmt.Errorf("faltan parámetros name o content")
+			}
+			if err := tasks.CreateFileContext(ctx, name, content, repeat); err != nil {
+				return nil, err
+			}
+			return map[string]any{"output": name, "repeat": repeat}, nil
+		},
+		2, 8, 60*time.Second,
+		jobs.WithLimits(jobs.ResourceLimits{Disk: 1 << 30}),
+		jobs.WithEstimator(func(p map[string]string) jobs.ResourceUsage {
+			repeat, err := strconv.Atoi(p["repeat"])
+			if err != nil || repeat <= 0 {
+				repeat = 1
+			}
+			return jobs.ResourceUsage{Disk: tasks.CreateFileUsage(p["content"], repeat)}
+		}))
+
+	r.Register("wordcount",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
 			name := params["file"]
-			lines, words, bytes, err := tasks.WordCount(name)
-			job.Progress = 100
+			lines, words, bytes, err := tasks.WordCountContext(ctx, name)
 			if err != nil {
 				return map[string]any{"error": err.Error()}, nil
 			}
@@ -138,12 +329,12 @@ func main() {
 		},
 		2, 4, 60*time.Second)
 
-	jobManager.Register("grep",
-		func(params map[string]string, job *jobs.Job) (any, error) {
+	r.Register("grep",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
 			name := params["file"]
 			pattern := params["pattern"]
-			count, lines, err := tasks.Grep(name, pattern)
-			job.Progress = 100
+			count, lines, err := tasks.GrepContext(ctx, name, pattern)
 			if err != nil {
 				return map[string]any{"error": err.Error()}, nil
 			}
@@ -151,36 +342,77 @@ func main() {
 		},
 		2, 4, 60*time.Second)
 
-	jobManager.Register("compress",
-		func(params map[string]string, job *jobs.Job) (any, error) {
+	r.Register("compress",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
 			name := params["file"]
 			codec := params["codec"]
-			out, size, err := tasks.Compress(name, codec)
-			job.Progress = 100
+			out, size, err := tasks.CompressContext(ctx, name, codec)
+			if errors.Is(err, jobs.ErrLimitExceeded) {
+				return nil, err
+			}
 			if err != nil {
 				return map[string]any{"error": err.Error()}, nil
 			}
 			return map[string]any{"output": out, "size": size}, nil
 		},
-		1, 2, 90*time.Second)
+		1, 2, 90*time.Second, jobs.WithLimits(jobs.ResourceLimits{Disk: 4 << 30}))
 
-	jobManager.Register("hashfile",
-		func(params map[string]string, job *jobs.Job) (any, error) {
+	r.Register("hashfile",
+		func(ctx context.Context, params map[string]string, pr *jobs.Progress) (any, error) {
+			ctx = jobContext(ctx, pr)
 			name := params["file"]
-			hash, err := tasks.HashFile(name)
-			job.Progress = 100
+			hash, err := tasks.HashFileContext(ctx, name)
 			if err != nil {
 				return map[string]any{"error": err.Error()}, nil
 			}
 			return map[string]any{"hash": hash}, nil
 		},
 		2, 4, 60*time.Second)
+}
 
-	portPtr := flag.Int("port", 8080, "Puerto TCP para escuchar")
-	flag.Parse()
-	port := *portPtr
-	fmt.Printf("Iniciando servidor en puerto %d...\n", port)
+// remoteOnly agrega WithRemoteOnly a las tareas de names al registrarlas.
+type remoteOnly struct {
+	jobs.Registrar
+	names map[string]bool
+}
 
-	srv := server.NewServer(port, jobManager)
-	srv.Start()
-}
\ No newline at end of file
+func (r remoteOnly) Register(name string, fn jobs.TaskFunc, workers, queueDepth int, timeout time.Duration, opts ...jobs.TaskOption) {
+	if r.names[name] {
+		opts = append(opts, jobs.WithRemoteOnly())
+	}
+	r.Registrar.Register(name, fn, workers, queueDepth, timeout, opts...)
+}
+
+// runNode atiende jobs del servidor addr como nodo remoto hasta SIGINT o
+// SIGTERM. Devuelve el código de salida.
+func runNode(addr, name, taskList, token string) int {
+	slots, err := jobs.ParseNodeTasks(taskList)
+	if err != nil {
+		fmt.Printf("-node-tasks: %v\n", err)
+		return 2
+	}
+	if name == "" {
+		host, _ := os.Hostname()
+		name = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	node := jobs.NewNode(name, slots)
+	node.Token = token
+	registerTasks(node)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := node.Run(ctx, addr); err != nil {
+		fmt.Printf("[Node:%s] %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// jobContext conecta el contexto de una tarea con el Progress de su job:
+// avance, reservas de memoria y escrituras a disco (ver jobs/limits.go).
+func jobContext(ctx context.Context, pr *jobs.Progress) context.Context {
+	ctx = tasks.WithProgress(ctx, pr.Update)
+	ctx = tasks.WithMemoryQuota(ctx, pr.ReserveMemory)
+	return tasks.WithDiskQuota(ctx, pr.WriteDisk)
+}
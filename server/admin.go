@@ -0,0 +1,203 @@
+// listener de administración: perfiles, trazas, volcados de estado y pools
+
+package server
+
+import (
+	"P1/jobs"
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"time"
+)
+
+// Duración máxima de un perfil de CPU o traza pedido por el listener.
+const maxProfileSeconds = 120
+
+// AdminServer atiende rutas de diagnóstico en un puerto separado del público.
+// Por defecto se enlaza a 127.0.0.1 para que no sea accesible desde afuera.
+type AdminServer struct {
+	addr    string
+	Manager jobs.ManagerInterface
+}
+
+func NewAdminServer(addr string, manager jobs.ManagerInterface) *AdminServer {
+	return &AdminServer{addr: addr, Manager: manager}
+}
+
+func (a *AdminServer) Start() {
+	listener, err := net.Listen("tcp", a.addr)
+	if err != nil {
+		fmt.Printf("[Admin] No se pudo escuchar en %s: %v\n", a.addr, err)
+		return
+	}
+	defer listener.Close()
+
+	fmt.Printf("[Admin] Listener de administración en %s\n", a.addr)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			fmt.Println("[Admin] Error al aceptar conexión:", err)
+			continue
+		}
+		b := make([]byte, 8)
+		rand.Read(b)
+		go a.handleConnection(conn, hex.EncodeToString(b))
+	}
+}
+
+func (a *AdminServer) handleConnection(conn net.Conn, reqID string) {
+	defer conn.Close()
+
+	req, err := readRequest(bufio.NewReader(conn))
+	if err != nil {
+		fmt.Printf("[Admin][%s] Error al leer solicitud: %v\n", reqID, err)
+		return
+	}
+
+	fmt.Printf("[Admin][%s] %s %s\n", reqID, req.Method, req.Path)
+	code, contentType, body := HandleAdminRequest(req.Method, req.Path, a.Manager)
+	conn.Write(buildRawResponse(code, contentType, body, reqID))
+}
+
+// HandleAdminRequest enruta las peticiones del listener de administración.
+// Devuelve código, Content-Type y cuerpo (binario en el caso de los perfiles).
+func HandleAdminRequest(method, path string, manager jobs.ManagerInterface) (int, string, []byte) {
+	if method != "GET" {
+		return 400, "application/json", []byte(`{"error": "Método no soportado, use GET"}`)
+	}
+
+	parts := strings.SplitN(path, "?", 2)
+	route := parts[0]
+	var params url.Values
+	if len(parts) > 1 {
+		params, _ = url.ParseQuery(parts[1])
+	}
+
+	switch {
+	case route == "/debug/pprof/profile":
+		seconds := profileSeconds(params, 30)
+		var buf bytes.Buffer
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			return 500, "application/json", []byte(fmt.Sprintf(`{"error": "%v"}`, err))
+		}
+		time.Sleep(time.Duration(seconds) * time.Second)
+		pprof.StopCPUProfile()
+		return 200, "application/octet-stream", buf.Bytes()
+
+	case route == "/debug/trace":
+		seconds := profileSeconds(params, 5)
+		var buf bytes.Buffer
+		if err := trace.Start(&buf); err != nil {
+			return 500, "application/json", []byte(fmt.Sprintf(`{"error": "%v"}`, err))
+		}
+		time.Sleep(time.Duration(seconds) * time.Second)
+		trace.Stop()
+		return 200, "application/octet-stream", buf.Bytes()
+
+	case strings.HasPrefix(route, "/debug/pprof/"):
+		// heap, goroutine, allocs, block, mutex, threadcreate
+		name := strings.TrimPrefix(route, "/debug/pprof/")
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			return 404, "application/json", []byte(`{"error": "Perfil no encontrado"}`)
+		}
+		level := parseIntParam(params, "debug", 0)
+		var buf bytes.Buffer
+		if err := profile.WriteTo(&buf, level); err != nil {
+			return 500, "application/json", []byte(fmt.Sprintf(`{"error": "%v"}`, err))
+		}
+		if level > 0 {
+			return 200, "text/plain; charset=utf-8", buf.Bytes()
+		}
+		return 200, "application/octet-stream", buf.Bytes()
+
+	case route == "/debug/manager":
+		body, _ := json.MarshalIndent(manager.DebugDump(), "", "  ")
+		return 200, "application/json", body
+
+	case route == "/pools" || strings.HasPrefix(route, "/pools/"):
+		code, body := poolAdmin(route, params, manager)
+		return code, "application/json", body
+
+	case route == "/nodes":
+		body, _ := json.MarshalIndent(manager.Nodes(), "", "  ")
+		return 200, "application/json", body
+
+	case route == "/debug/runtime":
+		body, _ := json.MarshalIndent(RuntimeSummary(), "", "  ")
+		return 200, "application/json", body
+
+	default:
+		return 404, "application/json", []byte(`{"error": "Ruta no encontrada"}`)
+	}
+}
+
+// RuntimeSummary resume el estado del GC y las goroutines del proceso.
+func RuntimeSummary() map[string]any {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+
+	lastPause := time.Duration(0)
+	if len(gc.Pause) > 0 {
+		lastPause = gc.Pause[0]
+	}
+
+	return map[string]any{
+		"goroutines":     runtime.NumGoroutine(),
+		"gomaxprocs":     runtime.GOMAXPROCS(0),
+		"heap_alloc":     ms.HeapAlloc,
+		"heap_inuse":     ms.HeapInuse,
+		"heap_objects":   ms.HeapObjects,
+		"sys":            ms.Sys,
+		"num_gc":         ms.NumGC,
+		"last_gc":        gc.LastGC,
+		"last_pause_ms":  float64(lastPause) / 1e6,
+		"pause_total_ms": float64(gc.PauseTotal) / 1e6,
+		"next_gc":        ms.NextGC,
+	}
+}
+
+// WriteDebugDump escribe en path el volcado del Manager, el resumen del
+// runtime y las pilas de todas las goroutines. Lo usa el handler de SIGUSR1.
+func WriteDebugDump(path string, manager jobs.ManagerInterface) error {
+	var stacks bytes.Buffer
+	if p := pprof.Lookup("goroutine"); p != nil {
+		p.WriteTo(&stacks, 2)
+	}
+
+	data, err := json.MarshalIndent(map[string]any{
+		"manager":    manager.DebugDump(),
+		"runtime":    RuntimeSummary(),
+		"goroutines": stacks.String(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func profileSeconds(params url.Values, def int) int {
+	seconds := parseIntParam(params, "seconds", def)
+	if seconds <= 0 {
+		seconds = def
+	}
+	if seconds > maxProfileSeconds {
+		seconds = maxProfileSeconds
+	}
+	return seconds
+}
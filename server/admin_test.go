@@ -0,0 +1,100 @@
+package server
+
+import (
+	"P1/jobs"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHandleAdminRequest prueba las rutas de diagnóstico del listener de administración
+func TestHandleAdminRequest(t *testing.T) {
+	manager := jobs.NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("mock", func(ctx context.Context, params map[string]string, p *jobs.Progress) (any, error) {
+		return nil, nil
+	}, 1, 4, 1*time.Second)
+	defer manager.Close()
+
+	code, ctype, body := HandleAdminRequest("GET", "/debug/manager", manager)
+	if code != 200 || ctype != "application/json" {
+		t.Fatalf("/debug/manager code = %d, content-type = %s; se esperaba 200 JSON", code, ctype)
+	}
+	var dump jobs.ManagerDump
+	if err := json.Unmarshal(body, &dump); err != nil {
+		t.Fatalf("/debug/manager no devolvió JSON válido: %v", err)
+	}
+	if _, ok := dump.Pools["mock"]; !ok {
+		t.Errorf("/debug/manager no incluye el pool 'mock': %s", body)
+	}
+
+	code, _, body = HandleAdminRequest("GET", "/debug/runtime", manager)
+	if code != 200 || !strings.Contains(string(body), "goroutines") {
+		t.Errorf("/debug/runtime code = %d, body = %s", code, body)
+	}
+
+	code, ctype, body = HandleAdminRequest("GET", "/debug/pprof/goroutine?debug=1", manager)
+	if code != 200 || !strings.HasPrefix(ctype, "text/plain") || len(body) == 0 {
+		t.Errorf("/debug/pprof/goroutine code = %d, content-type = %s", code, ctype)
+	}
+
+	code, _, _ = HandleAdminRequest("GET", "/debug/pprof/no-existe", manager)
+	if code != 404 {
+		t.Errorf("/debug/pprof/no-existe code = %d; se esperaba 404", code)
+	}
+
+	code, _, body = HandleAdminRequest("GET", "/nodes", manager)
+	if code != 200 || strings.TrimSpace(string(body)) != "[]" {
+		t.Errorf("/nodes sin nodos = %d %s; se esperaba []", code, body)
+	}
+}
+
+// TestHandleAdminRequest_Pools prueba las rutas de administración de pools
+func TestHandleAdminRequest_Pools(t *testing.T) {
+	manager := jobs.NewManager("", 1*time.Minute, 1*time.Minute)
+	manager.Register("mock", func(ctx context.Context, params map[string]string, p *jobs.Progress) (any, error) {
+		return nil, nil
+	}, 1, 4, 1*time.Second)
+	defer manager.Close()
+
+	code, _, body := HandleAdminRequest("GET", "/pools/resize?task=mock&workers=3&queue=8", manager)
+	var st jobs.PoolState
+	if code != 200 || json.Unmarshal(body, &st) != nil || st.Workers != 3 || st.QueueCapacity != 8 {
+		t.Fatalf("/pools/resize = %d %s", code, body)
+	}
+
+	code, _, body = HandleAdminRequest("GET", "/pools/pause?task=mock", manager)
+	if code != 200 || !strings.Contains(string(body), `"paused":true`) {
+		t.Errorf("/pools/pause = %d %s", code, body)
+	}
+	code, _, body = HandleAdminRequest("GET", "/pools", manager)
+	if code != 200 || !strings.Contains(string(body), `"mock"`) {
+		t.Errorf("/pools = %d %s", code, body)
+	}
+
+	code, _, body = HandleAdminRequest("GET", "/pools/drain?task=mock&timeout=1s", manager)
+	if code != 200 || !strings.Contains(string(body), `"disabled":true`) || !strings.Contains(string(body), `"paused":false`) {
+		t.Errorf("/pools/drain = %d %s", code, body)
+	}
+	if code, _ := HandleRequest("GET", "/jobs/submit?task=mock", manager); code != 503 {
+		t.Errorf("/jobs/submit a una tarea drenada code = %d; se esperaba 503", code)
+	}
+	if code, _, _ := HandleAdminRequest("GET", "/pools/enable?task=mock", manager); code != 200 {
+		t.Errorf("/pools/enable code = %d", code)
+	}
+
+	cases := map[string]int{
+		"/pools/resize?task=mock":           400,
+		"/pools/resize?task=mock&workers=0": 400,
+		"/pools/drain?task=mock&timeout=x":  400,
+		"/pools/pause":                      400,
+		"/pools/pause?task=nada":            404,
+		"/pools/otra?task=mock":             404,
+	}
+	for path, want := range cases {
+		if code, _, _ := HandleAdminRequest("GET", path, manager); code != want {
+			t.Errorf("%s code = %d; se esperaba %d", path, code, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/url"
+
+	"P1/jobs"
+)
+
+// Rutas síncronas que son funciones puras de sus parámetros, con la tarea
+// del Manager cuyas opciones WithCache y WithCoalescing deciden si se
+// cachean y si las peticiones idénticas simultáneas comparten el cálculo.
+var pureRoutes = map[string]string{
+	"/isprime":    "isprime",
+	"/factor":     "factor",
+	"/pi":         "pi",
+	"/mandelbrot": "mandelbrot",
+	"/matrixmul":  "matrixmul",
+}
+
+// Prefijo de las claves de las rutas síncronas: su cuerpo no tiene el
+// mismo formato que el resultado del job de la misma tarea.
+const syncCacheNamespace = "sync:"
+
+// handlePure atiende una ruta síncrona pura. Con caché informa HIT o MISS
+// en el header X-Cache y solo guarda las respuestas 200; con coalescing,
+// X-Coalesced indica que la respuesta se calculó para otra petición.
+func handlePure(task string, params url.Values, path string, manager jobs.ManagerInterface) (HTTPResponse, bool) {
+	c := manager.CacheFor(task)
+	coalesce := manager.Coalesces(task)
+	if c == nil && !coalesce {
+		return HTTPResponse{}, false
+	}
+
+	flat := make(map[string]string, len(params))
+	for k, v := range params {
+		if len(v) > 0 {
+			flat[k] = v[0]
+		}
+	}
+	key := jobs.CacheKey(syncCacheNamespace+task, flat)
+	headers := map[string]string{}
+	if c != nil {
+		if data, ok := c.Get(key); ok {
+			headers["X-Cache"] = "HIT"
+			return HTTPResponse{StatusCode: 200, Body: string(data), Headers: headers}, true
+		}
+		headers["X-Cache"] = "MISS"
+	}
+
+	compute := func() (int, string) {
+		code, body := HandleRequest("GET", path, manager)
+		if c != nil && code == 200 {
+			c.Put(key, []byte(body))
+		}
+		return code, body
+	}
+	var code int
+	var body string
+	if coalesce {
+		var shared bool
+		code, body, shared = syncFlights.do(key, compute)
+		if shared {
+			headers["X-Coalesced"] = "true"
+		}
+	} else {
+		code, body = compute()
+	}
+	return HTTPResponse{StatusCode: code, Body: body, Headers: headers}, true
+}
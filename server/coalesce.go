@@ -0,0 +1,48 @@
+package server
+
+import "sync"
+
+// flightGroup comparte una misma ejecución entre peticiones idénticas
+// simultáneas: la primera calcula y el resto espera su respuesta.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	wg   sync.WaitGroup
+	code int
+	body string
+	dups int // peticiones que esperan esta ejecución
+}
+
+// Ejecuciones en curso de las rutas síncronas con coalescing.
+var syncFlights = &flightGroup{}
+
+// do ejecuta fn una sola vez por clave mientras haya una ejecución en
+// curso. shared indica que la respuesta la calculó otra petición.
+func (g *flightGroup) do(key string, fn func() (int, string)) (code int, body string, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.code, c.body, true
+	}
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.code, c.body = fn()
+	return c.code, c.body, false
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFlightGroup prueba que las llamadas simultáneas con la misma clave compartan la ejecución
+func TestFlightGroup(t *testing.T) {
+	g := &flightGroup{}
+	release := make(chan struct{})
+	calls := 0
+	fn := func() (int, string) {
+		calls++
+		<-release
+		return 200, "ok"
+	}
+
+	const n = 5
+	var wg sync.WaitGroup
+	shared := make(chan bool, n)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _, s := g.do("k", fn)
+		shared <- s
+	}()
+	// Esperar a que la primera llamada esté en curso antes de lanzar el resto
+	waitFor(t, func() bool { g.mu.Lock(); defer g.mu.Unlock(); return g.calls["k"] != nil })
+	for i := 1; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, body, s := g.do("k", fn)
+			if code != 200 || body != "ok" {
+				t.Errorf("do = %d %q", code, body)
+			}
+			shared <- s
+		}()
+	}
+	waitFor(t, func() bool { g.mu.Lock(); defer g.mu.Unlock(); return g.calls["k"].dups == n-1 })
+	close(release)
+	wg.Wait()
+	close(shared)
+
+	count := 0
+	for s := range shared {
+		if s {
+			count++
+		}
+	}
+	if calls != 1 || count != n-1 {
+		t.Errorf("ejecuciones = %d, compartidas = %d; se esperaba 1 y %d", calls, count, n-1)
+	}
+	if len(g.calls) != 0 {
+		t.Errorf("quedaron %d llamadas registradas", len(g.calls))
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("la condición no se cumplió a tiempo")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
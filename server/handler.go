@@ -9,6 +9,7 @@ import (
 	"time"
 	"P1/jobs"
 	"encoding/json"
+	"errors"
 )
 
 // HTTPResponse representa una respuesta HTTP lista para enviar.
@@ -17,6 +18,7 @@ type HTTPResponse struct {
 	StatusText string
 	Body       string
 	ContentType string
+	Headers    map[string]string // headers adicionales (opcional)
 }
 func HandleRequest(method, path string, manager jobs.ManagerInterface) (int, string) { 
 	if method != "GET" {
@@ -106,8 +108,7 @@ func HandleRequest(method, path string, manager jobs.ManagerInterface) (int, str
 	// --------------------------
 
 	case "/status":
-		body := tasks.Status(time.Now(), 0)
-		return 200, body
+		return 200, statusBody(manager)
 
 	case "/timestamp":
 		body := fmt.Sprintf(`{"timestamp": "%s"}`, tasks.Timestamp())
@@ -308,30 +309,52 @@ func HandleRequest(method, path string, manager jobs.ManagerInterface) (int, str
 	// --------------------------
 	// JOB MANAGER 
 	// --------------------------
+	case "/jobs":
+		return listJobs(params, manager)
+
 	case "/jobs/submit":
 		task := params.Get("task")
 		if task == "" {
 			return 400, `{"error": "falta parámetro 'task'"}`
 		}
 
-		// Prioridad (default = normal)
-		prioStr := params.Get("prio")
-		var prio jobs.JobPriority
-		switch prioStr {
-		case "high":
-			prio = jobs.PrioHigh
-		case "low":
-			prio = jobs.PrioLow
-		default:
-			prio = jobs.PrioNormal
+		prio := parsePriority(params)
+
+		// Ejecución diferida: run_at (RFC3339) o delay (duración, p.ej. 10m)
+		runAt, err := parseRunAt(params)
+		if err != nil {
+			return 400, fmt.Sprintf(`{"error": "%v"}`, err)
 		}
 
-		jobID, status, err := manager.Submit(task, params, prio)
+		// Idempotency-Key (header o parámetro): un reintento idéntico
+		// devuelve el job original; con otros parámetros, 409
+		jobID, status, err := manager.SubmitJob(jobs.SubmitRequest{
+			Task:           task,
+			Params:         params,
+			Priority:       prio,
+			RunAt:          runAt,
+			IdempotencyKey: params.Get(jobs.IdempotencyParam),
+		})
+		if errors.Is(err, jobs.ErrIdempotencyConflict) {
+			return 409, errorJSON(err)
+		}
+		if errors.Is(err, jobs.ErrTaskDisabled) {
+			return 503, errorJSON(err)
+		}
+		if errors.Is(err, jobs.ErrLimitExceeded) {
+			// la estimación del job excede sus límites: no se encola
+			body, _ := json.Marshal(map[string]any{"error": err.Error(), "code": jobs.ErrorCode(err)})
+			return 413, string(body)
+		}
 		if err != nil {
 			body := fmt.Sprintf(`{"error": "%v"}`, err)
 			return 400, body
 		}
 
+		if status == jobs.StatusScheduled {
+			return 200, fmt.Sprintf(`{"job_id": "%s", "status": "%s", "run_at": "%s"}`,
+				jobID, status, runAt.Format(time.RFC3339))
+		}
 		body := fmt.Sprintf(`{"job_id": "%s", "status": "%s"}`, jobID, status)
 		return 200, body
 
@@ -385,12 +408,267 @@ func HandleRequest(method, path string, manager jobs.ManagerInterface) (int, str
 	case "/metrics":
 		stats := manager.WorkerStats()
 		queues := manager.QueueSizes()
-		body, _ := json.Marshal(map[string]any{
+		metrics := map[string]any{
 			"workers": stats,
 			"queues":  queues,
+			"queues_by_priority": manager.QueueDepthsByPriority(),
 			"total_jobs": len(manager.JobsSnapshot()),
-		})
+		}
+		if cs := manager.CacheStats(); cs != nil {
+			metrics["cache"] = cs
+		}
+		body, _ := json.Marshal(metrics)
+		return 200, string(body)
+	// --------------------------
+	// HEALTH (orquestador)
+	// --------------------------
+
+	case "/healthz":
+		// Si el handler responde, el proceso está vivo.
+		return 200, `{"status":"ok"}`
+
+	case "/readyz":
+		checks := manager.Readiness()
+		failing := []jobs.HealthCheck{}
+		for _, c := range checks {
+			if !c.OK {
+				failing = append(failing, c)
+			}
+		}
+		if len(failing) > 0 {
+			body, _ := json.Marshal(map[string]any{"status": "not_ready", "failing": failing})
+			return 503, string(body)
+		}
+		body, _ := json.Marshal(map[string]any{"status": "ready", "checks": checks})
+		return 200, string(body)
+
+	// --------------------------
+	// LOTES (el envío es POST /jobs/batch, ver handlePost)
+	// --------------------------
+
+	case "/jobs/batch":
+		return 400, `{"error": "use POST con los jobs del lote en el cuerpo"}`
+
+	case "/jobs/batch/status":
+		id := parseStringParam(params, "id", "")
+		if id == "" {
+			return 400, `{"error": "falta parámetro 'id'"}`
+		}
+		st, err := manager.GetBatch(id)
+		if err != nil {
+			return 404, errorJSON(err)
+		}
+		body, _ := json.Marshal(st)
+		return 200, string(body)
+
+	case "/jobs/batch/cancel":
+		id := parseStringParam(params, "id", "")
+		if id == "" {
+			return 400, `{"error": "falta parámetro 'id'"}`
+		}
+		n, err := manager.CancelBatch(id)
+		if err != nil {
+			return 404, errorJSON(err)
+		}
+		return 200, fmt.Sprintf(`{"batch_id": "%s", "canceled": %d}`, id, n)
+
+	// --------------------------
+	// DEAD-LETTER QUEUE
+	// --------------------------
+
+	case "/jobs/dlq":
+		f, err := parseDeadLetterFilter(params)
+		if err != nil {
+			return 400, errorJSON(err)
+		}
+		entries := manager.DeadLetters(f)
+		body, _ := json.Marshal(map[string]any{"count": len(entries), "entries": entries})
+		return 200, string(body)
+
+	case "/jobs/dlq/requeue":
+		id := parseStringParam(params, "id", "")
+		if id == "" {
+			return 400, `{"error": "falta parámetro 'id'"}`
+		}
+		// set.<param>=<valor> sobrescribe parámetros del job original
+		overrides := map[string]string{}
+		for k, v := range params {
+			if name, ok := strings.CutPrefix(k, "set."); ok && name != "" && len(v) > 0 {
+				overrides[name] = v[0]
+			}
+		}
+
+		jobID, err := manager.RequeueDeadLetter(id, overrides)
+		if errors.Is(err, jobs.ErrDeadLetterNotFound) {
+			return 404, errorJSON(err)
+		}
+		if errors.Is(err, jobs.ErrTaskDisabled) {
+			// la entrada sigue en la DLQ: se reencola cuando se habilite la tarea
+			return 503, errorJSON(err)
+		}
+		if errors.Is(err, jobs.ErrLimitExceeded) {
+			body, _ := json.Marshal(map[string]any{"error": err.Error(), "code": jobs.ErrorCode(err)})
+			return 413, string(body)
+		}
+		if err != nil {
+			return 400, errorJSON(err)
+		}
+		return 200, fmt.Sprintf(`{"job_id": "%s", "requeued_from": "%s", "status": "%s"}`, jobID, id, jobs.StatusQueued)
+
+	case "/jobs/dlq/purge":
+		f, err := parseDeadLetterFilter(params)
+		if err != nil {
+			return 400, errorJSON(err)
+		}
+		// sin filtros hay que pedir explícitamente vaciar toda la DLQ; limit
+		// acota cuántas se borran pero no es un filtro
+		unfiltered := f
+		unfiltered.Limit = 0
+		if unfiltered == (jobs.DeadLetterFilter{}) && params.Get("all") != "true" {
+			return 400, `{"error": "indique un filtro o all=true"}`
+		}
+		n, err := manager.PurgeDeadLetters(f)
+		if err != nil {
+			return 500, errorJSON(err)
+		}
+		return 200, fmt.Sprintf(`{"purged": %d}`, n)
+
+	// --------------------------
+	// RECUPERACIÓN AL ARRANCAR
+	// --------------------------
+
+	case "/jobs/recovery":
+		body, _ := json.Marshal(map[string]any{"tasks": manager.RecoveryReport()})
 		return 200, string(body)
+
+	// --------------------------
+	// JOBS RECURRENTES (cron)
+	// --------------------------
+
+	case "/schedules":
+		list := manager.Schedules()
+		body, _ := json.Marshal(map[string]any{"count": len(list), "schedules": list})
+		return 200, string(body)
+
+	case "/schedules/create":
+		spec := jobs.ScheduleSpec{
+			Name:     params.Get("name"),
+			Cron:     params.Get("cron"),
+			Task:     params.Get("task"),
+			Priority: parsePriority(params),
+			Overlap:  jobs.OverlapPolicy(params.Get("overlap")),
+			Params:   map[string]string{},
+		}
+		if spec.Cron == "" || spec.Task == "" {
+			return 400, `{"error": "faltan parámetros 'cron' y/o 'task'"}`
+		}
+		// El resto de los parámetros son los de la tarea, como en /jobs/submit
+		for k, v := range params {
+			if !scheduleReserved[k] && len(v) > 0 {
+				spec.Params[k] = v[0]
+			}
+		}
+		sch, err := manager.CreateSchedule(spec)
+		if err != nil {
+			return 400, errorJSON(err)
+		}
+		body, _ := json.Marshal(sch)
+		return 200, string(body)
+
+	case "/schedules/get":
+		id := parseStringParam(params, "id", "")
+		if id == "" {
+			return 400, `{"error": "falta parámetro 'id'"}`
+		}
+		sch, err := manager.GetSchedule(id)
+		if err != nil {
+			return 404, errorJSON(err)
+		}
+		body, _ := json.Marshal(sch)
+		return 200, string(body)
+
+	case "/schedules/update":
+		id := parseStringParam(params, "id", "")
+		if id == "" {
+			return 400, `{"error": "falta parámetro 'id'"}`
+		}
+		var u jobs.ScheduleUpdate
+		if params.Has("cron") {
+			cron := params.Get("cron")
+			u.Cron = &cron
+		}
+		if params.Has("overlap") {
+			overlap := jobs.OverlapPolicy(params.Get("overlap"))
+			u.Overlap = &overlap
+		}
+		if params.Has("paused") {
+			paused, err := strconv.ParseBool(params.Get("paused"))
+			if err != nil {
+				return 400, `{"error": "parámetro 'paused' inválido (true/false)"}`
+			}
+			u.Paused = &paused
+		}
+		// set.<param>=<valor> cambia un parámetro de la tarea (vacío = borrarlo)
+		u.Params = map[string]string{}
+		for k, v := range params {
+			if name, ok := strings.CutPrefix(k, "set."); ok && name != "" && len(v) > 0 {
+				u.Params[name] = v[0]
+			}
+		}
+
+		sch, err := manager.UpdateSchedule(id, u)
+		if errors.Is(err, jobs.ErrScheduleNotFound) {
+			return 404, errorJSON(err)
+		}
+		if err != nil {
+			return 400, errorJSON(err)
+		}
+		body, _ := json.Marshal(sch)
+		return 200, string(body)
+
+	case "/schedules/delete":
+		id := parseStringParam(params, "id", "")
+		if id == "" {
+			return 400, `{"error": "falta parámetro 'id'"}`
+		}
+		if err := manager.DeleteSchedule(id); err != nil {
+			return 404, errorJSON(err)
+		}
+		return 200, fmt.Sprintf(`{"id": "%s", "deleted": true}`, id)
+
+	// --------------------------
+	// WORKFLOWS (el envío es POST, ver handlePost)
+	// --------------------------
+
+	case "/workflows/submit":
+		return 400, `{"error": "use POST con la definición del workflow en el cuerpo"}`
+
+	case "/workflows/status":
+		id := parseStringParam(params, "id", "")
+		if id == "" {
+			return 400, `{"error": "falta parámetro 'id'"}`
+		}
+		wf, err := manager.GetWorkflow(id)
+		if err != nil {
+			return 404, errorJSON(err)
+		}
+		body, _ := json.Marshal(wf)
+		return 200, string(body)
+
+	case "/workflows/cancel":
+		id := parseStringParam(params, "id", "")
+		if id == "" {
+			return 400, `{"error": "falta parámetro 'id'"}`
+		}
+		wf, err := manager.CancelWorkflow(id)
+		if errors.Is(err, jobs.ErrWorkflowNotFound) {
+			return 404, errorJSON(err)
+		}
+		if err != nil {
+			return 400, errorJSON(err)
+		}
+		return 200, fmt.Sprintf(`{"workflow_id": "%s", "status": "%s"}`, wf.ID, wf.Status)
+
 	// --------------------------
 	// JOB CLEANUP
 	// --------------------------
@@ -404,6 +682,46 @@ func HandleRequest(method, path string, manager jobs.ManagerInterface) (int, str
 }
 
 
+// handlePost atiende las rutas que reciben un cuerpo JSON. Cualquier otra
+// ruta sigue el camino de HandleRequest (que rechaza métodos distintos de GET).
+func handlePost(req *Request, route string, params url.Values, manager jobs.ManagerInterface) HTTPResponse {
+	switch route {
+	case "/jobs/batch":
+		var spec jobs.BatchSpec
+		if err := json.Unmarshal(req.Body, &spec); err != nil {
+			return HTTPResponse{StatusCode: 400, Body: errorJSON(fmt.Errorf("JSON inválido: %w", err))}
+		}
+		if m := params.Get("mode"); m != "" {
+			spec.Mode = m
+		}
+		res, err := manager.SubmitBatch(spec)
+		if err != nil {
+			return HTTPResponse{StatusCode: 400, Body: errorJSON(err)}
+		}
+		body, _ := json.Marshal(res)
+		// Un lote atómico rechazado no creó ningún job
+		if res.Mode == jobs.BatchAtomic && res.Accepted == 0 {
+			return HTTPResponse{StatusCode: 400, Body: string(body)}
+		}
+		return HTTPResponse{StatusCode: 200, Body: string(body)}
+
+	case "/workflows/submit":
+		var spec jobs.WorkflowSpec
+		if err := json.Unmarshal(req.Body, &spec); err != nil {
+			return HTTPResponse{StatusCode: 400, Body: errorJSON(fmt.Errorf("JSON inválido: %w", err))}
+		}
+		wf, err := manager.SubmitWorkflow(spec)
+		if err != nil {
+			return HTTPResponse{StatusCode: 400, Body: errorJSON(err)}
+		}
+		body, _ := json.Marshal(wf)
+		return HTTPResponse{StatusCode: 200, Body: string(body)}
+	}
+
+	code, body := HandleRequest(req.Method, req.Path, manager)
+	return HTTPResponse{StatusCode: code, Body: body}
+}
+
 // ---------------------------
 // Funciones auxiliares
 // ---------------------------
@@ -420,6 +738,68 @@ func parseIntParam(params url.Values, key string, def int) int {
 	return num
 }
 
+// Parámetros de /schedules/create que no se pasan a la tarea.
+var scheduleReserved = map[string]bool{"name": true, "cron": true, "task": true, "prio": true, "overlap": true}
+
+// errorJSON arma {"error": ...} escapando el mensaje (los errores de
+// validación suelen citar valores entre comillas).
+func errorJSON(err error) string {
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(body)
+}
+
+// parsePriority lee prio=high|normal|low (default = normal).
+func parsePriority(params url.Values) jobs.JobPriority {
+	return jobs.ParsePriority(params.Get("prio"))
+}
+
+// parseRunAt interpreta run_at (RFC3339) o delay (time.ParseDuration).
+// Devuelve la hora cero si no se pidió ejecución diferida.
+func parseRunAt(params url.Values) (time.Time, error) {
+	runAtStr, delayStr := params.Get("run_at"), params.Get("delay")
+	switch {
+	case runAtStr != "" && delayStr != "":
+		return time.Time{}, fmt.Errorf("use 'run_at' o 'delay', no ambos")
+	case runAtStr != "":
+		t, err := time.Parse(time.RFC3339, runAtStr)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parámetro 'run_at' inválido (use RFC3339)")
+		}
+		return t, nil
+	case delayStr != "":
+		d, err := time.ParseDuration(delayStr)
+		if err != nil || d < 0 {
+			return time.Time{}, fmt.Errorf("parámetro 'delay' inválido (p.ej. 90s, 10m, 2h)")
+		}
+		return time.Now().Add(d), nil
+	}
+	return time.Time{}, nil
+}
+
+// parseDeadLetterFilter arma el filtro de la DLQ desde los parámetros
+// id, task, class, reason, since, until (RFC3339) y limit.
+func parseDeadLetterFilter(params url.Values) (jobs.DeadLetterFilter, error) {
+	f := jobs.DeadLetterFilter{
+		ID:     params.Get("id"),
+		Task:   params.Get("task"),
+		Reason: params.Get("reason"),
+		Class:  jobs.ErrorClass(params.Get("class")),
+		Limit:  parseIntParam(params, "limit", 0),
+	}
+	for key, dst := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		v := params.Get(key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, fmt.Errorf("parámetro '%s' inválido (use RFC3339)", key)
+		}
+		*dst = t
+	}
+	return f, nil
+}
+
 func parseStringParam(params url.Values, key string, def string) string {
 	value := params.Get(key)
 	if value == "" {
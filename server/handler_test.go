@@ -12,15 +12,41 @@ import (
 
 type mockManager struct {
 	jobs.ManagerInterface
+
+	deadLetters      []jobs.DeadLetter
+	requeueOverrides map[string]string
+	scheduleUpdate   jobs.ScheduleUpdate
+	cache            *jobs.ResultCache // compartida por todas las tareas
+	coalesce         bool
+	listQuery        jobs.JobQuery // última consulta de ListJobs
 }
 
 func (m *mockManager) Submit(task string, params url.Values, prio jobs.JobPriority) (string, jobs.JobStatus, error) {
 	if task == "pi" {
 		return "job-123", jobs.StatusQueued, nil
 	}
+	if task == "maint" {
+		return "", "", jobs.ErrTaskDisabled
+	}
+	if task == "huge" {
+		return "", "", &jobs.LimitError{Code: jobs.LimitMemory, Limit: 1 << 20, Used: 1 << 30, Estimated: true}
+	}
 	return "", "", jobs.ErrTaskNotFound
 }
 
+func (m *mockManager) SubmitJob(req jobs.SubmitRequest) (string, jobs.JobStatus, error) {
+	if req.IdempotencyKey == "used" {
+		return "", "", jobs.ErrIdempotencyConflict
+	}
+	if req.IdempotencyKey != "" {
+		return "job-" + req.IdempotencyKey, jobs.StatusQueued, nil
+	}
+	if !req.RunAt.IsZero() {
+		return "job-456", jobs.StatusScheduled, nil
+	}
+	return m.Submit(req.Task, req.Params, req.Priority)
+}
+
 func (m *mockManager) GetStatus(jobID string) (*jobs.Job, error) {
 	if jobID == "job-123" {
 		return &jobs.Job{ID: "job-123", Status: jobs.StatusDone, Result: "simulated_result"}, nil
@@ -41,10 +67,118 @@ func (m *mockManager) Cancel(jobID string) (jobs.JobStatus, error) {
 
 func (m *mockManager) WorkerStats() map[string]any                { return nil }
 func (m *mockManager) QueueSizes() map[string]int                 { return nil }
+func (m *mockManager) WorkerStatus() map[string][]jobs.WorkerInfo {
+	return map[string][]jobs.WorkerInfo{"pi": {{PID: 4242, Status: "busy", JobID: "job-123"}}}
+}
+func (m *mockManager) QueueDepthsByPriority() map[string]map[string]int { return nil }
 func (m *mockManager) JobsSnapshot() map[string]*jobs.Job          { return nil }
 func (m *mockManager) CleanupOnce()                               {}
+func (m *mockManager) Readiness() []jobs.HealthCheck {
+	return []jobs.HealthCheck{{Name: "persistence", Reason: "disco de solo lectura"}}
+}
+func (m *mockManager) DeadLetters(f jobs.DeadLetterFilter) []jobs.DeadLetter {
+	return m.deadLetters
+}
+func (m *mockManager) RequeueDeadLetter(id string, overrides map[string]string) (string, error) {
+	if id != "dead-1" {
+		return "", jobs.ErrDeadLetterNotFound
+	}
+	m.requeueOverrides = overrides
+	return "new-1", nil
+}
+func (m *mockManager) PurgeDeadLetters(f jobs.DeadLetterFilter) (int, error) { return 1, nil }
+func (m *mockManager) CreateSchedule(spec jobs.ScheduleSpec) (*jobs.Schedule, error) {
+	if spec.Cron == "bad" {
+		return nil, jobs.ErrInvalidCron
+	}
+	return &jobs.Schedule{ID: "sch-1", Cron: spec.Cron, Task: spec.Task, Params: spec.Params}, nil
+}
+func (m *mockManager) Schedules() []jobs.Schedule { return []jobs.Schedule{{ID: "sch-1"}} }
+func (m *mockManager) GetSchedule(id string) (*jobs.Schedule, error) {
+	if id != "sch-1" {
+		return nil, jobs.ErrScheduleNotFound
+	}
+	return &jobs.Schedule{ID: id}, nil
+}
+func (m *mockManager) UpdateSchedule(id string, u jobs.ScheduleUpdate) (*jobs.Schedule, error) {
+	if id != "sch-1" {
+		return nil, jobs.ErrScheduleNotFound
+	}
+	m.scheduleUpdate = u
+	return &jobs.Schedule{ID: id}, nil
+}
+func (m *mockManager) DeleteSchedule(id string) error {
+	if id != "sch-1" {
+		return jobs.ErrScheduleNotFound
+	}
+	return nil
+}
+func (m *mockManager) SubmitWorkflow(spec jobs.WorkflowSpec) (*jobs.Workflow, error) {
+	if len(spec.Steps) == 0 {
+		return nil, jobs.ErrInvalidWorkflow
+	}
+	return &jobs.Workflow{ID: "wf-1", Status: jobs.WorkflowRunning}, nil
+}
+func (m *mockManager) GetWorkflow(id string) (*jobs.Workflow, error) {
+	if id != "wf-1" {
+		return nil, jobs.ErrWorkflowNotFound
+	}
+	return &jobs.Workflow{ID: id, Status: jobs.WorkflowDone}, nil
+}
+func (m *mockManager) CancelWorkflow(id string) (*jobs.Workflow, error) {
+	if id != "wf-1" {
+		return nil, jobs.ErrWorkflowNotFound
+	}
+	return &jobs.Workflow{ID: id, Status: jobs.WorkflowCanceled}, nil
+}
+func (m *mockManager) SubmitBatch(spec jobs.BatchSpec) (*jobs.BatchResult, error) {
+	if len(spec.Jobs) == 0 {
+		return nil, jobs.ErrBatchEmpty
+	}
+	res := &jobs.BatchResult{ID: "batch-1", Mode: spec.Mode}
+	if spec.Mode == jobs.BatchAtomic {
+		res.Rejected = len(spec.Jobs) // el mock rechaza todo lote atómico
+	} else {
+		res.Accepted = len(spec.Jobs)
+	}
+	return res, nil
+}
+func (m *mockManager) GetBatch(id string) (*jobs.BatchStatus, error) {
+	if id != "batch-1" {
+		return nil, jobs.ErrBatchNotFound
+	}
+	return &jobs.BatchStatus{ID: id, Total: 2, Counts: map[jobs.JobStatus]int{jobs.StatusDone: 2}, Finished: true}, nil
+}
+func (m *mockManager) CancelBatch(id string) (int, error) {
+	if id != "batch-1" {
+		return 0, jobs.ErrBatchNotFound
+	}
+	return 2, nil
+}
+func (m *mockManager) CacheFor(task string) *jobs.ResultCache { return m.cache }
+func (m *mockManager) Coalesces(task string) bool              { return m.coalesce }
+func (m *mockManager) CacheStats() *jobs.CacheStats {
+	if m.cache == nil {
+		return nil
+	}
+	st := m.cache.Stats()
+	return &st
+}
+func (m *mockManager) ListJobs(q jobs.JobQuery) (jobs.JobPage, error) {
+	m.listQuery = q
+	return jobs.JobPage{
+		Jobs: []jobs.Job{
+			{ID: "job-2", Task: "pi", Status: jobs.StatusDone, Result: map[string]any{"digits": "3.14159"}},
+			{ID: "job-1", Task: "pi", Status: jobs.StatusDone, Result: map[string]any{"digits": "3.14"}},
+		},
+		NextCursor: "abc",
+	}, nil
+}
+func (m *mockManager) RecoveryReport() map[string]jobs.RecoveryStats {
+	return map[string]jobs.RecoveryStats{"sortfile": {Queued: 2, Retried: 1}}
+}
 func (m *mockManager) Close()                                     {}
-func (m *mockManager) Register(name string, task jobs.TaskFunc, workers int, queueDepth int, timeout time.Duration) {}
+func (m *mockManager) Register(name string, task jobs.TaskFunc, workers int, queueDepth int, timeout time.Duration, opts ...jobs.TaskOption) {}
 
 
 func createTempFile(t *testing.T, content string) string {
@@ -69,6 +203,9 @@ func TestHandleRequest_Sync(t *testing.T) {
 	if !strings.Contains(body, "uptime") {
 		t.Errorf("/status body = %s; se esperaba JSON de status", body)
 	}
+	if !strings.Contains(body, `"worker_pid":4242`) || !strings.Contains(body, "server_pid") {
+		t.Errorf("/status body = %s; se esperaban los pids de servidor y workers", body)
+	}
 
 	code, body = HandleRequest("GET", "/ruta-que-no-existe", mockMgr)
 	if code != 404 {
@@ -102,6 +239,28 @@ func TestHandleRequest_Jobs(t *testing.T) {
 		t.Errorf("/jobs/submit (sin task) code = %d; se esperaba 400", code)
 	}
 
+	code, body = HandleRequest("GET", "/jobs/submit?task=sortfile&run_at=2030-01-01T02:00:00Z", mockMgr)
+	if code != 200 || !strings.Contains(body, `"scheduled"`) || !strings.Contains(body, "2030-01-01T02:00:00Z") {
+		t.Errorf("/jobs/submit (run_at) = %d %s; se esperaba scheduled con run_at", code, body)
+	}
+
+	for _, q := range []string{"delay=10m&run_at=2030-01-01T02:00:00Z", "delay=-5s", "delay=mañana", "run_at=02:00"} {
+		code, _ = HandleRequest("GET", "/jobs/submit?task=sortfile&"+q, mockMgr)
+		if code != 400 {
+			t.Errorf("/jobs/submit?%s code = %d; se esperaba 400", q, code)
+		}
+	}
+
+	code, _ = HandleRequest("GET", "/jobs/submit?task=maint", mockMgr)
+	if code != 503 {
+		t.Errorf("/jobs/submit (tarea deshabilitada) code = %d; se esperaba 503", code)
+	}
+
+	code, body = HandleRequest("GET", "/jobs/submit?task=huge", mockMgr)
+	if code != 413 || !strings.Contains(body, `"code":"memory_limit"`) {
+		t.Errorf("/jobs/submit (límite excedido) = %d %s; se esperaba 413 con memory_limit", code, body)
+	}
+
 	code, body = HandleRequest("GET", "/jobs/status?id=job-123", mockMgr)
 	if code != 200 {
 		t.Errorf("/jobs/status code = %d; se esperaba 200", code)
@@ -130,6 +289,161 @@ func TestHandleRequest_Jobs(t *testing.T) {
 	if code != 200 {
 		t.Errorf("/metrics code = %d; se esperaba 200", code)
 	}
+
+	code, _ = HandleRequest("GET", "/healthz", mockMgr)
+	if code != 200 {
+		t.Errorf("/healthz code = %d; se esperaba 200", code)
+	}
+
+	code, body = HandleRequest("GET", "/readyz", mockMgr)
+	if code != 503 {
+		t.Errorf("/readyz code = %d; se esperaba 503", code)
+	}
+	if !strings.Contains(body, "disco de solo lectura") {
+		t.Errorf("/readyz body = %s; se esperaba el motivo del fallo", body)
+	}
+}
+
+// TestHandleRequest_DLQ prueba las rutas de la dead-letter queue
+func TestHandleRequest_DLQ(t *testing.T) {
+	mockMgr := &mockManager{deadLetters: []jobs.DeadLetter{
+		{Job: jobs.Job{ID: "dead-1", Task: "sortfile"}, Reason: jobs.ReasonExhausted, Class: jobs.ClassIO},
+	}}
+
+	code, body := HandleRequest("GET", "/jobs/dlq?task=sortfile", mockMgr)
+	if code != 200 || !strings.Contains(body, "dead-1") {
+		t.Errorf("/jobs/dlq = %d %s; se esperaba 200 con 'dead-1'", code, body)
+	}
+
+	code, _ = HandleRequest("GET", "/jobs/dlq?since=ayer", mockMgr)
+	if code != 400 {
+		t.Errorf("/jobs/dlq (since inválido) code = %d; se esperaba 400", code)
+	}
+
+	code, body = HandleRequest("GET", "/jobs/dlq/requeue?id=dead-1&set.algo=merge", mockMgr)
+	if code != 200 || !strings.Contains(body, "new-1") {
+		t.Errorf("/jobs/dlq/requeue = %d %s; se esperaba 200 con 'new-1'", code, body)
+	}
+	if mockMgr.requeueOverrides["algo"] != "merge" {
+		t.Errorf("overrides = %v; se esperaba algo=merge", mockMgr.requeueOverrides)
+	}
+
+	code, _ = HandleRequest("GET", "/jobs/dlq/requeue?id=nope", mockMgr)
+	if code != 404 {
+		t.Errorf("/jobs/dlq/requeue (id inexistente) code = %d; se esperaba 404", code)
+	}
+
+	code, _ = HandleRequest("GET", "/jobs/dlq/purge", mockMgr)
+	if code != 400 {
+		t.Errorf("/jobs/dlq/purge (sin filtro) code = %d; se esperaba 400", code)
+	}
+	code, _ = HandleRequest("GET", "/jobs/dlq/purge?limit=1", mockMgr)
+	if code != 400 {
+		t.Errorf("/jobs/dlq/purge?limit=1 (sin filtro) code = %d; se esperaba 400", code)
+	}
+	code, body = HandleRequest("GET", "/jobs/dlq/purge?all=true", mockMgr)
+	if code != 200 || !strings.Contains(body, `"purged": 1`) {
+		t.Errorf("/jobs/dlq/purge?all=true = %d %s; se esperaba 200", code, body)
+	}
+}
+
+// TestHandleRequest_ListJobs prueba filtros, proyección y cursor de /jobs
+func TestHandleRequest_ListJobs(t *testing.T) {
+	mockMgr := &mockManager{}
+	code, body := HandleRequest("GET", "/jobs?task=pi&status=done&prio=high&sort=updated_at&order=asc&limit=2&fields=id,status", mockMgr)
+	if code != 200 {
+		t.Fatalf("/jobs = %d %s; se esperaba 200", code, body)
+	}
+	q := mockMgr.listQuery
+	if q.Task != "pi" || q.Status != jobs.StatusDone || q.Priority == nil || *q.Priority != jobs.PrioHigh ||
+		q.Sort != jobs.SortUpdated || q.Desc || q.Limit != 2 || !q.OmitResult {
+		t.Errorf("consulta = %+v", q)
+	}
+	if strings.Contains(body, "3.14") || !strings.Contains(body, `"status":"done"`) || !strings.Contains(body, `"next_cursor":"abc"`) {
+		t.Errorf("/jobs con fields = %s; se esperaba solo id y status, con next_cursor", body)
+	}
+
+	HandleRequest("GET", "/jobs", mockMgr)
+	if q := mockMgr.listQuery; !q.Desc || q.Limit != 50 || q.OmitResult {
+		t.Errorf("consulta por defecto = %+v; se esperaba desc con limit 50 y result", q)
+	}
+
+	// limit=0 significa sin límite, como en JobQuery
+	if code, _ := HandleRequest("GET", "/jobs?limit=0&fields=id,result", mockMgr); code != 200 {
+		t.Errorf("/jobs?limit=0 code = %d; se esperaba 200", code)
+	}
+	if q := mockMgr.listQuery; q.Limit != 0 || q.OmitResult {
+		t.Errorf("consulta con limit=0 = %+v; se esperaba sin límite y con result", q)
+	}
+
+	for _, path := range []string{
+		"/jobs?status=perdido",
+		"/jobs?sort=nombre",
+		"/jobs?created_after=ayer",
+		"/jobs?limit=-1",
+		"/jobs?limit=1001",
+		"/jobs?limit=diez",
+		"/jobs?fields=id,nada",
+		"/jobs?prio=urgente",
+	} {
+		if code, _ := HandleRequest("GET", path, mockMgr); code != 400 {
+			t.Errorf("%s code = %d; se esperaba 400", path, code)
+		}
+	}
+}
+
+// TestHandleRequest_Recovery prueba el reporte de la recuperación al arrancar
+func TestHandleRequest_Recovery(t *testing.T) {
+	code, body := HandleRequest("GET", "/jobs/recovery", &mockManager{})
+	if code != 200 || !strings.Contains(body, `"sortfile":{"queued":2,"retried":1`) {
+		t.Errorf("/jobs/recovery = %d %s; se esperaba 200 con el reporte de sortfile", code, body)
+	}
+}
+
+// TestHandleRequest_Schedules prueba el CRUD de jobs recurrentes
+func TestHandleRequest_Schedules(t *testing.T) {
+	mockMgr := &mockManager{}
+
+	code, body := HandleRequest("GET", "/schedules/create?cron=0+2+*+*+*&task=wordcount&file=datos.txt", mockMgr)
+	if code != 200 || !strings.Contains(body, `"cron":"0 2 * * *"`) || !strings.Contains(body, `"file":"datos.txt"`) {
+		t.Errorf("/schedules/create = %d %s; se esperaba el schedule con sus parámetros", code, body)
+	}
+	if strings.Count(body, "0 2 * * *") != 1 || strings.Count(body, "wordcount") != 1 {
+		t.Errorf("/schedules/create body = %s; 'cron' y 'task' no deberían pasarse a la tarea", body)
+	}
+
+	code, _ = HandleRequest("GET", "/schedules/create?cron=bad&task=wordcount", mockMgr)
+	if code != 400 {
+		t.Errorf("/schedules/create (cron inválido) code = %d; se esperaba 400", code)
+	}
+	code, _ = HandleRequest("GET", "/schedules/create?task=wordcount", mockMgr)
+	if code != 400 {
+		t.Errorf("/schedules/create (sin cron) code = %d; se esperaba 400", code)
+	}
+
+	code, _ = HandleRequest("GET", "/schedules", mockMgr)
+	if code != 200 {
+		t.Errorf("/schedules code = %d; se esperaba 200", code)
+	}
+
+	code, _ = HandleRequest("GET", "/schedules/update?id=sch-1&paused=true&overlap=queue&set.file=otro.txt", mockMgr)
+	u := mockMgr.scheduleUpdate
+	if code != 200 || u.Paused == nil || !*u.Paused || u.Overlap == nil || *u.Overlap != jobs.OverlapQueue || u.Params["file"] != "otro.txt" || u.Cron != nil {
+		t.Errorf("/schedules/update code = %d, update = %+v; se esperaban paused, overlap y file", code, u)
+	}
+	code, _ = HandleRequest("GET", "/schedules/update?id=sch-1&paused=quizas", mockMgr)
+	if code != 400 {
+		t.Errorf("/schedules/update (paused inválido) code = %d; se esperaba 400", code)
+	}
+
+	for _, route := range []string{"/schedules/get?id=x", "/schedules/update?id=x", "/schedules/delete?id=x"} {
+		if code, _ := HandleRequest("GET", route, mockMgr); code != 404 {
+			t.Errorf("%s code = %d; se esperaba 404", route, code)
+		}
+	}
+	if code, _ := HandleRequest("GET", "/schedules/delete?id=sch-1", mockMgr); code != 200 {
+		t.Errorf("/schedules/delete code = %d; se esperaba 200", code)
+	}
 }
 
 // TestHandleRequest_Sync_CPU prueba las rutas de CPU síncronas
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"P1/jobs"
+)
+
+// Tamaño de página de /jobs cuando no se indica limit.
+const defaultListLimit = 50
+
+// parseJobQuery arma la consulta de /jobs desde task, status, prio,
+// created_after/created_before, updated_after/updated_before (RFC3339),
+// sort, order (asc|desc, por defecto desc), limit (0 = sin límite) y cursor.
+func parseJobQuery(params url.Values) (jobs.JobQuery, error) {
+	q := jobs.JobQuery{
+		Task:   params.Get("task"),
+		Status: jobs.JobStatus(params.Get("status")),
+		Sort:   params.Get("sort"),
+		Cursor: params.Get("cursor"),
+		Limit:  defaultListLimit,
+	}
+	if v := params.Get("prio"); v != "" {
+		if v != "high" && v != "normal" && v != "low" {
+			return q, fmt.Errorf("parámetro 'prio' inválido (use high, normal o low)")
+		}
+		p := jobs.ParsePriority(v)
+		q.Priority = &p
+	}
+	switch params.Get("order") {
+	case "", "desc":
+		q.Desc = true
+	case "asc":
+	default:
+		return q, fmt.Errorf("parámetro 'order' inválido (use asc o desc)")
+	}
+	if v := params.Get("limit"); v != "" {
+		// el rango lo revisa q.Validate, igual que para cualquier JobQuery
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return q, fmt.Errorf("parámetro 'limit' inválido")
+		}
+		q.Limit = n
+	}
+	ranges := map[string]*time.Time{
+		"created_after": &q.CreatedAfter, "created_before": &q.CreatedBefore,
+		"updated_after": &q.UpdatedAfter, "updated_before": &q.UpdatedBefore,
+	}
+	for key, dst := range ranges {
+		v := params.Get(key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, fmt.Errorf("parámetro '%s' inválido (use RFC3339)", key)
+		}
+		*dst = t
+	}
+	return q, q.Validate()
+}
+
+// jobFields son los nombres JSON de los campos de un job, para validar fields.
+var jobFields = func() map[string]bool {
+	out := map[string]bool{}
+	t := reflect.TypeOf(jobs.Job{})
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "" && name != "-" {
+			out[name] = true
+		}
+	}
+	return out
+}()
+
+// parseFields lee fields=id,status,... (vacío = todos los campos).
+func parseFields(params url.Values) ([]string, error) {
+	v := params.Get("fields")
+	if v == "" {
+		return nil, nil
+	}
+	fields := strings.Split(v, ",")
+	for _, f := range fields {
+		if !jobFields[f] {
+			return nil, fmt.Errorf("campo desconocido %q en 'fields'", f)
+		}
+	}
+	return fields, nil
+}
+
+// projectJobs deja en cada job solo los campos pedidos (p.ej. para omitir
+// resultados grandes). Sin fields devuelve los jobs completos.
+func projectJobs(list []jobs.Job, fields []string) []any {
+	out := make([]any, len(list))
+	for i := range list {
+		if fields == nil {
+			out[i] = list[i]
+			continue
+		}
+		var all map[string]json.RawMessage
+		data, _ := json.Marshal(list[i])
+		json.Unmarshal(data, &all)
+		picked := make(map[string]json.RawMessage, len(fields))
+		for _, f := range fields {
+			if v, ok := all[f]; ok {
+				picked[f] = v
+			}
+		}
+		out[i] = picked
+	}
+	return out
+}
+
+// listJobs atiende GET /jobs.
+func listJobs(params url.Values, manager jobs.ManagerInterface) (int, string) {
+	q, err := parseJobQuery(params)
+	if err != nil {
+		return 400, errorJSON(err)
+	}
+	fields, err := parseFields(params)
+	if err != nil {
+		return 400, errorJSON(err)
+	}
+	// sin result entre los campos pedidos, la página no lo copia
+	q.OmitResult = fields != nil && !slices.Contains(fields, "result")
+	page, err := manager.ListJobs(q)
+	if err != nil {
+		return 400, errorJSON(err)
+	}
+	resp := map[string]any{"count": len(page.Jobs), "jobs": projectJobs(page.Jobs, fields)}
+	if page.NextCursor != "" {
+		resp["next_cursor"] = page.NextCursor
+	}
+	body, _ := json.Marshal(resp)
+	return 200, string(body)
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"P1/jobs"
+)
+
+// Espera máxima de /pools/drain cuando no se indica timeout.
+const defaultDrainTimeout = 30 * time.Second
+
+// poolAdmin atiende las rutas /pools del listener de administración:
+//
+//	/pools                                  estado de todos los pools
+//	/pools/resize?task=&workers=&queue=     workers y capacidad por clase
+//	/pools/pause?task=  /pools/resume?task=
+//	/pools/drain?task=&timeout=30s          deshabilita y espera a vaciar
+//	/pools/disable?task=  /pools/enable?task=
+func poolAdmin(route string, params url.Values, manager jobs.ManagerInterface) (int, []byte) {
+	if route == "/pools" {
+		body, _ := json.Marshal(map[string]any{"pools": manager.PoolStates()})
+		return 200, body
+	}
+
+	task := params.Get("task")
+	if task == "" {
+		return 400, []byte(`{"error": "falta parámetro 'task'"}`)
+	}
+
+	var state jobs.PoolState
+	var err error
+	switch route {
+	case "/pools/resize":
+		workers, werr := sizeParam(params, "workers")
+		queue, qerr := sizeParam(params, "queue")
+		if werr != nil || qerr != nil {
+			return 400, []byte(errorJSON(errors.Join(werr, qerr)))
+		}
+		if workers == 0 && queue == 0 {
+			return 400, []byte(`{"error": "indique 'workers' y/o 'queue'"}`)
+		}
+		state, err = manager.ResizePool(task, workers, queue)
+	case "/pools/pause":
+		state, err = manager.PausePool(task)
+	case "/pools/resume":
+		state, err = manager.ResumePool(task)
+	case "/pools/disable":
+		state, err = manager.SetTaskEnabled(task, false)
+	case "/pools/enable":
+		state, err = manager.SetTaskEnabled(task, true)
+	case "/pools/drain":
+		timeout := defaultDrainTimeout
+		if v := params.Get("timeout"); v != "" {
+			d, perr := time.ParseDuration(v)
+			if perr != nil || d <= 0 {
+				return 400, []byte(`{"error": "parámetro 'timeout' inválido (p.ej. 30s)"}`)
+			}
+			timeout = d
+		}
+		state, err = manager.DrainPool(task, timeout)
+	default:
+		return 404, []byte(`{"error": "Ruta no encontrada"}`)
+	}
+
+	switch {
+	case errors.Is(err, jobs.ErrTaskNotFound):
+		return 404, []byte(errorJSON(err))
+	case errors.Is(err, jobs.ErrDrainTimeout):
+		// el pool sigue deshabilitado: se informa lo que falta
+		body, _ := json.Marshal(map[string]any{"error": err.Error(), "pool": state})
+		return 503, body
+	case err != nil:
+		return 400, []byte(errorJSON(err))
+	}
+	body, _ := json.Marshal(state)
+	return 200, body
+}
+
+// sizeParam lee un tamaño positivo opcional (0 si falta).
+func sizeParam(params url.Values, key string) (int, error) {
+	v := params.Get(key)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("parámetro '%s' inválido", key)
+	}
+	return n, nil
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"P1/jobs"
+)
+
+// Tamaño máximo del cuerpo de una petición (definiciones de workflows, lotes).
+const maxBodyBytes = 1 << 20
+
+var ErrBodyTooLarge = errors.New("cuerpo de la petición demasiado grande")
+
+// Request es una petición HTTP/1.0 ya leída: línea de solicitud, headers
+// (con el nombre en minúsculas) y cuerpo según Content-Length.
+type Request struct {
+	Method  string
+	Path    string
+	Version string
+	Headers map[string]string
+	Body    []byte
+}
+
+// Header devuelve el valor de un header sin distinguir mayúsculas.
+func (r *Request) Header(name string) string {
+	return r.Headers[strings.ToLower(name)]
+}
+
+// readRequest lee la línea de solicitud, los headers y, si hay
+// Content-Length, el cuerpo.
+func readRequest(reader *bufio.Reader) (*Request, error) {
+	requestLine, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	req := &Request{Headers: map[string]string{}}
+	req.Method, req.Path, req.Version = parseRequestLine(requestLine)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil || line == "\r\n" || line == "\n" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			req.Headers[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+		}
+	}
+
+	if cl := req.Header("Content-Length"); cl != "" {
+		n, err := strconv.Atoi(cl)
+		if err != nil || n < 0 {
+			return nil, errors.New("Content-Length inválido")
+		}
+		if n > maxBodyBytes {
+			return nil, ErrBodyTooLarge
+		}
+		req.Body = make([]byte, n)
+		if _, err := io.ReadFull(reader, req.Body); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// Handle enruta una petición completa. Las rutas con cuerpo (POST) se
+// atienden aquí; el resto sigue pasando por HandleRequest.
+func Handle(req *Request, manager jobs.ManagerInterface) HTTPResponse {
+	req.Path = withIdempotencyKey(req)
+	if req.Method == "POST" {
+		route, rawQuery, _ := strings.Cut(req.Path, "?")
+		params, _ := url.ParseQuery(rawQuery)
+		return handlePost(req, route, params, manager)
+	}
+	if route, rawQuery, _ := strings.Cut(req.Path, "?"); req.Method == "GET" {
+		if task, ok := pureRoutes[route]; ok {
+			params, _ := url.ParseQuery(rawQuery)
+			if resp, ok := handlePure(task, params, req.Path, manager); ok {
+				return resp
+			}
+		}
+	}
+	code, body := HandleRequest(req.Method, req.Path, manager)
+	return HTTPResponse{StatusCode: code, Body: body}
+}
+
+// withIdempotencyKey copia el header Idempotency-Key al parámetro
+// idempotency_key de la ruta; si ambos están, manda el parámetro.
+func withIdempotencyKey(req *Request) string {
+	key := req.Header("Idempotency-Key")
+	if key == "" {
+		return req.Path
+	}
+	route, rawQuery, _ := strings.Cut(req.Path, "?")
+	params, _ := url.ParseQuery(rawQuery)
+	if params.Has(jobs.IdempotencyParam) {
+		return req.Path
+	}
+	param := url.Values{jobs.IdempotencyParam: {key}}.Encode()
+	if rawQuery == "" {
+		return route + "?" + param
+	}
+	return route + "?" + rawQuery + "&" + param
+}
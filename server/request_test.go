@@ -0,0 +1,228 @@
+package server
+
+import (
+	"P1/jobs"
+	"bufio"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReadRequest prueba la lectura de headers y cuerpo
+func TestReadRequest(t *testing.T) {
+	raw := "POST /workflows/submit HTTP/1.0\r\nHost: x\r\nContent-Type: application/json\r\nContent-Length: 13\r\n\r\n{\"steps\": []}"
+	req, err := readRequest(bufio.NewReader(strings.NewReader(raw)))
+	if err != nil {
+		t.Fatalf("readRequest devolvió un error: %v", err)
+	}
+	if req.Method != "POST" || req.Path != "/workflows/submit" || req.Version != "HTTP/1.0" {
+		t.Errorf("línea de solicitud = %s %s %s", req.Method, req.Path, req.Version)
+	}
+	if req.Header("content-type") != "application/json" {
+		t.Errorf("Header(Content-Type) = %q; se esperaba application/json", req.Header("content-type"))
+	}
+	if string(req.Body) != `{"steps": []}` {
+		t.Errorf("Body = %q", req.Body)
+	}
+
+	raw = "POST / HTTP/1.0\r\nContent-Length: 99999999\r\n\r\n"
+	if _, err := readRequest(bufio.NewReader(strings.NewReader(raw))); err != ErrBodyTooLarge {
+		t.Errorf("readRequest(cuerpo enorme) = %v; se esperaba ErrBodyTooLarge", err)
+	}
+}
+
+// TestHandle_Workflows prueba las rutas de workflows (POST y GET)
+func TestHandle_Workflows(t *testing.T) {
+	mockMgr := &mockManager{}
+	post := func(body string) HTTPResponse {
+		return Handle(&Request{Method: "POST", Path: "/workflows/submit", Body: []byte(body)}, mockMgr)
+	}
+
+	resp := post(`{"steps": [{"id": "a", "task": "pi", "params": {"digits": "10"}}]}`)
+	if resp.StatusCode != 200 || !strings.Contains(resp.Body, "wf-1") {
+		t.Errorf("POST /workflows/submit = %d %s; se esperaba 200 con wf-1", resp.StatusCode, resp.Body)
+	}
+	if resp := post(`{"steps": `); resp.StatusCode != 400 {
+		t.Errorf("POST /workflows/submit (JSON roto) code = %d; se esperaba 400", resp.StatusCode)
+	}
+	if resp := post(`{"steps": []}`); resp.StatusCode != 400 {
+		t.Errorf("POST /workflows/submit (sin pasos) code = %d; se esperaba 400", resp.StatusCode)
+	}
+
+	// POST a una ruta GET conserva el rechazo de siempre
+	if resp := Handle(&Request{Method: "POST", Path: "/status"}, mockMgr); resp.StatusCode != 400 {
+		t.Errorf("POST /status code = %d; se esperaba 400", resp.StatusCode)
+	}
+
+	if code, body := HandleRequest("GET", "/workflows/status?id=wf-1", mockMgr); code != 200 || !strings.Contains(body, `"done"`) {
+		t.Errorf("/workflows/status = %d %s; se esperaba 200 done", code, body)
+	}
+	if code, _ := HandleRequest("GET", "/workflows/status?id=x", mockMgr); code != 404 {
+		t.Errorf("/workflows/status (id inexistente) code = %d; se esperaba 404", code)
+	}
+	if code, body := HandleRequest("GET", "/workflows/cancel?id=wf-1", mockMgr); code != 200 || !strings.Contains(body, "canceled") {
+		t.Errorf("/workflows/cancel = %d %s; se esperaba 200 canceled", code, body)
+	}
+}
+
+// TestHandle_Batch prueba el envío por lote y sus consultas
+func TestHandle_Batch(t *testing.T) {
+	mockMgr := &mockManager{}
+	post := func(path, body string) HTTPResponse {
+		return Handle(&Request{Method: "POST", Path: path, Body: []byte(body)}, mockMgr)
+	}
+
+	resp := post("/jobs/batch", `{"jobs": [{"task": "pi", "params": {"digits": "10"}}, {"task": "isprime"}]}`)
+	if resp.StatusCode != 200 || !strings.Contains(resp.Body, `"accepted":2`) {
+		t.Errorf("POST /jobs/batch = %d %s; se esperaba 200 con 2 aceptados", resp.StatusCode, resp.Body)
+	}
+	// ?mode= tiene prioridad sobre el cuerpo
+	if resp := post("/jobs/batch?mode=atomic", `{"mode": "partial", "jobs": [{"task": "pi"}]}`); resp.StatusCode != 400 || !strings.Contains(resp.Body, "batch-1") {
+		t.Errorf("POST /jobs/batch?mode=atomic = %d %s; se esperaba 400 con el detalle del lote", resp.StatusCode, resp.Body)
+	}
+	if resp := post("/jobs/batch", `{"jobs": []}`); resp.StatusCode != 400 {
+		t.Errorf("POST /jobs/batch (vacío) code = %d; se esperaba 400", resp.StatusCode)
+	}
+
+	if code, body := HandleRequest("GET", "/jobs/batch/status?id=batch-1", mockMgr); code != 200 || !strings.Contains(body, `"done":2`) {
+		t.Errorf("/jobs/batch/status = %d %s; se esperaba 200 con los conteos", code, body)
+	}
+	if code, _ := HandleRequest("GET", "/jobs/batch/status?id=x", mockMgr); code != 404 {
+		t.Errorf("/jobs/batch/status (id inexistente) code = %d; se esperaba 404", code)
+	}
+	if code, body := HandleRequest("GET", "/jobs/batch/cancel?id=batch-1", mockMgr); code != 200 || !strings.Contains(body, `"canceled": 2`) {
+		t.Errorf("/jobs/batch/cancel = %d %s; se esperaba 200 con 2 cancelados", code, body)
+	}
+}
+
+// TestHandle_IdempotencyKey prueba que el header llegue como parámetro y que el conflicto sea 409
+func TestHandle_IdempotencyKey(t *testing.T) {
+	mockMgr := &mockManager{}
+	get := func(path, key string) HTTPResponse {
+		return Handle(&Request{Method: "GET", Path: path, Headers: map[string]string{"idempotency-key": key}}, mockMgr)
+	}
+
+	if resp := get("/jobs/submit?task=pi", "k1"); resp.StatusCode != 200 || !strings.Contains(resp.Body, "job-k1") {
+		t.Errorf("submit con header = %d %s; se esperaba el job de la key", resp.StatusCode, resp.Body)
+	}
+	// El parámetro tiene prioridad sobre el header
+	if resp := get("/jobs/submit?task=pi&idempotency_key=k2", "k1"); !strings.Contains(resp.Body, "job-k2") {
+		t.Errorf("submit con parámetro = %s; se esperaba el job de k2", resp.Body)
+	}
+	if resp := get("/jobs/submit?task=pi", "used"); resp.StatusCode != 409 {
+		t.Errorf("submit con key en conflicto code = %d; se esperaba 409", resp.StatusCode)
+	}
+	if raw := string(buildHTTPResponse(HTTPResponse{StatusCode: 409}, "")); !strings.HasPrefix(raw, "HTTP/1.0 409 Conflict") {
+		t.Errorf("línea de estado = %q; se esperaba 409 Conflict", raw)
+	}
+}
+
+// TestHandle_ResultCache prueba el header X-Cache de las rutas síncronas y las métricas
+func TestHandle_ResultCache(t *testing.T) {
+	mockMgr := &mockManager{}
+	get := func(path string) HTTPResponse {
+		return Handle(&Request{Method: "GET", Path: path}, mockMgr)
+	}
+
+	// Sin caché no hay header
+	if resp := get("/isprime?n=97"); resp.Headers["X-Cache"] != "" {
+		t.Errorf("sin caché X-Cache = %q; se esperaba vacío", resp.Headers["X-Cache"])
+	}
+
+	mockMgr.cache = jobs.NewResultCache(jobs.CacheConfig{MaxBytes: 1 << 20})
+	first := get("/isprime?n=97")
+	if first.StatusCode != 200 || first.Headers["X-Cache"] != "MISS" {
+		t.Fatalf("primera petición = %d X-Cache %q; se esperaba 200 MISS", first.StatusCode, first.Headers["X-Cache"])
+	}
+	second := get("/isprime?n=97")
+	if second.Headers["X-Cache"] != "HIT" || second.Body != first.Body {
+		t.Errorf("segunda petición X-Cache %q body %s; se esperaba HIT con el mismo cuerpo", second.Headers["X-Cache"], second.Body)
+	}
+	// Los errores no se cachean
+	get("/isprime?n=-1")
+	if resp := get("/isprime?n=-1"); resp.StatusCode != 400 || resp.Headers["X-Cache"] != "MISS" {
+		t.Errorf("error repetido = %d X-Cache %q; se esperaba 400 MISS", resp.StatusCode, resp.Headers["X-Cache"])
+	}
+
+	if code, body := HandleRequest("GET", "/metrics", mockMgr); code != 200 || !strings.Contains(body, `"hits":1`) {
+		t.Errorf("/metrics = %d %s; se esperaban las métricas de la caché", code, body)
+	}
+}
+
+// waitJob consulta /jobs/status hasta que el job llega a status
+func waitJob(t *testing.T, manager jobs.ManagerInterface, id, status string) map[string]any {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, body := HandleRequest("GET", "/jobs/status?id="+id, manager)
+		var j map[string]any
+		json.Unmarshal([]byte(body), &j)
+		if j["status"] == status {
+			return j
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s: %s; se esperaba status %s", id, body, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// submitID envía path a /jobs/submit y devuelve el job_id
+func submitID(t *testing.T, manager jobs.ManagerInterface, path string) string {
+	t.Helper()
+	code, body := HandleRequest("GET", path, manager)
+	var resp map[string]string
+	if code != 200 || json.Unmarshal([]byte(body), &resp) != nil || resp["job_id"] == "" {
+		t.Fatalf("%s = %d %s", path, code, body)
+	}
+	return resp["job_id"]
+}
+
+// TestHandle_SubmitCoalescesAcrossPrio prueba que los parámetros de ruteo
+// (prio, task) no lleguen al job ni impidan el coalescing
+func TestHandle_SubmitCoalescesAcrossPrio(t *testing.T) {
+	manager := jobs.NewManager("", time.Minute, time.Minute)
+	defer manager.Close()
+	release := make(chan struct{})
+	manager.Register("slow", func(ctx context.Context, params map[string]string, p *jobs.Progress) (any, error) {
+		<-release
+		return params["n"], nil
+	}, 1, 4, 5*time.Second, jobs.WithCoalescing(true))
+
+	leader := submitID(t, manager, "/jobs/submit?task=slow&n=1&prio=high")
+	waitJob(t, manager, leader, "running")
+	follower := submitID(t, manager, "/jobs/submit?task=slow&n=1")
+	j := waitJob(t, manager, follower, "running")
+	if j["coalesced_with"] != leader {
+		t.Errorf("coalesced_with = %v; se esperaba el líder %s", j["coalesced_with"], leader)
+	}
+	close(release)
+
+	j = waitJob(t, manager, leader, "done")
+	if params := j["params"].(map[string]any); len(params) != 1 || params["n"] != "1" {
+		t.Errorf("params del job = %v; se esperaba solo n", params)
+	}
+}
+
+// TestHandle_SubmitCacheIgnoresPrio prueba que un job asíncrono use el
+// resultado cacheado por otro que solo difiere en la prioridad
+func TestHandle_SubmitCacheIgnoresPrio(t *testing.T) {
+	manager := jobs.NewManager("", time.Minute, time.Minute)
+	defer manager.Close()
+	manager.SetResultCache(jobs.NewResultCache(jobs.CacheConfig{MaxBytes: 1 << 20}))
+	runs := 0
+	manager.Register("square", func(ctx context.Context, params map[string]string, p *jobs.Progress) (any, error) {
+		runs++
+		return params["digits"] + "^2", nil
+	}, 1, 4, 5*time.Second, jobs.WithCache(true))
+
+	first := submitID(t, manager, "/jobs/submit?task=square&digits=10&prio=high")
+	waitJob(t, manager, first, "done")
+	second := submitID(t, manager, "/jobs/submit?task=square&digits=10&prio=low")
+	j := waitJob(t, manager, second, "done")
+	if j["cached"] != true || j["result"] != "10^2" || runs != 1 {
+		t.Errorf("segundo job: cached %v, resultado %v, ejecuciones %d; se esperaba un acierto de caché", j["cached"], j["result"], runs)
+	}
+}
@@ -3,31 +3,63 @@
 package server
 
 import "fmt"
+import "sort"
 import "strings"
 
 func buildResponse(code int, body string, reqID string) string {
-	statusText := map[int]string{
-		200: "OK",
-		400: "Bad Request",
-		404: "Not Found",
-		500: "Internal Server Error",
-		503: "Service Unavailable", 
-	}[code]
+	return string(buildRawResponse(code, "application/json", []byte(body), reqID))
+}
 
-	bodyBytes := []byte(body)
-	contentLength := len(bodyBytes)
+// buildRawResponse arma la respuesta con un Content-Type arbitrario.
+// Se usa para los perfiles binarios (pprof, trace) del listener de administración.
+func buildRawResponse(code int, contentType string, bodyBytes []byte, reqID string) []byte {
+	return buildHTTPResponse(HTTPResponse{StatusCode: code, ContentType: contentType, Body: string(bodyBytes)}, reqID)
+}
+
+var statusTexts = map[int]string{
+	200: "OK",
+	400: "Bad Request",
+	404: "Not Found",
+	405: "Method Not Allowed",
+	409: "Conflict",
+	413: "Payload Too Large",
+	500: "Internal Server Error",
+	503: "Service Unavailable",
+}
+
+// buildHTTPResponse serializa una HTTPResponse, incluidos sus headers extra.
+func buildHTTPResponse(resp HTTPResponse, reqID string) []byte {
+	statusText := resp.StatusText
+	if statusText == "" {
+		statusText = statusTexts[resp.StatusCode]
+	}
+	contentType := resp.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
 
 	var b strings.Builder
-	fmt.Fprintf(&b, "HTTP/1.0 %d %s\r\n", code, statusText)
-	fmt.Fprintf(&b, "Content-Type: application/json\r\n")
-	fmt.Fprintf(&b, "Content-Length: %d\r\n", contentLength)
+	fmt.Fprintf(&b, "HTTP/1.0 %d %s\r\n", resp.StatusCode, statusText)
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(&b, "Content-Length: %d\r\n", len(resp.Body))
 	fmt.Fprintf(&b, "Connection: close\r\n")
-	
+
 	fmt.Fprintf(&b, "X-Request-Id: %s\r\n", reqID)
-	
+	for _, k := range sortedKeys(resp.Headers) {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, resp.Headers[k])
+	}
+
 	fmt.Fprintf(&b, "\r\n") // línea vacía requerida
-	b.Write(bodyBytes)
+	b.WriteString(resp.Body)
+
+	return []byte(b.String())
+}
 
-	return b.String()
-		
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
\ No newline at end of file
@@ -35,4 +35,17 @@ func TestBuildResponse(t *testing.T) {
 	if !strings.HasSuffix(response, body) {
 		t.Errorf("La respuesta no termina con el body '%s'. Respuesta: \n%s", body, response)
 	}
-}
\ No newline at end of file
+}
+func TestBuildHTTPResponse_Headers(t *testing.T) {
+	resp := string(buildHTTPResponse(HTTPResponse{
+		StatusCode: 413,
+		Body:       `{}`,
+		Headers:    map[string]string{"X-Cache": "HIT"},
+	}, "abc-123"))
+
+	for _, want := range []string{"HTTP/1.0 413 Payload Too Large", "Content-Type: application/json", "X-Cache: HIT\r\n"} {
+		if !strings.Contains(resp, want) {
+			t.Errorf("La respuesta no contiene '%s'. Respuesta: \n%s", want, resp)
+		}
+	}
+}
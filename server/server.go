@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync/atomic"
 )
 
 type Server struct {
@@ -37,6 +38,8 @@ func (s *Server) Start() {
 			continue
 		}
 
+		atomic.AddInt64(&connectionsHandled, 1)
+
 		b := make([]byte, 8)
 		rand.Read(b)
 		reqID := hex.EncodeToString(b)
@@ -50,24 +53,18 @@ func (s *Server) handleConnection(conn net.Conn, reqID string) {
 	defer conn.Close()
 	reader := bufio.NewReader(conn)
 
-	requestLine, err := reader.ReadString('\n')
+	req, err := readRequest(reader)
 	if err != nil {
 		fmt.Printf("[%s] Error al leer solicitud: %v\n", reqID, err)
-		return
-	}
-	method, path, version := parseRequestLine(requestLine)
-
-	for {
-		line, _ := reader.ReadString('\n')
-		if line == "\r\n" || line == "\n" {
-			break
+		if err == ErrBodyTooLarge {
+			conn.Write([]byte(buildResponse(413, fmt.Sprintf(`{"error": "%v"}`, err), reqID)))
 		}
+		return
 	}
 
-	fmt.Printf("[%s] %s %s %s\n", reqID, version, method, path)
-	statusCode, body := HandleRequest(method, path, s.Manager)
-	response := buildResponse(statusCode, body, reqID)
-	conn.Write([]byte(response))
+	fmt.Printf("[%s] %s %s %s\n", reqID, req.Version, req.Method, req.Path)
+	resp := Handle(req, s.Manager)
+	conn.Write(buildHTTPResponse(resp, reqID))
 }
 
 func parseRequestLine(line string) (method, path, version string) {
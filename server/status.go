@@ -0,0 +1,42 @@
+package server
+
+import (
+	"P1/jobs"
+	"encoding/json"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// serverStart es el instante de arranque del proceso, para el uptime.
+var serverStart = time.Now()
+
+// connectionsHandled cuenta las conexiones aceptadas por el listener público.
+var connectionsHandled int64
+
+// statusBody arma la respuesta de /status: uptime, pid del servidor,
+// colas y los workers de cada pool con su pid real (el del servidor para
+// los pools de goroutines, el de cada proceso hijo para los aislados).
+func statusBody(manager jobs.ManagerInterface) string {
+	uptime := time.Since(serverStart)
+	workers := manager.WorkerStatus()
+	active := 0
+	for _, list := range workers {
+		for _, w := range list {
+			if w.Status == "busy" {
+				active++
+			}
+		}
+	}
+	body, _ := json.Marshal(map[string]any{
+		"uptime":              uptime.Round(time.Second).String(),
+		"uptime_seconds":      int64(uptime.Seconds()),
+		"server_pid":          os.Getpid(),
+		"connections_handled": atomic.LoadInt64(&connectionsHandled),
+		"active_tasks":        active,
+		"queues_size":         manager.QueueSizes(),
+		"workers":             workers,
+		"time":                time.Now().Format(time.RFC3339),
+	})
+	return string(body)
+}
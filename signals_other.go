@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "P1/jobs"
+
+// SIGUSR1 no existe fuera de unix: el volcado solo está disponible
+// a través del listener de administración.
+func watchDebugSignal(manager jobs.ManagerInterface) {}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package main
+
+import (
+	"P1/jobs"
+	"P1/server"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// watchDebugSignal escribe un volcado del Manager en debug-dump-<ts>.json
+// cada vez que el proceso recibe SIGUSR1.
+func watchDebugSignal(manager jobs.ManagerInterface) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1)
+	go func() {
+		for range ch {
+			path := fmt.Sprintf("debug-dump-%s.json", time.Now().Format("20060102-150405"))
+			if err := server.WriteDebugDump(path, manager); err != nil {
+				fmt.Printf("[Debug] Error escribiendo volcado: %v\n", err)
+				continue
+			}
+			fmt.Printf("[Debug] Volcado escrito en %s\n", path)
+		}
+	}()
+}
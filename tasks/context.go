@@ -0,0 +1,108 @@
+package tasks
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// Cada cuántas iteraciones los bucles largos revisan el contexto.
+const ctxCheckEvery = 1 << 14
+
+// ProgressFunc recibe el avance de una tarea: done de total unidades
+// (bytes, filas, iteraciones...) y una descripción libre de la etapa.
+type ProgressFunc func(done, total int64, stage string)
+
+type progressKey struct{}
+
+// WithProgress asocia un ProgressFunc al contexto. Las funciones *Context
+// de este paquete lo usan para reportar su avance real.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress llama al ProgressFunc del contexto, si hay uno.
+func reportProgress(ctx context.Context, done, total int64, stage string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(done, total, stage)
+	}
+}
+
+// QuotaFunc recibe los bytes que una tarea está por usar de un recurso
+// (memoria o disco). Si devuelve un error, la tarea aborta con él.
+type QuotaFunc func(n int64) error
+
+type memoryKey struct{}
+type diskKey struct{}
+
+// WithMemoryQuota asocia al contexto la función que autoriza las reservas
+// grandes de memoria (matrices, archivos leídos completos).
+func WithMemoryQuota(ctx context.Context, fn QuotaFunc) context.Context {
+	return context.WithValue(ctx, memoryKey{}, fn)
+}
+
+// WithDiskQuota asocia al contexto la función que contabiliza los bytes
+// que la tarea escribe a disco.
+func WithDiskQuota(ctx context.Context, fn QuotaFunc) context.Context {
+	return context.WithValue(ctx, diskKey{}, fn)
+}
+
+// reserveMemory declara una reserva de n bytes ante la cuota del contexto.
+func reserveMemory(ctx context.Context, n int64) error {
+	if fn, ok := ctx.Value(memoryKey{}).(QuotaFunc); ok && fn != nil {
+		return fn(n)
+	}
+	return nil
+}
+
+// diskWriter devuelve w con sus escrituras contadas contra la cuota de
+// disco del contexto (w tal cual si no hay cuota).
+func diskWriter(ctx context.Context, w io.Writer) io.Writer {
+	if fn, ok := ctx.Value(diskKey{}).(QuotaFunc); ok && fn != nil {
+		return &quotaWriter{w: w, fn: fn}
+	}
+	return w
+}
+
+type quotaWriter struct {
+	w  io.Writer
+	fn QuotaFunc
+}
+
+func (q *quotaWriter) Write(p []byte) (int, error) {
+	if err := q.fn(int64(len(p))); err != nil {
+		return 0, err
+	}
+	return q.w.Write(p)
+}
+
+// ctxReader corta la lectura en cuanto el contexto se cancela, para que
+// las tareas IO-bound (io.Copy, bufio.Scanner) se detengan a tiempo.
+// Si total > 0, además reporta base+leídos sobre total como progreso.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+
+	base, read, total int64
+	stage             string
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	if n > 0 && c.total > 0 {
+		c.read += int64(n)
+		reportProgress(c.ctx, c.base+c.read, c.total, c.stage)
+	}
+	return n, err
+}
+
+// fileSize devuelve el tamaño del archivo abierto, o 0 si no se conoce.
+func fileSize(f *os.File) int64 {
+	if fi, err := f.Stat(); err == nil {
+		return fi.Size()
+	}
+	return 0
+}
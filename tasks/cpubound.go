@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
@@ -18,6 +19,11 @@ import (
 // IsPrime: simple prueba por división hasta sqrt(n).
 // Para n grandes y exigencias de performance, podés agregar Miller-Rabin.
 func IsPrime(n int64) (bool, error) {
+	return IsPrimeContext(context.Background(), n)
+}
+
+// IsPrimeContext es IsPrime con cancelación cooperativa.
+func IsPrimeContext(ctx context.Context, n int64) (bool, error) {
 	if n < 2 {
 		return false, nil
 	}
@@ -29,12 +35,23 @@ func IsPrime(n int64) (bool, error) {
 		if n%d == 0 {
 			return false, nil
 		}
+		if d%ctxCheckEvery == 1 {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+			reportProgress(ctx, d, limit, "divisiones de prueba")
+		}
 	}
 	return true, nil
 }
 
 // Factor: factorización por división trial (retorna slice de pares [prime,count]).
 func Factor(n int64) ([][]int64, error) {
+	return FactorContext(context.Background(), n)
+}
+
+// FactorContext es Factor con cancelación cooperativa.
+func FactorContext(ctx context.Context, n int64) ([][]int64, error) {
 	if n < 2 {
 		return nil, errors.New("n debe ser >= 2")
 	}
@@ -61,6 +78,11 @@ func Factor(n int64) ([][]int64, error) {
 			res = append(res, []int64{f, cnt})
 		}
 		f += 2
+		if f%ctxCheckEvery == 1 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
 	}
 	if n > 1 {
 		res = append(res, []int64{n, 1})
@@ -73,6 +95,12 @@ func Factor(n int64) ([][]int64, error) {
 // Es iterativo y usa big.Int para precisión arbitraria.
 // ---------------------------
 func PiDigits(digits int) (string, error) {
+	return PiDigitsContext(context.Background(), digits)
+}
+
+// PiDigitsContext es PiDigits con cancelación cooperativa: revisa el
+// contexto en cada iteración de la serie.
+func PiDigitsContext(ctx context.Context, digits int) (string, error) {
 	if digits <= 0 {
 		return "", fmt.Errorf("el número de dígitos debe ser mayor que cero")
 	}
@@ -91,7 +119,12 @@ func PiDigits(digits int) (string, error) {
 	kFact := big.NewFloat(1).SetPrec(prec)
 
 	neg1pow := 1.0
-	for k := 0; k < digits/14+1; k++ { // 14 dígitos por iteración aprox.
+	iterations := digits/14 + 1
+	for k := 0; k < iterations; k++ { // 14 dígitos por iteración aprox.
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		reportProgress(ctx, int64(k), int64(iterations), "serie de Chudnovsky")
 		t1 := new(big.Float).SetPrec(prec).Copy(sixKFact)
 		t2 := new(big.Float).SetPrec(prec).Mul(b, new(big.Float).SetPrec(prec).SetFloat64(float64(k)))
 		t2.Add(t2, a)
@@ -131,14 +164,26 @@ func PiDigits(digits int) (string, error) {
 // Mandelbrot: returns matrix[height][width] with iter count until escape (0..maxIter)
 // ---------------------------
 func Mandelbrot(width, height, maxIter int) ([][]int, error) {
+	return MandelbrotContext(context.Background(), width, height, maxIter)
+}
+
+// MandelbrotContext es Mandelbrot con cancelación cooperativa (por fila).
+func MandelbrotContext(ctx context.Context, width, height, maxIter int) ([][]int, error) {
 	if width <= 0 || height <= 0 || maxIter <= 0 {
 		return nil, errors.New("parametros invalidos")
 	}
 	// typical view: x from -2.5..1, y from -1..1
+	if err := reserveMemory(ctx, MandelbrotMemory(width, height)); err != nil {
+		return nil, err
+	}
 	xmin, xmax := -2.5, 1.0
 	ymin, ymax := -1.0, 1.0
 	result := make([][]int, height)
 	for j := 0; j < height; j++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		reportProgress(ctx, int64(j), int64(height), "calculando filas")
 		result[j] = make([]int, width)
 		y := ymin + (float64(j)/float64(height))*(ymax-ymin)
 		for i := 0; i < width; i++ {
@@ -163,9 +208,17 @@ func Mandelbrot(width, height, maxIter int) ([][]int, error) {
 // Matrix multiplication -> returns sha256 hex of result matrix flattened
 // ---------------------------
 func MatrixMul(size int, seed int64) (string, error) {
+	return MatrixMulContext(context.Background(), size, seed)
+}
+
+// MatrixMulContext es MatrixMul con cancelación cooperativa (por fila).
+func MatrixMulContext(ctx context.Context, size int, seed int64) (string, error) {
 	if size <= 0 {
 		return "", errors.New("size debe ser > 0")
 	}
+	if err := reserveMemory(ctx, MatrixMulMemory(size)); err != nil {
+		return "", err
+	}
 	r := rand.New(rand.NewSource(seed))
 	// generate matrices A and B
 	A := make([][]int64, size)
@@ -181,6 +234,10 @@ func MatrixMul(size int, seed int64) (string, error) {
 	// multiply C = A * B (naive)
 	C := make([][]int64, size)
 	for i := 0; i < size; i++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		reportProgress(ctx, int64(i), int64(size), "multiplicando filas")
 		C[i] = make([]int64, size)
 		for j := 0; j < size; j++ {
 			var sum int64 = 0
@@ -2,6 +2,7 @@ package tasks
 
 import (
 	"bufio"
+	"context"
 	"container/heap"
 	"compress/gzip"
 	"crypto/sha256"
@@ -31,6 +32,12 @@ type chunkInfo struct {
 }
 
 func SortFile(name, algo string) (sortedFile string, elapsedMs int64, err error) {
+	return SortFileContext(context.Background(), name, algo)
+}
+
+// SortFileContext es SortFile con cancelación cooperativa: la lectura de
+// la entrada y la mezcla de chunks se cortan cuando el contexto se cancela.
+func SortFileContext(ctx context.Context, name, algo string) (sortedFile string, elapsedMs int64, err error) {
 	start := time.Now()
 	// open input
 	f, err := os.Open(name)
@@ -46,7 +53,12 @@ func SortFile(name, algo string) (sortedFile string, elapsedMs int64, err error)
 	}
 	defer os.RemoveAll(tmpDir)
 
-	reader := bufio.NewScanner(f)
+	// El progreso total es 2*size: lectura/ordenamiento de la entrada y
+	// escritura (mezcla) de la salida.
+	size := fileSize(f)
+	total := 2 * size
+	in := &ctxReader{ctx: ctx, r: f, total: total, stage: "leyendo y ordenando chunks"}
+	reader := bufio.NewScanner(in)
 	// increase buffer for long lines
 	const maxBuf = 10 * 1024 * 1024
 	buf := make([]byte, 0, 64*1024)
@@ -55,8 +67,10 @@ func SortFile(name, algo string) (sortedFile string, elapsedMs int64, err error)
 	chunkPaths := []string{}
 	chunkSizeLimit := int64(20 * 1024 * 1024) // 20MB per chunk
 	if strings.ToLower(algo) == "quick" {
+		in.stage = "leyendo en memoria"
 		// read whole file in memory (may OOM)
 		var nums []int
+		reserved := 0 // capacidad de nums ya declarada a la cuota de memoria
 		for reader.Scan() {
 			line := strings.TrimSpace(reader.Text())
 			if line == "" {
@@ -67,18 +81,39 @@ func SortFile(name, algo string) (sortedFile string, elapsedMs int64, err error)
 				return "", 0, fmt.Errorf("line parse error: %v", e)
 			}
 			nums = append(nums, v)
+			if cap(nums) > reserved {
+				if err := reserveMemory(ctx, int64(cap(nums)-reserved)*8); err != nil {
+					return "", 0, err
+				}
+				reserved = cap(nums)
+			}
 		}
 		if err := reader.Err(); err != nil {
 			return "", 0, err
 		}
+		if err := ctx.Err(); err != nil {
+			return "", 0, err
+		}
+		reportProgress(ctx, size, total, "ordenando en memoria")
 		sort.Ints(nums)
 		out := name + ".sorted"
 		of, err := os.Create(out)
 		if err != nil {
 			return "", 0, err
 		}
-		for _, v := range nums {
-			fmt.Fprintln(of, v)
+		w := bufio.NewWriter(diskWriter(ctx, of))
+		for i, v := range nums {
+			if _, err := fmt.Fprintln(w, v); err != nil {
+				of.Close()
+				return "", 0, err
+			}
+			if i%ctxCheckEvery == 0 {
+				reportProgress(ctx, size+int64(i)*size/int64(len(nums)), total, "escribiendo salida")
+			}
+		}
+		if err := w.Flush(); err != nil {
+			of.Close()
+			return "", 0, err
 		}
 		of.Close()
 		return out, time.Since(start).Milliseconds(), nil
@@ -101,18 +136,19 @@ func SortFile(name, algo string) (sortedFile string, elapsedMs int64, err error)
 		if curBytes >= chunkSizeLimit {
 			sort.Ints(curChunk)
 			chunkFile := filepath.Join(tmpDir, fmt.Sprintf("chunk-%d.tmp", len(chunkPaths)))
-			if err := writeIntSlice(chunkFile, curChunk); err != nil {
+			if err := writeIntSlice(ctx, chunkFile, curChunk); err != nil {
 				return "", 0, err
 			}
 			chunkPaths = append(chunkPaths, chunkFile)
 			curChunk = nil
 			curBytes = 0
+			in.stage = fmt.Sprintf("leyendo y ordenando chunks (%d escritos)", len(chunkPaths))
 		}
 	}
 	if len(curChunk) > 0 {
 		sort.Ints(curChunk)
 		chunkFile := filepath.Join(tmpDir, fmt.Sprintf("chunk-%d.tmp", len(chunkPaths)))
-		if err := writeIntSlice(chunkFile, curChunk); err != nil {
+		if err := writeIntSlice(ctx, chunkFile, curChunk); err != nil {
 			return "", 0, err
 		}
 		chunkPaths = append(chunkPaths, chunkFile)
@@ -139,7 +175,11 @@ func SortFile(name, algo string) (sortedFile string, elapsedMs int64, err error)
 	}
 	defer outFile.Close()
 
-	if err := kWayMerge(chunkPaths, outFile); err != nil {
+	stage := fmt.Sprintf("merge de %d chunks", len(chunkPaths))
+	progress := func(written int64) {
+		reportProgress(ctx, size+written, total, stage)
+	}
+	if err := kWayMerge(ctx, chunkPaths, diskWriter(ctx, outFile), progress); err != nil {
 		return "", 0, err
 	}
 
@@ -147,15 +187,17 @@ func SortFile(name, algo string) (sortedFile string, elapsedMs int64, err error)
 	return outName, elapsed, nil
 }
 
-func writeIntSlice(path string, data []int) error {
+func writeIntSlice(ctx context.Context, path string, data []int) error {
 	of, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer of.Close()
-	w := bufio.NewWriter(of)
+	w := bufio.NewWriter(diskWriter(ctx, of))
 	for _, v := range data {
-		fmt.Fprintln(w, v)
+		if _, err := fmt.Fprintln(w, v); err != nil {
+			return err
+		}
 	}
 	return w.Flush()
 }
@@ -181,7 +223,8 @@ func (h *minHeap) Pop() interface{} {
 	return x
 }
 
-func kWayMerge(chunkPaths []string, out io.Writer) error {
+// progress recibe los bytes escritos hasta el momento.
+func kWayMerge(ctx context.Context, chunkPaths []string, out io.Writer, progress func(written int64)) error {
 	h := &minHeap{}
 	heap.Init(h)
 	// open all chunk files
@@ -206,13 +249,33 @@ func kWayMerge(chunkPaths []string, out io.Writer) error {
 			f.Close()
 		}
 	}
+	// cerrar los chunks que queden abiertos si la mezcla se interrumpe
+	defer func() {
+		for _, fs := range *h {
+			fs.f.Close()
+		}
+	}()
+
 	w := bufio.NewWriter(out)
+	lines, written := 0, int64(0)
 	for h.Len() > 0 {
+		lines++
+		if lines%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			progress(written)
+		}
 		fs := heap.Pop(h).(*fileScanner)
-		fmt.Fprintln(w, fs.val)
+		n, err := fmt.Fprintln(w, fs.val)
+		if err != nil {
+			return err
+		}
+		written += int64(n)
 		if fs.sc.Scan() {
 			v, err := strconv.Atoi(strings.TrimSpace(fs.sc.Text()))
 			if err != nil {
+				fs.f.Close()
 				return err
 			}
 			fs.val = v
@@ -228,13 +291,18 @@ func kWayMerge(chunkPaths []string, out io.Writer) error {
 // WordCount: lines, words, bytes (streaming)
 // ---------------------------
 func WordCount(name string) (lines, words, bytesCount int64, err error) {
+	return WordCountContext(context.Background(), name)
+}
+
+// WordCountContext es WordCount con cancelación cooperativa.
+func WordCountContext(ctx context.Context, name string) (lines, words, bytesCount int64, err error) {
 	f, err := os.Open(name)
 	if err != nil {
 		return 0, 0, 0, err
 	}
 	defer f.Close()
 
-	r := bufio.NewReader(f)
+	r := bufio.NewReader(&ctxReader{ctx: ctx, r: f, total: fileSize(f), stage: "contando"})
 	var inWord bool
 	for {
 		buf := make([]byte, 32*1024)
@@ -271,6 +339,11 @@ func WordCount(name string) (lines, words, bytesCount int64, err error) {
 // Grep: regex search, return count and first up to 10 matched lines
 // ---------------------------
 func Grep(name, pattern string) (count int64, matched []string, err error) {
+	return GrepContext(context.Background(), name, pattern)
+}
+
+// GrepContext es Grep con cancelación cooperativa.
+func GrepContext(ctx context.Context, name, pattern string) (count int64, matched []string, err error) {
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return 0, nil, err
@@ -281,7 +354,7 @@ func Grep(name, pattern string) (count int64, matched []string, err error) {
 	}
 	defer f.Close()
 
-	sc := bufio.NewScanner(f)
+	sc := bufio.NewScanner(&ctxReader{ctx: ctx, r: f, total: fileSize(f), stage: "buscando"})
 	for sc.Scan() {
 		line := sc.Text()
 		if re.FindStringIndex(line) != nil {
@@ -302,6 +375,12 @@ func Grep(name, pattern string) (count int64, matched []string, err error) {
 // Returns output filename and size in bytes.
 // ---------------------------
 func Compress(name, codec string) (outName string, outSize int64, err error) {
+	return CompressContext(context.Background(), name, codec)
+}
+
+// CompressContext es Compress con cancelación cooperativa: gzip deja de
+// leer la entrada y xz se mata con el contexto.
+func CompressContext(ctx context.Context, name, codec string) (outName string, outSize int64, err error) {
 	switch strings.ToLower(codec) {
 	case "gzip", "gz":
 		in, err := os.Open(name)
@@ -315,9 +394,9 @@ func Compress(name, codec string) (outName string, outSize int64, err error) {
 			return "", 0, err
 		}
 		defer out.Close()
-		gw := gzip.NewWriter(out)
+		gw := gzip.NewWriter(diskWriter(ctx, out))
 		defer gw.Close()
-		if _, err := io.Copy(gw, in); err != nil {
+		if _, err := io.Copy(gw, &ctxReader{ctx: ctx, r: in, total: fileSize(in), stage: "comprimiendo (gzip)"}); err != nil {
 			return "", 0, err
 		}
 		if fi, err := os.Stat(outName); err == nil {
@@ -327,13 +406,13 @@ func Compress(name, codec string) (outName string, outSize int64, err error) {
 	case "xz":
 		// require 'xz' command available on system
 		outName = name + ".xz"
-		cmd := exec.Command("xz", "-k", "-c", name) // -k keep input
+		cmd := exec.CommandContext(ctx, "xz", "-k", "-c", name) // -k keep input
 		outfile, err := os.Create(outName)
 		if err != nil {
 			return "", 0, err
 		}
 		defer outfile.Close()
-		cmd.Stdout = outfile
+		cmd.Stdout = diskWriter(ctx, outfile)
 		if err := cmd.Run(); err != nil {
 			return "", 0, fmt.Errorf("error running xz: %v", err)
 		}
@@ -350,14 +429,18 @@ func Compress(name, codec string) (outName string, outSize int64, err error) {
 // HashFile: sha256 streaming
 // ---------------------------
 func HashFile(name string) (string, error) {
-	
+	return HashFileContext(context.Background(), name)
+}
+
+// HashFileContext es HashFile con cancelación cooperativa.
+func HashFileContext(ctx context.Context, name string) (string, error) {
 	f, err := os.Open(name)
 	if err != nil {
 		return "", err
 	}
 	defer f.Close()
 	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
+	if _, err := io.Copy(h, &ctxReader{ctx: ctx, r: f, total: fileSize(f), stage: "calculando sha256"}); err != nil {
 		return "", err
 	}
 	return hex.EncodeToString(h.Sum(nil)), nil
@@ -2,6 +2,8 @@ package tasks
 
 import (
 	"bufio"   
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"sort"    
@@ -157,4 +159,62 @@ func checkFileIsSorted(t *testing.T, path string) {
 	if len(nums) != 5 || nums[0] != 1 || nums[4] != 10 {
 		t.Errorf("checkFileIsSorted: Contenido inesperado. Se esperaban 5 elementos ordenados de 1 a 10. Se obtuvo: %v", nums)
 	}
-}
\ No newline at end of file
+}
+// TestHashFileContext_Progress prueba que HashFileContext reporte el avance por bytes
+func TestHashFileContext_Progress(t *testing.T) {
+	path := createTempFile(t, strings.Repeat("0123456789\n", 1000))
+
+	var lastDone, lastTotal int64
+	ctx := WithProgress(context.Background(), func(done, total int64, stage string) {
+		lastDone, lastTotal = done, total
+	})
+	if _, err := HashFileContext(ctx, path); err != nil {
+		t.Fatalf("HashFileContext devolvió un error: %v", err)
+	}
+	if lastTotal != 11000 || lastDone != lastTotal {
+		t.Errorf("Progreso final = %d/%d; se esperaba 11000/11000", lastDone, lastTotal)
+	}
+}
+
+// TestSortFileContext_Canceled prueba que SortFile se detenga con el contexto cancelado
+func TestSortFileContext_Canceled(t *testing.T) {
+	path := createTempFile(t, "3\n1\n2\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := SortFileContext(ctx, path, "merge"); err != context.Canceled {
+		t.Errorf("SortFileContext err = %v; se esperaba %v", err, context.Canceled)
+	}
+}
+
+// TestSortFileContext_Quotas prueba que las cuotas de memoria y disco del contexto aborten el ordenamiento
+func TestSortFileContext_Quotas(t *testing.T) {
+	var lines strings.Builder
+	for i := 1000; i > 0; i-- {
+		lines.WriteString(strconv.Itoa(i) + "\n")
+	}
+	path := createTempFile(t, lines.String())
+	errQuota := errors.New("cuota excedida")
+	quota := func(max int64) QuotaFunc {
+		var used int64
+		return func(n int64) error {
+			if used += n; used > max {
+				return errQuota
+			}
+			return nil
+		}
+	}
+
+	ctx := WithMemoryQuota(context.Background(), quota(1024))
+	if _, _, err := SortFileContext(ctx, path, "quick"); !errors.Is(err, errQuota) {
+		t.Errorf("quick con 1 KB de memoria: err = %v; se esperaba la cuota", err)
+	}
+	ctx = WithDiskQuota(context.Background(), quota(1024))
+	if _, _, err := SortFileContext(ctx, path, "merge"); !errors.Is(err, errQuota) {
+		t.Errorf("merge con 1 KB de disco: err = %v; se esperaba la cuota", err)
+	}
+	ctx = WithDiskQuota(context.Background(), quota(1<<20))
+	if _, _, err := SortFileContext(ctx, path, "quick"); err != nil {
+		t.Errorf("quick con cuota suficiente: %v", err)
+	}
+}
@@ -1,5 +1,6 @@
 package tasks
 
+import "context"
 import "strings"
 import "os"
 import "time"
@@ -32,14 +33,25 @@ func Fibonacci(n int) int { // calcula el número n de la serie Fibonacci
 // funciones de archivos 
 
 func CreateFile(name, content string, repeat int) error { //  crea un archivo con contenido repetido 'repeat' veces
+	return CreateFileContext(context.Background(), name, content, repeat)
+}
+
+func CreateFileContext(ctx context.Context, name, content string, repeat int) error { // CreateFile con cancelación cooperativa
 	file, err := os.Create(name)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
+	w := diskWriter(ctx, file)
+	line := []byte(content + "\n")
 	for i := 0; i < repeat; i++ {
-		_, err := file.WriteString(content + "\n")
+		if i%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		_, err := w.Write(line)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,49 @@
+package tasks
+
+import (
+	"os"
+	"strings"
+)
+
+// Estimaciones de recursos de las tareas, en bytes, para rechazar un job
+// antes de ejecutarlo. Son cotas aproximadas; durante la ejecución las
+// tareas declaran lo que realmente reservan y escriben (ver context.go).
+
+// MandelbrotMemory es la matriz de iteraciones (un int por píxel).
+func MandelbrotMemory(width, height int) int64 {
+	return int64(width) * int64(height) * 8
+}
+
+// MandelbrotOutput es el JSON de la matriz: hasta 4 bytes por píxel
+// ("255," en el peor caso típico).
+func MandelbrotOutput(width, height int) int64 {
+	return int64(width) * int64(height) * 4
+}
+
+// MatrixMulMemory son las tres matrices size x size de int64 (A, B y C).
+func MatrixMulMemory(size int) int64 {
+	return 3 * int64(size) * int64(size) * 8
+}
+
+// SortFileUsage estima la memoria y el disco de SortFile sobre name. Con
+// "quick" la entrada completa se carga como []int: en el peor caso (un
+// dígito por línea) son 4 veces su tamaño, y el crecimiento del slice
+// puede duplicarlo. La mezcla externa escribe los chunks y la salida.
+// Si el archivo no existe devuelve ceros: la tarea fallará al abrirlo.
+func SortFileUsage(name, algo string) (memory, disk int64) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return 0, 0
+	}
+	size := fi.Size()
+	if strings.ToLower(algo) == "quick" {
+		return 8 * size, size
+	}
+	const chunk = 20 << 20 // ver chunkSizeLimit en SortFileContext
+	return 8 * chunk, 2 * size
+}
+
+// CreateFileUsage es el tamaño del archivo que escribe CreateFile.
+func CreateFileUsage(content string, repeat int) int64 {
+	return int64(len(content)+1) * int64(repeat)
+}